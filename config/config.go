@@ -0,0 +1,564 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets when the configuration is printed.
+// redacted substitui os segredos quando a configuração é impressa.
+const redacted = "[REDACTED]"
+
+// Config is the whole application configuration. It starts from Default, is overlaid by an
+// optional YAML or JSON file and then by the environment (including .env), and is validated
+// once at startup.
+// Config é toda a configuração da aplicação. Ela parte de Default, é sobreposta por um arquivo
+// YAML ou JSON opcional e depois pelo ambiente (incluindo o .env), e é validada uma vez na
+// inicialização.
+type Config struct {
+	Port    string        `yaml:"port"`    // HTTP server port
+	Server  ServerConfig  `yaml:"server"`  // HTTP server timeouts and shutdown
+	Weather WeatherConfig `yaml:"weather"` // Weather providers, strategy, hedging and cache
+	CEP     CEPConfig     `yaml:"cep"`     // CEP providers, lookup and cache
+	Breaker BreakerConfig `yaml:"breaker"` // Circuit breaker of each upstream host
+	Retry   RetryConfig   `yaml:"retry"`   // Retries of the upstream calls
+	Health  HealthConfig  `yaml:"health"`  // Readiness checks
+	Tracing TracingConfig `yaml:"tracing"` // Distributed tracing
+	Logging LoggingConfig `yaml:"logging"` // Structured logs
+	Auth    AuthConfig    `yaml:"auth"`    // API keys and quotas
+	Admin   AdminConfig   `yaml:"admin"`   // Operational endpoints under /admin
+}
+
+// TracingExporters are the accepted values of tracing.exporter.
+// TracingExporters são os valores aceitos em tracing.exporter.
+var TracingExporters = []string{"none", "stdout", "otlp"}
+
+// LogFormats are the accepted values of logging.format.
+// LogFormats são os valores aceitos em logging.format.
+var LogFormats = []string{"json", "text"}
+
+// ServerConfig configures the HTTP server timeouts and its shutdown.
+// ServerConfig configura os timeouts do servidor HTTP e o seu desligamento.
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"` // Maximum time to read the request headers
+	ReadTimeout       time.Duration `yaml:"read_timeout"`        // Maximum time to read the whole request
+	WriteTimeout      time.Duration `yaml:"write_timeout"`       // Maximum time to write the response
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`        // How long an idle keep-alive connection is kept
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`    // How long in-flight requests may take on shutdown
+}
+
+// WeatherConfig configures the weather providers and how they are combined.
+// WeatherConfig configura os provedores de clima e como eles são combinados.
+type WeatherConfig struct {
+	Providers          []string           `yaml:"providers"`           // Weather providers in priority order
+	Strategy           string             `yaml:"strategy"`            // How several providers are combined
+	ProviderTimeout    time.Duration      `yaml:"provider_timeout"`    // Maximum time of each provider in failover and consensus
+	ConsensusThreshold float64            `yaml:"consensus_threshold"` // Distance in °C from the median that makes a provider an outlier
+	WeatherAPIKey      string             `yaml:"weatherapi_key"`      // weatherapi.com API key, secret
+	OpenWeatherMapKey  string             `yaml:"openweathermap_key"`  // OpenWeatherMap API key, secret
+	BaseURLs           WeatherBaseURLs    `yaml:"base_urls"`           // Provider endpoints, replaceable by local stand-ins
+	Hedge              HedgeConfig        `yaml:"hedge"`               // Request hedging
+	Cache              WeatherCacheConfig `yaml:"cache"`               // Weather cache
+}
+
+// WeatherBaseURLs holds the base URL of each weather provider endpoint.
+// WeatherBaseURLs contém a URL base de cada endpoint dos provedores de clima.
+type WeatherBaseURLs struct {
+	WeatherAPI         string `yaml:"weatherapi"`
+	OpenMeteo          string `yaml:"openmeteo"`
+	OpenMeteoArchive   string `yaml:"openmeteo_archive"`
+	OpenMeteoGeocoding string `yaml:"openmeteo_geocoding"`
+	OpenWeatherMap     string `yaml:"openweathermap"`
+}
+
+// HedgeConfig configures request hedging on the weather call.
+// HedgeConfig configura as requisições de reserva na chamada de clima.
+type HedgeConfig struct {
+	Percentile float64       `yaml:"percentile"` // Latency percentile after which the hedge is sent, 0 disables hedging
+	Provider   string        `yaml:"provider"`   // Provider that gets the hedge, empty for the same one
+	MinDelay   time.Duration `yaml:"min_delay"`  // Shortest wait before hedging
+	MaxDelay   time.Duration `yaml:"max_delay"`  // Longest wait before hedging
+}
+
+// WeatherCacheConfig configures the weather cache.
+// WeatherCacheConfig configura o cache de clima.
+type WeatherCacheConfig struct {
+	TTL    time.Duration `yaml:"ttl"`     // Freshness of an observation, 0 disables the cache
+	MinTTL time.Duration `yaml:"min_ttl"` // Minimum time an observation stays cached
+	Size   int           `yaml:"size"`    // Maximum number of cached cities
+}
+
+// CEPConfig configures the CEP providers and lookups.
+// CEPConfig configura os provedores e as buscas de CEP.
+type CEPConfig struct {
+	Providers     []string       `yaml:"providers"`      // Enabled CEP providers in lookup order
+	DatabaseFile  string         `yaml:"database_file"`  // JSON file backing the "local" provider
+	LookupTimeout time.Duration  `yaml:"lookup_timeout"` // Maximum time of a CEP lookup
+	Pattern       string         `yaml:"pattern"`        // Regular expression a CEP must match
+	BaseURLs      CEPBaseURLs    `yaml:"base_urls"`      // Provider endpoints, replaceable by local stand-ins
+	Cache         CEPCacheConfig `yaml:"cache"`          // CEP cache
+}
+
+// CEPBaseURLs holds the base URL of each CEP provider endpoint.
+// CEPBaseURLs contém a URL base de cada endpoint dos provedores de CEP.
+type CEPBaseURLs struct {
+	BrasilAPI  string `yaml:"brasilapi"`
+	ViaCEP     string `yaml:"viacep"`
+	OpenCEP    string `yaml:"opencep"`
+	AwesomeAPI string `yaml:"awesomeapi"`
+}
+
+// CEPCacheConfig configures the CEP cache.
+// CEPCacheConfig configura o cache de CEP.
+type CEPCacheConfig struct {
+	TTL         time.Duration `yaml:"ttl"`          // How long a found CEP is served, 0 disables the cache
+	StaleTTL    time.Duration `yaml:"stale_ttl"`    // Extra time an expired CEP is served while refreshed
+	NegativeTTL time.Duration `yaml:"negative_ttl"` // How long an unknown CEP is remembered
+	Size        int           `yaml:"size"`         // Maximum number of cached CEPs
+}
+
+// BreakerConfig configures the circuit breaker of each upstream host.
+// BreakerConfig configura o circuit breaker de cada host de origem.
+type BreakerConfig struct {
+	FailureRate    float64       `yaml:"failure_rate"`     // Failure rate that opens a breaker, 0 disables the breakers
+	MinRequests    int           `yaml:"min_requests"`     // Calls needed in a window before the rate is considered
+	Window         time.Duration `yaml:"window"`           // Window over which calls are counted
+	Cooldown       time.Duration `yaml:"cooldown"`         // How long a breaker stays open
+	HalfOpenProbes int           `yaml:"half_open_probes"` // Concurrent probes while half-open
+}
+
+// RetryConfig configures the retries of the upstream calls.
+// RetryConfig configura as novas tentativas das chamadas às origens.
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"`    // Attempts per call, 1 disables retries
+	AttemptTimeout time.Duration `yaml:"attempt_timeout"` // Maximum time of each attempt
+	BaseDelay      time.Duration `yaml:"base_delay"`      // Backoff before the first retry
+	MaxDelay       time.Duration `yaml:"max_delay"`       // Upper bound of the backoff
+	Budget         time.Duration `yaml:"budget"`          // Maximum total time of a call
+}
+
+// HealthConfig configures the readiness checks.
+// HealthConfig configura as verificações de prontidão.
+type HealthConfig struct {
+	ProbeUpstreams bool          `yaml:"probe_upstreams"` // Whether readiness also calls the CEP and weather providers
+	CacheTTL       time.Duration `yaml:"cache_ttl"`       // How long check results are reused
+	Timeout        time.Duration `yaml:"timeout"`         // Maximum time of each check
+}
+
+// TracingConfig configures distributed tracing.
+// TracingConfig configura o tracing distribuído.
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter"`      // Where spans go: "none", "stdout" or "otlp"
+	OTLPEndpoint string  `yaml:"otlp_endpoint"` // Base URL of the OTLP/HTTP collector
+	ServiceName  string  `yaml:"service_name"`  // service.name reported to the collector
+	SampleRatio  float64 `yaml:"sample_ratio"`  // Fraction of the new traces recorded
+}
+
+// LoggingConfig configures the structured logs.
+// LoggingConfig configura os logs estruturados.
+type LoggingConfig struct {
+	Level     string `yaml:"level"`      // Lowest level written: "debug", "info", "warn" or "error"
+	Format    string `yaml:"format"`     // "json" for Cloud Logging or "text" for a terminal
+	ProjectID string `yaml:"project_id"` // Google Cloud project that links the log entries to their trace
+}
+
+// AuthConfig configures the API keys. Authentication is enabled when at least one key is
+// configured, inline or in KeysFile. The usage is counted in Redis when RedisAddr is set, so
+// every instance enforces the same quotas, and in the memory of each instance otherwise.
+// AuthConfig configura as chaves de API. A autenticação é habilitada quando ao menos uma chave
+// é configurada, diretamente ou em KeysFile. O uso é contado no Redis quando RedisAddr é
+// definido, para que todas as instâncias apliquem as mesmas cotas, e na memória de cada
+// instância caso contrário.
+type AuthConfig struct {
+	Keys          []APIKeyConfig `yaml:"keys"`           // Keys accepted by the API
+	KeysFile      string         `yaml:"keys_file"`      // YAML or JSON list of more keys, e.g. a mounted secret
+	DailyQuota    int            `yaml:"daily_quota"`    // Requests per UTC day of the keys without their own, 0 for unlimited
+	MonthlyQuota  int            `yaml:"monthly_quota"`  // Requests per UTC month of the keys without their own, 0 for unlimited
+	RedisAddr     string         `yaml:"redis_addr"`     // host:port of the Redis server shared by the instances, empty for in-memory counters
+	RedisPassword string         `yaml:"redis_password"` // Redis AUTH password, secret
+}
+
+// APIKeyConfig is one API key and the quotas of its client.
+// APIKeyConfig é uma chave de API e as cotas do seu cliente.
+type APIKeyConfig struct {
+	Client       string `yaml:"client"`        // Name shown in /admin/usage
+	Key          string `yaml:"key"`           // Secret sent in X-API-Key or api_key
+	DailyQuota   int    `yaml:"daily_quota"`   // Requests per UTC day, 0 for the auth.daily_quota
+	MonthlyQuota int    `yaml:"monthly_quota"` // Requests per UTC month, 0 for the auth.monthly_quota
+	Admin        bool   `yaml:"admin"`         // Whether the key can reach /admin
+}
+
+// AdminConfig configures the operational endpoints under /admin, which expose the upstream
+// hosts and their failures and are therefore off unless enabled, and then need an admin key.
+// AdminConfig configura os endpoints operacionais em /admin, que expõem os hosts de origem e
+// suas falhas e por isso ficam desligados a menos que habilitados, e então exigem uma chave
+// de administrador.
+type AdminConfig struct {
+	Enabled bool `yaml:"enabled"` // Whether the /admin endpoints are served
+}
+
+// Default returns the configuration used when nothing is configured.
+// Retorna a configuração usada quando nada é configurado.
+func Default() Config {
+	return Config{
+		Port: "8080",
+		Server: ServerConfig{
+			ReadHeaderTimeout: shared.DefaultServerConfig.ReadHeaderTimeout,
+			ReadTimeout:       shared.DefaultServerConfig.ReadTimeout,
+			WriteTimeout:      shared.DefaultServerConfig.WriteTimeout,
+			IdleTimeout:       shared.DefaultServerConfig.IdleTimeout,
+			ShutdownTimeout:   shared.DefaultServerConfig.ShutdownTimeout,
+		},
+		Weather: WeatherConfig{
+			Providers:          []string{services.DefaultWeatherProvider},
+			Strategy:           services.WeatherStrategyFailover,
+			ProviderTimeout:    services.DefaultWeatherStrategyConfig.AttemptTimeout,
+			ConsensusThreshold: services.DefaultWeatherStrategyConfig.OutlierThresholdC,
+			BaseURLs: WeatherBaseURLs{
+				WeatherAPI:         services.DefaultWeatherAPIBaseURL,
+				OpenMeteo:          services.DefaultOpenMeteoBaseURL,
+				OpenMeteoArchive:   services.DefaultOpenMeteoArchiveURL,
+				OpenMeteoGeocoding: services.DefaultOpenMeteoGeocodingURL,
+				OpenWeatherMap:     services.DefaultOpenWeatherMapBaseURL,
+			},
+			Hedge: HedgeConfig{
+				Percentile: services.DefaultHedgeConfig.Percentile,
+				MinDelay:   services.DefaultHedgeConfig.MinDelay,
+				MaxDelay:   services.DefaultHedgeConfig.MaxDelay,
+			},
+			Cache: WeatherCacheConfig{
+				TTL:    services.DefaultWeatherCacheConfig.TTL,
+				MinTTL: services.DefaultWeatherCacheConfig.MinTTL,
+				Size:   services.DefaultWeatherCacheConfig.Size,
+			},
+		},
+		CEP: CEPConfig{
+			Providers:     slices.Clone(services.DefaultCEPProviders),
+			LookupTimeout: services.DefaultLocationLookupTimeout,
+			Pattern:       shared.DefaultCepPattern,
+			BaseURLs: CEPBaseURLs{
+				BrasilAPI:  services.DefaultBrasilAPIBaseURL,
+				ViaCEP:     services.DefaultViaCEPBaseURL,
+				OpenCEP:    services.DefaultOpenCEPBaseURL,
+				AwesomeAPI: services.DefaultAwesomeAPIBaseURL,
+			},
+			Cache: CEPCacheConfig{
+				TTL:         services.DefaultLocationCacheConfig.TTL,
+				StaleTTL:    services.DefaultLocationCacheConfig.StaleTTL,
+				NegativeTTL: services.DefaultLocationCacheConfig.NegativeTTL,
+				Size:        services.DefaultLocationCacheConfig.Size,
+			},
+		},
+		Breaker: BreakerConfig{
+			FailureRate:    services.DefaultBreakerConfig.FailureRate,
+			MinRequests:    services.DefaultBreakerConfig.MinRequests,
+			Window:         services.DefaultBreakerConfig.Window,
+			Cooldown:       services.DefaultBreakerConfig.Cooldown,
+			HalfOpenProbes: services.DefaultBreakerConfig.HalfOpenProbes,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:    services.DefaultRetryConfig.MaxAttempts,
+			AttemptTimeout: services.DefaultRetryConfig.AttemptTimeout,
+			BaseDelay:      services.DefaultRetryConfig.BaseDelay,
+			MaxDelay:       services.DefaultRetryConfig.MaxDelay,
+			Budget:         services.DefaultRetryConfig.Budget,
+		},
+		Health: HealthConfig{
+			CacheTTL: services.DefaultHealthConfig.CacheTTL,
+			Timeout:  services.DefaultHealthConfig.Timeout,
+		},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			OTLPEndpoint: "http://localhost:4318",
+			ServiceName:  "weather-api",
+			SampleRatio:  1,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the file at path (or CONFIG_FILE when path
+// is empty; no file is read when both are empty) and the environment, then validates it.
+// Monta a configuração a partir dos padrões, do arquivo em path (ou CONFIG_FILE quando path está
+// vazio; nenhum arquivo é lido quando ambos estão vazios) e do ambiente, e então a valida.
+func Load(path string) (Config, error) {
+	config := Default()
+	if path == "" {
+		path = getEnv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return config, err
+		}
+	}
+	if err := config.loadEnv(); err != nil {
+		return config, err
+	}
+	if config.Auth.KeysFile != "" {
+		if err := config.loadKeysFile(config.Auth.KeysFile); err != nil {
+			return config, err
+		}
+	}
+	return config, config.Validate()
+}
+
+// loadFile overlays the configuration with a YAML file. JSON is valid YAML, so JSON files
+// are read the same way. Unknown keys are rejected to catch typos.
+// Sobrepõe a configuração com um arquivo YAML. JSON é YAML válido, então arquivos JSON são
+// lidos da mesma forma. Chaves desconhecidas são rejeitadas para pegar erros de digitação.
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err) // An empty file is fine
+	}
+	return nil
+}
+
+// loadKeysFile appends the API keys of a YAML or JSON file to the configured ones.
+// Acrescenta as chaves de API de um arquivo YAML ou JSON às configuradas.
+func (c *Config) loadKeysFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("API keys file: %w", err)
+	}
+	defer file.Close()
+
+	var keys []APIKeyConfig
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&keys); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("API keys file %s: %w", path, err)
+	}
+	c.Auth.Keys = append(c.Auth.Keys, keys...)
+	return nil
+}
+
+// loadEnv overlays the configuration with the environment variables that are set.
+// Sobrepõe a configuração com as variáveis de ambiente que estão definidas.
+func (c *Config) loadEnv() error {
+	env := &envLoader{}
+	env.string("PORT", &c.Port)
+	env.duration("SERVER_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout)
+	env.duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.duration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+
+	env.list("WEATHER_PROVIDER", &c.Weather.Providers)
+	env.string("WEATHER_STRATEGY", &c.Weather.Strategy)
+	env.duration("WEATHER_PROVIDER_TIMEOUT", &c.Weather.ProviderTimeout)
+	env.float("WEATHER_CONSENSUS_THRESHOLD", &c.Weather.ConsensusThreshold)
+	env.string("WEATHER_API_KEY", &c.Weather.WeatherAPIKey)
+	env.string("OPENWEATHERMAP_API_KEY", &c.Weather.OpenWeatherMapKey)
+	env.string("WEATHER_API_BASE_URL", &c.Weather.BaseURLs.WeatherAPI)
+	env.string("OPEN_METEO_BASE_URL", &c.Weather.BaseURLs.OpenMeteo)
+	env.string("OPEN_METEO_ARCHIVE_URL", &c.Weather.BaseURLs.OpenMeteoArchive)
+	env.string("OPEN_METEO_GEOCODING_URL", &c.Weather.BaseURLs.OpenMeteoGeocoding)
+	env.string("OPENWEATHERMAP_BASE_URL", &c.Weather.BaseURLs.OpenWeatherMap)
+	env.float("WEATHER_HEDGE_PERCENTILE", &c.Weather.Hedge.Percentile)
+	env.string("WEATHER_HEDGE_PROVIDER", &c.Weather.Hedge.Provider)
+	env.duration("WEATHER_HEDGE_MIN_DELAY", &c.Weather.Hedge.MinDelay)
+	env.duration("WEATHER_HEDGE_MAX_DELAY", &c.Weather.Hedge.MaxDelay)
+	env.duration("WEATHER_CACHE_TTL", &c.Weather.Cache.TTL)
+	env.duration("WEATHER_CACHE_MIN_TTL", &c.Weather.Cache.MinTTL)
+	env.int("WEATHER_CACHE_SIZE", &c.Weather.Cache.Size)
+
+	env.list("CEP_PROVIDERS", &c.CEP.Providers)
+	env.string("CEP_DATABASE_FILE", &c.CEP.DatabaseFile)
+	env.duration("CEP_LOOKUP_TIMEOUT", &c.CEP.LookupTimeout)
+	env.string("CEP_PATTERN", &c.CEP.Pattern)
+	env.string("BRASILAPI_BASE_URL", &c.CEP.BaseURLs.BrasilAPI)
+	env.string("VIACEP_BASE_URL", &c.CEP.BaseURLs.ViaCEP)
+	env.string("OPENCEP_BASE_URL", &c.CEP.BaseURLs.OpenCEP)
+	env.string("AWESOMEAPI_BASE_URL", &c.CEP.BaseURLs.AwesomeAPI)
+	env.duration("CEP_CACHE_TTL", &c.CEP.Cache.TTL)
+	env.duration("CEP_CACHE_STALE_TTL", &c.CEP.Cache.StaleTTL)
+	env.duration("CEP_CACHE_NEGATIVE_TTL", &c.CEP.Cache.NegativeTTL)
+	env.int("CEP_CACHE_SIZE", &c.CEP.Cache.Size)
+
+	env.float("BREAKER_FAILURE_RATE", &c.Breaker.FailureRate)
+	env.int("BREAKER_MIN_REQUESTS", &c.Breaker.MinRequests)
+	env.duration("BREAKER_WINDOW", &c.Breaker.Window)
+	env.duration("BREAKER_COOLDOWN", &c.Breaker.Cooldown)
+	env.int("BREAKER_HALF_OPEN_PROBES", &c.Breaker.HalfOpenProbes)
+
+	env.int("RETRY_MAX_ATTEMPTS", &c.Retry.MaxAttempts)
+	env.duration("RETRY_ATTEMPT_TIMEOUT", &c.Retry.AttemptTimeout)
+	env.duration("RETRY_BASE_DELAY", &c.Retry.BaseDelay)
+	env.duration("RETRY_MAX_DELAY", &c.Retry.MaxDelay)
+	env.duration("RETRY_BUDGET", &c.Retry.Budget)
+
+	env.bool("HEALTH_PROBE_UPSTREAMS", &c.Health.ProbeUpstreams)
+	env.duration("HEALTH_CACHE_TTL", &c.Health.CacheTTL)
+	env.duration("HEALTH_TIMEOUT", &c.Health.Timeout)
+
+	env.string("TRACING_EXPORTER", &c.Tracing.Exporter)
+	env.string("TRACING_OTLP_ENDPOINT", &c.Tracing.OTLPEndpoint)
+	env.string("TRACING_SERVICE_NAME", &c.Tracing.ServiceName)
+	env.float("TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+
+	env.string("LOG_LEVEL", &c.Logging.Level)
+	env.string("LOG_FORMAT", &c.Logging.Format)
+	env.string("LOG_PROJECT_ID", &c.Logging.ProjectID)
+
+	env.apiKeys("AUTH_API_KEYS", &c.Auth.Keys)
+	env.string("AUTH_KEYS_FILE", &c.Auth.KeysFile)
+	env.int("AUTH_DAILY_QUOTA", &c.Auth.DailyQuota)
+	env.int("AUTH_MONTHLY_QUOTA", &c.Auth.MonthlyQuota)
+	env.string("AUTH_REDIS_ADDR", &c.Auth.RedisAddr)
+	env.string("AUTH_REDIS_PASSWORD", &c.Auth.RedisPassword)
+
+	env.bool("ADMIN_ENABLED", &c.Admin.Enabled)
+	return errors.Join(env.errs...)
+}
+
+// Validate reports every invalid setting at once, so a bad deploy fails at startup with the
+// full list instead of on the first request.
+// Informa todas as configurações inválidas de uma vez, para que um deploy com problema falhe
+// na inicialização com a lista completa em vez de na primeira requisição.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	check(err == nil && port > 0 && port <= 65535, "port: %q is not a valid TCP port", c.Port)
+	check(c.Server.ReadHeaderTimeout >= 0 && c.Server.ReadTimeout >= 0 && c.Server.WriteTimeout >= 0 && c.Server.IdleTimeout >= 0,
+		"server: timeouts must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout: must be positive")
+
+	check(len(c.Weather.Providers) > 0, "weather.providers: at least one provider is required")
+	for _, name := range c.Weather.Providers {
+		check(slices.Contains(services.WeatherProviderNames, name), "weather.providers: unknown weather provider %q", name)
+	}
+	check(slices.Contains(services.WeatherStrategyNames, c.Weather.Strategy), "weather.strategy: unknown weather strategy %q", c.Weather.Strategy)
+	usesWeatherAPI := slices.Contains(c.Weather.Providers, "weatherapi") || c.Weather.Hedge.Provider == "weatherapi"
+	check(!usesWeatherAPI || c.Weather.WeatherAPIKey != "", "weather.weatherapi_key: required by the weatherapi provider (WEATHER_API_KEY)")
+	usesOpenWeatherMap := slices.Contains(c.Weather.Providers, "openweathermap") || c.Weather.Hedge.Provider == "openweathermap"
+	check(!usesOpenWeatherMap || c.Weather.OpenWeatherMapKey != "", "weather.openweathermap_key: required by the openweathermap provider (OPENWEATHERMAP_API_KEY)")
+	check(c.Weather.ProviderTimeout >= 0, "weather.provider_timeout: must not be negative")
+	check(c.Weather.ConsensusThreshold >= 0, "weather.consensus_threshold: must not be negative")
+	for _, baseURL := range []struct{ name, value string }{
+		{"weather.base_urls.weatherapi", c.Weather.BaseURLs.WeatherAPI},
+		{"weather.base_urls.openmeteo", c.Weather.BaseURLs.OpenMeteo},
+		{"weather.base_urls.openmeteo_archive", c.Weather.BaseURLs.OpenMeteoArchive},
+		{"weather.base_urls.openmeteo_geocoding", c.Weather.BaseURLs.OpenMeteoGeocoding},
+		{"weather.base_urls.openweathermap", c.Weather.BaseURLs.OpenWeatherMap},
+		{"cep.base_urls.brasilapi", c.CEP.BaseURLs.BrasilAPI},
+		{"cep.base_urls.viacep", c.CEP.BaseURLs.ViaCEP},
+		{"cep.base_urls.opencep", c.CEP.BaseURLs.OpenCEP},
+		{"cep.base_urls.awesomeapi", c.CEP.BaseURLs.AwesomeAPI},
+	} {
+		check(validBaseURL(baseURL.value), "%s: %q is not an http(s) URL", baseURL.name, baseURL.value)
+	}
+	check(c.Weather.Hedge.Percentile >= 0 && c.Weather.Hedge.Percentile <= 100, "weather.hedge.percentile: must be between 0 and 100")
+	check(c.Weather.Hedge.Provider == "" || slices.Contains(services.WeatherProviderNames, c.Weather.Hedge.Provider), "weather.hedge.provider: unknown weather provider %q", c.Weather.Hedge.Provider)
+	check(c.Weather.Hedge.MinDelay >= 0 && c.Weather.Hedge.MinDelay <= c.Weather.Hedge.MaxDelay, "weather.hedge: min_delay must be between 0 and max_delay")
+	check(c.Weather.Cache.TTL >= 0 && c.Weather.Cache.MinTTL >= 0, "weather.cache: TTLs must not be negative")
+	check(c.Weather.Cache.TTL == 0 || c.Weather.Cache.Size > 0, "weather.cache.size: must be positive when the cache is enabled")
+
+	for _, name := range c.CEP.Providers {
+		check(slices.Contains(services.CEPProviderNames, name), "cep.providers: unknown CEP provider %q", name)
+	}
+	check(!slices.Contains(c.CEP.Providers, "local") || c.CEP.DatabaseFile != "", "cep.database_file: required by the local provider (CEP_DATABASE_FILE)")
+	check(c.CEP.LookupTimeout > 0, "cep.lookup_timeout: must be positive")
+	_, err = regexp.Compile(c.CEP.Pattern)
+	check(err == nil, "cep.pattern: %v", err)
+	check(c.CEP.Cache.TTL >= 0 && c.CEP.Cache.StaleTTL >= 0 && c.CEP.Cache.NegativeTTL >= 0, "cep.cache: TTLs must not be negative")
+	check(c.CEP.Cache.TTL == 0 || c.CEP.Cache.Size > 0, "cep.cache.size: must be positive when the cache is enabled")
+
+	check(c.Breaker.FailureRate >= 0 && c.Breaker.FailureRate <= 1, "breaker.failure_rate: must be between 0 and 1")
+	check(c.Breaker.FailureRate == 0 || (c.Breaker.MinRequests > 0 && c.Breaker.Window > 0 && c.Breaker.Cooldown > 0 && c.Breaker.HalfOpenProbes > 0),
+		"breaker: min_requests, window, cooldown and half_open_probes must be positive when the breakers are enabled")
+
+	check(c.Retry.MaxAttempts >= 1, "retry.max_attempts: must be at least 1")
+	check(c.Retry.AttemptTimeout >= 0 && c.Retry.BaseDelay >= 0 && c.Retry.MaxDelay >= 0 && c.Retry.Budget >= 0, "retry: durations must not be negative")
+
+	check(c.Health.CacheTTL >= 0, "health.cache_ttl: must not be negative")
+	check(c.Health.Timeout > 0, "health.timeout: must be positive")
+
+	check(slices.Contains(TracingExporters, c.Tracing.Exporter), "tracing.exporter: unknown exporter %q", c.Tracing.Exporter)
+	check(c.Tracing.Exporter != "otlp" || validBaseURL(c.Tracing.OTLPEndpoint), "tracing.otlp_endpoint: %q is not an http(s) URL", c.Tracing.OTLPEndpoint)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: must be between 0 and 1")
+
+	_, err = shared.ParseLogLevel(c.Logging.Level)
+	check(err == nil, "logging.level: unknown level %q", c.Logging.Level)
+	check(slices.Contains(LogFormats, c.Logging.Format), "logging.format: unknown format %q", c.Logging.Format)
+
+	check(c.Auth.DailyQuota >= 0 && c.Auth.MonthlyQuota >= 0, "auth: quotas must not be negative")
+	seenKeys := make(map[string]bool, len(c.Auth.Keys))
+	for i, key := range c.Auth.Keys {
+		check(key.Client != "", "auth.keys[%d].client: required", i)
+		check(key.Key != "", "auth.keys[%d].key: required", i)
+		check(key.Key == "" || !seenKeys[key.Key], "auth.keys[%d].key: duplicated", i)
+		check(key.DailyQuota >= 0 && key.MonthlyQuota >= 0, "auth.keys[%d]: quotas must not be negative", i)
+		seenKeys[key.Key] = true
+	}
+	if c.Auth.RedisAddr != "" {
+		_, _, err = net.SplitHostPort(c.Auth.RedisAddr)
+		check(err == nil, "auth.redis_addr: %q is not a host:port address", c.Auth.RedisAddr)
+	}
+	hasAdminKey := slices.ContainsFunc(c.Auth.Keys, func(key APIKeyConfig) bool { return key.Admin })
+	check(!c.Admin.Enabled || hasAdminKey, "admin.enabled: requires an auth key with admin: true, the admin endpoints are never public")
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration with the secrets masked, safe to print or log.
+// Retorna uma cópia da configuração com os segredos mascarados, segura para imprimir ou registrar.
+func (c Config) Redacted() Config {
+	c.Auth.Keys = slices.Clone(c.Auth.Keys) // Do not mask the keys of the original
+	secrets := []*string{&c.Weather.WeatherAPIKey, &c.Weather.OpenWeatherMapKey, &c.Auth.RedisPassword}
+	for i := range c.Auth.Keys {
+		secrets = append(secrets, &c.Auth.Keys[i].Key)
+	}
+	for _, secret := range secrets {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return c
+}
+
+// Print writes the configuration, with the secrets masked, as YAML.
+// Escreve a configuração, com os segredos mascarados, como YAML.
+func (c Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// validBaseURL reports whether value is an absolute http or https URL.
+// Informa se value é uma URL http ou https absoluta.
+func validBaseURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getEnv returns the trimmed environment variable, or "" when it is unset.
+// Retorna a variável de ambiente sem espaços, ou "" quando ela não está definida.
+func getEnv(key string) string {
+	return strings.TrimSpace(os.Getenv(key))
+}
+
+// envLoader overlays settings with the environment variables that are set, collecting every
+// parse error instead of stopping at the first one.
+// envLoader sobrepõe configurações com as variáveis de ambiente definidas, acumulando todos os
+// erros de interpretação em vez de parar no primeiro.
+type envLoader struct {
+	errs []error // Parse errors, one per invalid variable
+}
+
+// string sets target to the environment variable when it is set and not empty.
+// Define target com a variável de ambiente quando ela está definida e não vazia.
+func (e *envLoader) string(key string, target *string) {
+	if value := getEnv(key); value != "" {
+		*target = value
+	}
+}
+
+// list sets target to the comma-separated environment variable, dropping empty items.
+// Define target com a variável de ambiente separada por vírgulas, descartando itens vazios.
+func (e *envLoader) list(key string, target *[]string) {
+	var items []string
+	for _, item := range strings.Split(getEnv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) > 0 {
+		*target = items
+	}
+}
+
+// apiKeys appends the API keys of a comma-separated "client:key" environment variable to target.
+// Acrescenta a target as chaves de API de uma variável de ambiente "cliente:chave" separada por vírgulas.
+func (e *envLoader) apiKeys(key string, target *[]APIKeyConfig) {
+	var entries []string
+	e.list(key, &entries)
+	for _, entry := range entries {
+		client, apiKey, ok := strings.Cut(entry, ":")
+		if !ok {
+			e.errs = append(e.errs, fmt.Errorf("invalid %s: entries must be client:key", key)) // Never echo the key
+			return
+		}
+		*target = append(*target, APIKeyConfig{Client: strings.TrimSpace(client), Key: strings.TrimSpace(apiKey)})
+	}
+}
+
+// duration parses the environment variable as a time.Duration (e.g. "10m") into target.
+// Interpreta a variável de ambiente como time.Duration (ex.: "10m") em target.
+func (e *envLoader) duration(key string, target *time.Duration) {
+	parse(e, key, target, time.ParseDuration)
+}
+
+// int parses the environment variable as an integer into target.
+// Interpreta a variável de ambiente como inteiro em target.
+func (e *envLoader) int(key string, target *int) {
+	parse(e, key, target, strconv.Atoi)
+}
+
+// float parses the environment variable as a floating-point number into target.
+// Interpreta a variável de ambiente como número de ponto flutuante em target.
+func (e *envLoader) float(key string, target *float64) {
+	parse(e, key, target, func(value string) (float64, error) { return strconv.ParseFloat(value, 64) })
+}
+
+// bool parses the environment variable as a boolean (e.g. "true", "0") into target.
+// Interpreta a variável de ambiente como booleano (ex.: "true", "0") em target.
+func (e *envLoader) bool(key string, target *bool) {
+	parse(e, key, target, strconv.ParseBool)
+}
+
+// parse sets target to the parsed environment variable when it is set, recording parse errors.
+// Define target com a variável de ambiente interpretada quando ela está definida, registrando erros.
+func parse[T any](e *envLoader, key string, target *T, parser func(string) (T, error)) {
+	value := getEnv(key)
+	if value == "" {
+		return
+	}
+	parsed, err := parser(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s: %w", key, err))
+		return
+	}
+	*target = parsed
+}
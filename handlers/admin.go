@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"sort"
+)
+
+// AdminHandler serves the operational endpoints under /admin.
+// AdminHandler atende os endpoints operacionais em /admin.
+type AdminHandler struct {
+	Breakers *services.BreakerClient // Client holding the upstream circuit breakers, nil when disabled
+	Keys     *services.APIKeyStore   // API keys and their usage, nil when no key is configured
+}
+
+// NewAdminHandler creates and returns a new AdminHandler.
+// Cria e retorna um novo AdminHandler.
+func NewAdminHandler(breakers *services.BreakerClient) *AdminHandler {
+	return &AdminHandler{Breakers: breakers}
+}
+
+// BreakersHandlerFunc handles the HTTP requests for the circuit breaker state of each upstream host
+// Função que lida com as requisições HTTP para o estado do circuit breaker de cada host de origem
+func (h *AdminHandler) BreakersHandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := models.BreakersResponse{Breakers: []models.BreakerResponse{}}
+		if h.Breakers != nil {
+			for host, snapshot := range h.Breakers.Snapshot() {
+				breaker := models.BreakerResponse{
+					Host:     host,
+					State:    snapshot.State.String(),
+					Requests: snapshot.Requests,
+					Failures: snapshot.Failures,
+				}
+				if !snapshot.OpenedAt.IsZero() {
+					breaker.OpenedAt = &snapshot.OpenedAt
+				}
+				response.Breakers = append(response.Breakers, breaker)
+			}
+		}
+
+		// Sort by host so the output is stable
+		// Ordena por host para que a saída seja estável
+		sort.Slice(response.Breakers, func(i, j int) bool {
+			return response.Breakers[i].Host < response.Breakers[j].Host
+		})
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// UsageHandlerFunc handles the HTTP requests for the usage of each API key against its quotas
+// Função que lida com as requisições HTTP para o uso de cada chave de API em relação às suas cotas
+func (h *AdminHandler) UsageHandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := models.UsageResponse{Keys: []models.KeyUsageResponse{}}
+		if h.Keys != nil {
+			usages, err := h.Keys.Usage(r.Context())
+			if err != nil {
+				writeUsageUnavailable(w, r, err)
+				return
+			}
+			for _, usage := range usages { // Already sorted by client
+				response.Keys = append(response.Keys, models.KeyUsageResponse{
+					Client:       usage.Client,
+					Admin:        usage.Admin,
+					DailyUsed:    usage.DailyUsed,
+					DailyQuota:   usage.DailyQuota,
+					MonthlyUsed:  usage.MonthlyUsed,
+					MonthlyQuota: usage.MonthlyQuota,
+				})
+			}
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
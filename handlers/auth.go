@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"strconv"
+	"strings"
+)
+
+// RequireAPIKey returns a middleware that rejects requests without a valid API key with 401 and
+// those over the quota of their key with 429. Accepted requests count against the quota, whose
+// remaining requests are sent in the X-Quota-Remaining header. When the usage counters cannot
+// be reached, requests are rejected with 503 rather than served uncounted. A nil store, when no
+// key is configured, deliberately returns a middleware that accepts every request: the weather
+// routes stay public, as they were before the API keys.
+// Retorna um middleware que rejeita requisições sem uma chave de API válida com 401 e as acima
+// da cota da sua chave com 429. Requisições aceitas contam na cota, cujas requisições restantes
+// são enviadas no cabeçalho X-Quota-Remaining. Quando os contadores de uso não podem ser
+// alcançados, as requisições são rejeitadas com 503 em vez de atendidas sem contar. Um
+// repositório nil, quando nenhuma chave está configurada, retorna de propósito um middleware
+// que aceita todas as requisições: as rotas de clima continuam públicas, como eram antes das
+// chaves de API.
+func RequireAPIKey(keys *services.APIKeyStore) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if keys == nil {
+			return next
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			usage, err := keys.Consume(r.Context(), apiKeyFromRequest(r))
+			var quotaErr *services.QuotaExceededError
+			switch {
+			case errors.Is(err, services.ErrUsageUnavailable):
+				writeUsageUnavailable(w, r, err)
+				return
+			case errors.As(err, &quotaErr):
+				retryAfter := math.Ceil(quotaErr.ResetAt.Sub(keys.Now()).Seconds())
+				w.Header().Set("Retry-After", strconv.Itoa(max(int(retryAfter), 1)))
+				writeJSON(w, http.StatusTooManyRequests, models.ErrorResponse{Error: quotaErr.Error(), Code: "quota_exceeded"})
+				return
+			case err != nil:
+				writeAuthError(w, err)
+				return
+			}
+
+			if remaining, limited := quotaRemaining(usage); limited {
+				w.Header().Set("X-Quota-Remaining", strconv.Itoa(remaining))
+			}
+			next(w, r)
+		}
+	}
+}
+
+// RequireAdminKey returns a middleware that only accepts requests with an admin API key,
+// answering 401 without a valid key and 403 with a key that is not an admin one. Admin
+// requests do not count against the quotas. A nil store has no admin key, so the middleware
+// fails closed and answers 403 to every request.
+// Retorna um middleware que só aceita requisições com uma chave de API de administrador,
+// respondendo 401 sem uma chave válida e 403 com uma chave que não é de administrador.
+// Requisições de administrador não contam nas cotas. Um repositório nil não tem chave de
+// administrador, então o middleware falha fechado e responde 403 a todas as requisições.
+func RequireAdminKey(keys *services.APIKeyStore) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if keys == nil {
+				writeJSON(w, http.StatusForbidden, models.ErrorResponse{Error: "admin API key required", Code: "admin_key_required"})
+				return
+			}
+			key, err := keys.Authenticate(apiKeyFromRequest(r))
+			if err != nil {
+				writeAuthError(w, err)
+				return
+			}
+			if !key.Admin {
+				writeJSON(w, http.StatusForbidden, models.ErrorResponse{Error: "admin API key required", Code: "admin_key_required"})
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// apiKeyFromRequest reads the key from the X-API-Key header, then from the api_key query
+// parameter for clients that cannot set headers.
+// Lê a chave do cabeçalho X-API-Key e depois do parâmetro de query api_key, para clientes que
+// não conseguem definir cabeçalhos.
+func apiKeyFromRequest(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	return strings.TrimSpace(r.URL.Query().Get("api_key"))
+}
+
+// writeAuthError answers 401 for a missing or unknown API key.
+// Responde 401 para uma chave de API ausente ou desconhecida.
+func writeAuthError(w http.ResponseWriter, err error) {
+	code := "api_key_invalid"
+	if errors.Is(err, services.ErrAPIKeyMissing) {
+		code = "api_key_missing"
+	}
+	w.Header().Set("WWW-Authenticate", `APIKey header="X-API-Key"`)
+	writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: err.Error(), Code: code})
+}
+
+// writeUsageUnavailable logs why the usage counters failed and answers 503.
+// Registra por que os contadores de uso falharam e responde 503.
+func writeUsageUnavailable(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "API key usage unavailable", "error", err)
+	writeJSON(w, http.StatusServiceUnavailable, models.ErrorResponse{Error: "API key usage unavailable", Code: "usage_unavailable"})
+}
+
+// quotaRemaining returns the requests left before the tightest quota of the key is reached.
+// Retorna as requisições restantes até a cota mais apertada da chave ser atingida.
+func quotaRemaining(usage services.KeyUsage) (remaining int, limited bool) {
+	remaining = math.MaxInt
+	if usage.DailyQuota > 0 {
+		remaining = min(remaining, usage.DailyQuota-usage.DailyUsed)
+	}
+	if usage.MonthlyQuota > 0 {
+		remaining = min(remaining, usage.MonthlyQuota-usage.MonthlyUsed)
+	}
+	return remaining, remaining != math.MaxInt
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+)
+
+// ConditionsHandlerFunc handles the HTTP requests for the full current conditions of a CEP
+// Função que lida com as requisições HTTP para as condições atuais completas de um CEP
+func (h *WeatherHandler) ConditionsHandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Resolve the CEP of the request into a location
+		// Resolve o CEP da requisição em uma localização
+		location, ok := h.resolveLocation(w, r)
+		if !ok {
+			return
+		}
+
+		// Fetch the current weather for the resolved location
+		// Busca o clima atual para a localização resolvida
+		weather, ok := h.fetchCurrentWeather(w, r, location)
+		if !ok {
+			return
+		}
+
+		// Send the normalized conditions document as JSON
+		// Envia o documento normalizado de condições como JSON
+		writeJSON(w, http.StatusOK, h.conditions(location, weather))
+	}
+}
+
+// conditions builds the normalized conditions document from the location and the observation.
+// Monta o documento normalizado de condições a partir da localização e da observação.
+func (h *WeatherHandler) conditions(location models.Location, weather models.CurrentWeather) models.ConditionsResponse {
+	response := models.ConditionsResponse{
+		Address:     address(location),
+		Temperature: h.temperatures(weather.TempC),
+		FeelsLike:   h.temperatures(weather.FeelsLikeC),
+		Condition:   models.ConditionResponse(weather.Condition),
+		Humidity:    weather.Humidity,
+		Cloud:       weather.Cloud,
+		UV:          weather.UV,
+		IsDay:       weather.IsDay,
+		Wind: models.WindResponse{
+			Kph:       weather.WindKph,
+			Mph:       round2(weather.WindKph / kmPerMile),
+			Ms:        kphToMs(weather.WindKph),
+			GustKph:   weather.GustKph,
+			GustMph:   round2(weather.GustKph / kmPerMile),
+			GustMs:    kphToMs(weather.GustKph),
+			Degree:    weather.WindDegree,
+			Direction: weather.WindDir,
+		},
+		Pressure:      models.PressureResponse{Mb: weather.PressureMb, In: round2(weather.PressureMb * inHgPerMb)},
+		Precipitation: models.PrecipitationResponse{Mm: weather.PrecipMm, In: round2(weather.PrecipMm / mmPerInch)},
+		Visibility:    models.VisibilityResponse{Km: weather.VisKm, Miles: round2(weather.VisKm / kmPerMile)},
+		Providers:     weather.Providers,
+		Outliers:      weather.Outliers,
+	}
+
+	if !weather.LastUpdated.IsZero() {
+		lastUpdated := weather.LastUpdated.UTC()
+		response.LastUpdated = &lastUpdated
+	}
+	return response
+}
+
+// address flattens a resolved location into the response address.
+// Achata uma localização resolvida no endereço da resposta.
+func address(location models.Location) models.AddressResponse {
+	value := func(field *string) string {
+		if field == nil {
+			return ""
+		}
+		return *field
+	}
+
+	response := models.AddressResponse{
+		Cep:       value(location.Cep),
+		City:      value(location.City),
+		UF:        value(location.Uf),
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
+	}
+	if neighborhood := value(location.Localidade); neighborhood != response.City {
+		response.Neighborhood = neighborhood // Localidade is the city for some providers
+	}
+	return response
+}
+
+// Unit conversion factors for the imperial fields, as providers answer in metric units.
+// Fatores de conversão para os campos imperiais, já que os provedores respondem em unidades métricas.
+const (
+	kmPerMile = 1.609344
+	mmPerInch = 25.4
+	inHgPerMb = 0.02953
+)
+
+// kphToMs converts kilometers per hour to meters per second, rounded to two decimals.
+// Converte quilômetros por hora para metros por segundo, arredondando para duas casas.
+func kphToMs(kph float64) float64 {
+	return round2(kph / 3.6)
+}
+
+// round2 rounds a converted value to two decimals.
+// Arredonda um valor convertido para duas casas decimais.
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
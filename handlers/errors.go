@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+)
+
+// weatherErrorResponse maps a weather service failure to an HTTP status code and error body.
+// Mapeia uma falha do serviço de clima para um código de status HTTP e um corpo de erro.
+func weatherErrorResponse(err error) (int, models.ErrorResponse) {
+	switch {
+	case errors.Is(err, services.ErrUpstreamAuth):
+		// Our credentials were rejected: a server-side misconfiguration
+		// Nossas credenciais foram rejeitadas: erro de configuração do servidor
+		return http.StatusInternalServerError, models.ErrorResponse{Error: "weather provider authentication failed", Code: "weather_provider_auth_failed"}
+	case errors.Is(err, services.ErrUpstreamQuota):
+		return http.StatusServiceUnavailable, models.ErrorResponse{Error: "weather provider quota exceeded", Code: "weather_quota_exceeded"}
+	case errors.Is(err, services.ErrUpstreamNotFound):
+		return http.StatusNotFound, models.ErrorResponse{Error: "can not find weather for zipcode", Code: "weather_location_not_found"}
+	case errors.Is(err, services.ErrUpstreamUnavailable):
+		return http.StatusBadGateway, models.ErrorResponse{Error: "weather provider unavailable", Code: "weather_provider_unavailable"}
+	case errors.Is(err, services.ErrUpstreamBadRequest):
+		return http.StatusBadGateway, models.ErrorResponse{Error: "weather provider rejected the request", Code: "weather_provider_bad_request"}
+	case errors.Is(err, services.ErrUnsupported):
+		// The configured provider cannot serve this operation, e.g. history on OpenWeatherMap
+		// O provedor configurado não atende esta operação, ex.: histórico na OpenWeatherMap
+		return http.StatusNotImplemented, models.ErrorResponse{Error: "operation not supported by the weather provider", Code: "weather_operation_unsupported"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, models.ErrorResponse{Error: "weather provider timeout", Code: "weather_provider_timeout"}
+	default:
+		return http.StatusInternalServerError, models.ErrorResponse{Error: "failed to get temperature"}
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"strconv"
+	"strings"
+)
+
+// Forecast length limits accepted by /v1/forecast (weatherapi.com serves up to 14 days).
+// Limites de dias aceitos por /v1/forecast (a weatherapi.com fornece até 14 dias).
+const (
+	defaultForecastDays = 3
+	maxForecastDays     = 14
+)
+
+// ForecastHandlerFunc handles the HTTP requests for a multi-day forecast of a CEP
+// Função que lida com as requisições HTTP para a previsão de vários dias de um CEP
+func (h *WeatherHandler) ForecastHandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Validate the 'days' query parameter before any upstream call
+		// Valida o parâmetro 'days' antes de qualquer chamada externa
+		days := defaultForecastDays
+		if value := strings.TrimSpace(r.URL.Query().Get("days")); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > maxForecastDays {
+				writeJSON(w, http.StatusUnprocessableEntity, models.ErrorResponse{Error: "invalid days"})
+				return
+			}
+			days = parsed
+		}
+
+		// Resolve the CEP of the request into a location
+		// Resolve o CEP da requisição em uma localização
+		location, ok := h.resolveLocation(w, r)
+		if !ok {
+			return
+		}
+
+		// Fetch the forecast for the resolved location
+		// Busca a previsão para a localização resolvida
+		forecast, err := h.WeatherService.GetForecast(r.Context(), location, days)
+		if r.Context().Err() != nil {
+			return // The client disconnected while we fetched the forecast
+		}
+		if err != nil {
+			slog.WarnContext(r.Context(), "Forecast lookup failed", "city", *location.City, "error", err)
+
+			// Respond with the status and error code matching the upstream failure
+			// Retorna o status e o código de erro correspondentes à falha na origem
+			status, response := weatherErrorResponse(err)
+			writeJSON(w, status, response)
+			return
+		}
+
+		// Send the forecast converted to the three temperature scales
+		// Envia a previsão convertida para as três escalas de temperatura
+		setProviders(w, forecast.Providers)
+		writeJSON(w, http.StatusOK, models.ForecastResponse{
+			Address:   address(location),
+			Days:      h.forecastDays(forecast.Days),
+			Providers: forecast.Providers,
+			Outliers:  forecast.Outliers,
+		})
+	}
+}
+
+// forecastDays converts forecast days into the response format.
+// Converte os dias de previsão para o formato de resposta.
+func (h *WeatherHandler) forecastDays(forecastDays []models.ForecastDay) []models.ForecastDayResponse {
+	days := make([]models.ForecastDayResponse, 0, len(forecastDays))
+	for _, forecastDay := range forecastDays {
+		days = append(days, models.ForecastDayResponse{
+			Date:         forecastDay.Date,
+			Min:          h.temperatures(forecastDay.MinTempC),
+			Max:          h.temperatures(forecastDay.MaxTempC),
+			Avg:          h.temperatures(forecastDay.AvgTempC),
+			Condition:    models.ConditionResponse(forecastDay.Condition),
+			ChanceOfRain: forecastDay.ChanceOfRain,
+			PrecipMm:     forecastDay.PrecipMm,
+			Hours:        h.forecastHours(forecastDay.Hours),
+		})
+	}
+	return days
+}
+
+// forecastHours converts forecast or history hours into the response format.
+// Converte as horas de previsão ou histórico para o formato de resposta.
+func (h *WeatherHandler) forecastHours(forecastHours []models.ForecastHour) []models.ForecastHourResponse {
+	hours := make([]models.ForecastHourResponse, 0, len(forecastHours))
+	for _, hour := range forecastHours {
+		hours = append(hours, models.ForecastHourResponse{
+			Time:         hour.Time,
+			Temperature:  h.temperatures(hour.TempC),
+			Condition:    models.ConditionResponse(hour.Condition),
+			ChanceOfRain: hour.ChanceOfRain,
+		})
+	}
+	return hours
+}
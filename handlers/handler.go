@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"post-graduation-exercise-cloud-run-weather-api/models"
 	"post-graduation-exercise-cloud-run-weather-api/services"
 	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Define interfaces for services that can be injected
@@ -17,6 +20,7 @@ type WeatherHandler struct {
 	WeatherService       services.WeatherService      // Service to retrieve weather data
 	CepValidator         *shared.CepValidator         // Validator for validating CEP (Brazilian ZIP code)
 	TemperatureConverter *shared.TemperatureConverter // Utility to convert temperatures between Celsius, Fahrenheit, and Kelvin
+	Now                  func() time.Time             // Clock deciding which history dates are in the future, replaceable in tests
 }
 
 // NewWeatherHandler creates and returns a new WeatherHandler with everything initialized
@@ -25,16 +29,13 @@ func NewWeatherHandler(
 	locationService services.LocationService,
 	weatherService services.WeatherService,
 	temperatureConverter *shared.TemperatureConverter,
-	chBrasilAPI, chViaCEP chan models.Location,
 ) *WeatherHandler {
-	// Initialize channels for fetching location data
-	// Inicializa os canais para buscar dados de localização
-
 	return &WeatherHandler{
-		LocationService:      locationService,                   // Assign location service
-		WeatherService:       weatherService,                    // Assign weather service
-		CepValidator:         shared.NewCepValidator(`^\d{8}$`), // Assign CEP validator with a regex pattern
-		TemperatureConverter: temperatureConverter,              // Assign temperature converter utility
+		LocationService:      locationService,                                  // Assign location service
+		WeatherService:       weatherService,                                   // Assign weather service
+		CepValidator:         shared.NewCepValidator(shared.DefaultCepPattern), // Assign CEP validator with a regex pattern
+		TemperatureConverter: temperatureConverter,                             // Assign temperature converter utility
+		Now:                  time.Now,                                         // Assign the system clock
 	}
 }
 
@@ -42,87 +43,127 @@ func NewWeatherHandler(
 // Função que lida com as requisições HTTP para obter dados meteorológicos
 func (h *WeatherHandler) WeatherHandlerFunc() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Retrieve the 'cep' query parameter from the URL
-		// Obtém o parâmetro 'cep' da URL da requisição
-		cep := strings.TrimSpace(r.URL.Query().Get("cep"))
-
-		// Create channels for receiving location data from APIs
-		// Cria canais para receber dados de localização das APIs
-		chBrasilAPI := make(chan models.Location)
-		chViaCEP := make(chan models.Location)
-
-		// Validate the CEP input
-		// Valida o CEP fornecido
-		if !h.CepValidator.IsValidCep(cep) {
-			// Respond with an error message if CEP is invalid
-			// Retorna uma resposta de erro caso o CEP seja inválido
-			response := models.ErrorResponse{
-				Error: "invalid zipcode", // Error message in English
-			}
-			// Set the HTTP status code to 422 (Unprocessable Entity)
-			// Define o código de status HTTP como 422 (Entidade não processável)
-			w.WriteHeader(http.StatusUnprocessableEntity)
-
-			// Encode the response into JSON and send it to the client
-			// Codifica a resposta em JSON e envia para o cliente
-			json.NewEncoder(w).Encode(response)
-			return
-		}
-
-		// Fetch location data based on CEP, using channels to simulate multiple API responses
-		// Busca dados de localização com base no CEP, utilizando canais para simular múltiplas respostas de APIs
-		location, err := h.LocationService.GetLocationFromCEP(cep, chBrasilAPI, chViaCEP)
-		if err != nil || location.City == nil {
-			// Respond with an error message if the location cannot be found
-			// Retorna uma resposta de erro caso não seja possível encontrar a localização
-			response := models.ErrorResponse{
-				Error: "can not find zipcode", // Error message in English
-			}
-			// Set the HTTP status code to 422 (Unprocessable Entity)
-			// Define o código de status HTTP como 422 (Entidade não processável)
-			w.WriteHeader(http.StatusNotFound)
-
-			// Encode the response into JSON and send it to the client
-			// Codifica a resposta em JSON e envia para o cliente
-			json.NewEncoder(w).Encode(response)
+		// Resolve the CEP of the request into a location
+		// Resolve o CEP da requisição em uma localização
+		location, ok := h.resolveLocation(w, r)
+		if !ok {
 			return
 		}
 
-		// Fetch temperature for the city
-		// Busca a temperatura para a cidade
-		tempC, err := h.WeatherService.GetTemperature(*location.City)
-		if err != nil {
-			// Respond with an error message if fetching the temperature fails
-			// Retorna uma resposta de erro caso a busca pela temperatura falhe
-			response := models.ErrorResponse{
-				Error: "failed to get temperature", // Error message in English
-			}
-			// Set the HTTP status code to 422 (Unprocessable Entity)
-			// Define o código de status HTTP como 500 (Erro interno do servidor)
-			w.WriteHeader(http.StatusInternalServerError)
-
-			// Encode the response into JSON and send it to the client
-			// Codifica a resposta em JSON e envia para o cliente
-			json.NewEncoder(w).Encode(response)
+		// Fetch the current weather for the resolved location
+		// Busca o clima atual para a localização resolvida
+		weather, ok := h.fetchCurrentWeather(w, r, location)
+		if !ok {
 			return
 		}
 
-		// Convert temperature using the shared utility
-		// Converte a temperatura utilizando a ferramenta compartilhada
-		tempF := h.TemperatureConverter.CelsiusToFahrenheit(tempC)
-		tempK := h.TemperatureConverter.CelsiusToKelvin(tempC)
-
 		// Prepare the response with temperature data in Celsius, Fahrenheit, and Kelvin
 		// Prepara a resposta com os dados de temperatura em Celsius, Fahrenheit e Kelvin
-		response := models.TemperatureResponse{
-			Celsius:    tempC, // Temperature in Celsius
-			Fahrenheit: tempF, // Temperature in Fahrenheit
-			Kelvin:     tempK, // Temperature in Kelvin
-		}
+		response := h.temperatures(weather.TempC)
 
 		// Send the response as JSON
 		// Envia a resposta como JSON
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// resolveLocation validates the 'cep' query parameter and resolves it into a location.
+// On failure it writes the error response and returns false.
+// Valida o parâmetro 'cep' e o resolve em uma localização.
+// Em caso de falha, escreve a resposta de erro e retorna false.
+func (h *WeatherHandler) resolveLocation(w http.ResponseWriter, r *http.Request) (models.Location, bool) {
+	// Retrieve the 'cep' query parameter from the URL
+	// Obtém o parâmetro 'cep' da URL da requisição
+	cep := strings.TrimSpace(r.URL.Query().Get("cep"))
+
+	// Validate the CEP input
+	// Valida o CEP fornecido
+	_, span := shared.StartSpan(r.Context(), "validate cep", shared.SpanKindInternal)
+	valid := h.CepValidator.IsValidCep(cep)
+	span.SetAttribute("cep.valid", valid)
+	span.End()
+	if !valid {
+		// Respond with 422 (Unprocessable Entity) if CEP is invalid
+		// Retorna 422 (Entidade não processável) caso o CEP seja inválido
+		writeJSON(w, http.StatusUnprocessableEntity, models.ErrorResponse{Error: "invalid zipcode"})
+		return models.Location{}, false
+	}
+
+	// Fetch location data based on CEP, racing every enabled CEP provider
+	// Busca dados de localização com base no CEP, disputando entre todos os provedores de CEP habilitados
+	ctx, span := shared.StartSpan(r.Context(), "cep lookup", shared.SpanKindInternal)
+	location, err := h.LocationService.GetLocationFromCEP(ctx, cep)
+	span.SetError(err)
+	span.End()
+	if r.Context().Err() != nil {
+		// The client disconnected, so there is nobody left to answer
+		// O cliente desconectou, então não há ninguém para responder
+		return models.Location{}, false
 	}
+	if err != nil || location.City == nil {
+		// Log the per-provider breakdown for diagnostics
+		// Registra o detalhamento por provedor para diagnóstico
+		slog.WarnContext(r.Context(), "CEP lookup failed", "cep", cep, "error", err)
+
+		// Respond with 404 (Not Found) if the location cannot be found
+		// Retorna 404 (Não encontrado) caso não seja possível encontrar a localização
+		writeJSON(w, http.StatusNotFound, models.ErrorResponse{Error: "can not find zipcode"})
+		return models.Location{}, false
+	}
+	return location, true
+}
+
+// fetchCurrentWeather fetches the current weather for a location and sets the Age header.
+// On failure it writes the error response and returns false.
+// Busca o clima atual para uma localização e define o cabeçalho Age.
+// Em caso de falha, escreve a resposta de erro e retorna false.
+func (h *WeatherHandler) fetchCurrentWeather(w http.ResponseWriter, r *http.Request, location models.Location) (models.CurrentWeather, bool) {
+	weather, err := h.WeatherService.GetCurrentWeather(r.Context(), location)
+	if r.Context().Err() != nil {
+		return weather, false // The client disconnected while we fetched the weather
+	}
+	if err != nil {
+		slog.WarnContext(r.Context(), "Weather lookup failed", "city", *location.City, "error", err)
+
+		// Respond with the status and error code matching the upstream failure
+		// Retorna o status e o código de erro correspondentes à falha na origem
+		status, response := weatherErrorResponse(err)
+		writeJSON(w, status, response)
+		return weather, false
+	}
+
+	setProviders(w, weather.Providers)
+
+	// Tell the client how long ago the observation was fetched, as it may come from cache
+	// Informa ao cliente há quanto tempo a observação foi buscada, pois ela pode vir do cache
+	if !weather.FetchedAt.IsZero() {
+		w.Header().Set("Age", strconv.Itoa(int(time.Since(weather.FetchedAt).Seconds())))
+	}
+	return weather, true
+}
+
+// setProviders tells the client which weather providers answered, on every weather endpoint.
+// Informa ao cliente quais provedores de clima responderam, em todos os endpoints de clima.
+func setProviders(w http.ResponseWriter, providers []string) {
+	if len(providers) > 0 {
+		w.Header().Set("X-Weather-Providers", strings.Join(providers, ", "))
+	}
+}
+
+// temperatures converts a Celsius temperature into the three scales using the shared utility.
+// Converte uma temperatura em Celsius para as três escalas usando a ferramenta compartilhada.
+func (h *WeatherHandler) temperatures(tempC float64) models.TemperatureResponse {
+	return models.TemperatureResponse{
+		Celsius:    tempC,                                             // Temperature in Celsius
+		Fahrenheit: h.TemperatureConverter.CelsiusToFahrenheit(tempC), // Temperature in Fahrenheit
+		Kelvin:     h.TemperatureConverter.CelsiusToKelvin(tempC),     // Temperature in Kelvin
+	}
+}
+
+// writeJSON sends body as JSON with the given status code.
+// Envia body como JSON com o código de status informado.
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) // Encode the response into JSON and send it to the client
 }
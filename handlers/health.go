@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+)
+
+// HealthHandler serves the liveness and readiness endpoints.
+// HealthHandler atende os endpoints de vivacidade e prontidão.
+type HealthHandler struct {
+	Monitor *services.HealthMonitor // Checks the dependencies for readiness
+}
+
+// NewHealthHandler creates and returns a new HealthHandler.
+// Cria e retorna um novo HealthHandler.
+func NewHealthHandler(monitor *services.HealthMonitor) *HealthHandler {
+	return &HealthHandler{Monitor: monitor}
+}
+
+// LivenessHandlerFunc handles the HTTP requests that check whether the process is alive
+// Função que lida com as requisições HTTP que verificam se o processo está vivo
+func (h *HealthHandler) LivenessHandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, models.HealthResponse{Status: "ok"})
+	}
+}
+
+// ReadinessHandlerFunc handles the HTTP requests that check whether the instance can serve
+// traffic, answering 503 when a critical dependency fails
+// Função que lida com as requisições HTTP que verificam se a instância pode receber tráfego,
+// respondendo 503 quando uma dependência crítica falha
+func (h *HealthHandler) ReadinessHandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		statuses, ready := h.Monitor.Status(r.Context())
+
+		response := models.HealthResponse{Status: "ok", Dependencies: []models.DependencyResponse{}}
+		for _, status := range statuses {
+			dependency := models.DependencyResponse{
+				Name:      status.Name,
+				Status:    "ok",
+				Critical:  status.Critical,
+				Group:     status.Group,
+				LatencyMs: status.Latency.Milliseconds(),
+				CheckedAt: status.CheckedAt,
+			}
+			if status.Err != nil {
+				dependency.Status = "failing"
+				dependency.Error = healthFailureReason(status.Err)
+			}
+			response.Dependencies = append(response.Dependencies, dependency)
+		}
+
+		code := http.StatusOK
+		if !ready {
+			response.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, response)
+	}
+}
+
+// healthFailureReason classifies a failed check. The error itself is only for the logs: this
+// endpoint is public and upstream errors may carry request URLs with API keys.
+// Classifica uma verificação com falha. O erro em si fica só para os logs: este endpoint é
+// público e os erros das origens podem carregar URLs de requisição com chaves de API.
+func healthFailureReason(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return "timeout"
+	case errors.Is(err, services.ErrUpstreamAuth):
+		return "auth"
+	case errors.Is(err, services.ErrUpstreamQuota):
+		return "quota"
+	case errors.Is(err, services.ErrUpstreamNotFound) || errors.Is(err, services.ErrCEPNotFound):
+		return "not_found"
+	default:
+		return "unavailable"
+	}
+}
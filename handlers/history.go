@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"strings"
+	"time"
+	_ "time/tzdata" // The scratch image has no zoneinfo database
+)
+
+// maxHistoryDays is the longest date range weatherapi.com accepts in a single history call.
+// maxHistoryDays é o maior período que a weatherapi.com aceita em uma única consulta de histórico.
+const maxHistoryDays = 30
+
+// historyZone is the time zone whose calendar decides which dates are still in the future:
+// Brasília time, used by most of the users.
+// historyZone é o fuso horário cujo calendário decide quais datas ainda estão no futuro: o
+// horário de Brasília, usado pela maioria dos usuários.
+var historyZone, _ = time.LoadLocation("America/Sao_Paulo") // Embedded, so it cannot fail
+
+// HistoryHandlerFunc handles the HTTP requests for the observed weather of a CEP on a
+// given day (?date=) or date range (?start=&end=)
+// Função que lida com as requisições HTTP para o clima observado em um CEP em um dia
+// (?date=) ou período (?start=&end=)
+func (h *WeatherHandler) HistoryHandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Validate the dates before any upstream call
+		// Valida as datas antes de qualquer chamada externa
+		start, end, message := historyRange(r, h.Now())
+		if message != "" {
+			writeJSON(w, http.StatusUnprocessableEntity, models.ErrorResponse{Error: message})
+			return
+		}
+
+		// Resolve the CEP of the request into a location
+		// Resolve o CEP da requisição em uma localização
+		location, ok := h.resolveLocation(w, r)
+		if !ok {
+			return
+		}
+
+		// Fetch the history for the resolved location
+		// Busca o histórico para a localização resolvida
+		history, err := h.WeatherService.GetHistory(r.Context(), location, start, end)
+		if r.Context().Err() != nil {
+			return // The client disconnected while we fetched the history
+		}
+		if err != nil {
+			slog.WarnContext(r.Context(), "History lookup failed", "city", *location.City, "error", err)
+
+			// Respond with the status and error code matching the upstream failure
+			// Retorna o status e o código de erro correspondentes à falha na origem
+			status, response := weatherErrorResponse(err)
+			writeJSON(w, status, response)
+			return
+		}
+
+		// Send each day in the same three-scale format as /weather
+		// Envia cada dia no mesmo formato de três escalas de /weather
+		days := make([]models.HistoryDayResponse, 0, len(history.Days))
+		for _, day := range history.Days {
+			days = append(days, models.HistoryDayResponse{
+				Date:                day.Date,
+				TemperatureResponse: h.temperatures(day.AvgTempC),
+				Min:                 h.temperatures(day.MinTempC),
+				Max:                 h.temperatures(day.MaxTempC),
+				Condition:           models.ConditionResponse(day.Condition),
+				PrecipMm:            day.PrecipMm,
+				Hours:               h.forecastHours(day.Hours),
+			})
+		}
+		setProviders(w, history.Providers)
+		writeJSON(w, http.StatusOK, models.HistoryResponse{
+			Address:   address(location),
+			Days:      days,
+			Providers: history.Providers,
+			Outliers:  history.Outliers,
+		})
+	}
+}
+
+// historyRange reads the requested day or date range. It returns an error message when the
+// dates are missing, malformed, after today in historyZone, reversed or span more than
+// maxHistoryDays.
+// Lê o dia ou período solicitado. Retorna uma mensagem de erro quando as datas estão ausentes,
+// mal formatadas, depois de hoje em historyZone, invertidas ou abrangem mais de maxHistoryDays.
+func historyRange(r *http.Request, now time.Time) (time.Time, time.Time, string) {
+	query := r.URL.Query()
+	startValue := strings.TrimSpace(query.Get("start"))
+	endValue := strings.TrimSpace(query.Get("end"))
+	if date := strings.TrimSpace(query.Get("date")); date != "" {
+		startValue, endValue = date, date // A single day
+	}
+	if endValue == "" {
+		endValue = startValue
+	}
+
+	start, startErr := time.Parse(time.DateOnly, startValue)
+	end, endErr := time.Parse(time.DateOnly, endValue)
+	if startErr != nil || endErr != nil {
+		return time.Time{}, time.Time{}, "invalid date" // Expected YYYY-MM-DD
+	}
+	year, month, day := now.In(historyZone).Date()
+	if end.After(time.Date(year, month, day, 0, 0, 0, 0, time.UTC)) {
+		return time.Time{}, time.Time{}, "invalid date" // History cannot be in the future
+	}
+	if end.Before(start) || end.Sub(start) >= maxHistoryDays*24*time.Hour {
+		return time.Time{}, time.Time{}, "invalid date range"
+	}
+	return start, end, ""
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// MetricsHandler records the HTTP request metrics and serves every registered metric at /metrics.
+// MetricsHandler registra as métricas das requisições HTTP e serve todas as métricas registradas em /metrics.
+type MetricsHandler struct {
+	Registry *shared.MetricsRegistry // Registry written by /metrics
+
+	requests *shared.Counter   // Requests by route, method and status
+	duration *shared.Histogram // Request latency by route
+	inFlight *shared.Gauge     // Requests being served by route
+}
+
+// NewMetricsHandler registers the HTTP request metrics and the goroutine count in registry.
+// Registra as métricas das requisições HTTP e o número de goroutines em registry.
+func NewMetricsHandler(registry *shared.MetricsRegistry) *MetricsHandler {
+	registry.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	return &MetricsHandler{
+		Registry: registry,
+		requests: registry.NewCounter("http_requests_total", "HTTP requests served.", "route", "method", "status"),
+		duration: registry.NewHistogram("http_request_duration_seconds", "Latency of the HTTP requests.", shared.DefaultLatencyBuckets, "route"),
+		inFlight: registry.NewGauge("http_requests_in_flight", "HTTP requests being served.", "route"),
+	}
+}
+
+// Instrument wraps next so its requests are counted and timed under route. The route is the
+// registered pattern, never the raw path, and unusual methods are counted as "other", so
+// query strings, CEPs and made-up methods do not create new series.
+// Envolve next para que suas requisições sejam contadas e cronometradas sob route. A rota é o
+// padrão registrado, nunca o caminho bruto, e métodos incomuns são contados como "other", para
+// que query strings, CEPs e métodos inventados não criem novas séries.
+func (h *MetricsHandler) Instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.inFlight.Add(1, route)
+		defer h.inFlight.Add(-1, route)
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		h.duration.Observe(time.Since(start).Seconds(), route)
+		h.requests.Inc(route, methodLabel(r.Method), strconv.Itoa(recorder.status))
+	}
+}
+
+// methodLabel maps the request method to the few values used as a metric label.
+// Mapeia o método da requisição para os poucos valores usados como rótulo de métrica.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+		return method
+	default:
+		return "other"
+	}
+}
+
+// MetricsHandlerFunc handles the HTTP requests for the metrics in the Prometheus text format
+// Função que lida com as requisições HTTP para as métricas no formato de texto do Prometheus
+func (h *MetricsHandler) MetricsHandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		h.Registry.WriteText(w)
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Middleware wraps a handler with behavior shared by every route, such as logging or compression.
+// Middleware envolve um handler com um comportamento comum a todas as rotas, como log ou compressão.
+type Middleware func(next http.HandlerFunc) http.HandlerFunc
+
+// RouteMiddleware is a middleware that also needs the registered route, e.g. to label metrics.
+// RouteMiddleware é um middleware que também precisa da rota registrada, por exemplo para rotular métricas.
+type RouteMiddleware func(route string, next http.HandlerFunc) http.HandlerFunc
+
+// Chain composes middlewares into one; the first middleware is the outermost.
+// Compõe middlewares em um só; o primeiro middleware é o mais externo.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Recover turns a panic of next into a logged error and a JSON 500 response, so one bad request
+// does not drop the connection. When the response had already started, it can only be aborted.
+// Transforma um panic de next em um erro registrado e uma resposta JSON 500, para que uma
+// requisição ruim não derrube a conexão. Quando a resposta já começou, ela só pode ser abortada.
+func Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered) // Deliberate abort, handled by the server
+			}
+
+			slog.ErrorContext(r.Context(), "Handler panicked", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+			if recorder.wroteHeader {
+				panic(http.ErrAbortHandler) // Part of the response was sent, the client must see it failed
+			}
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: "internal_error"})
+		}()
+		next(recorder, r)
+	}
+}
+
+// AccessLog logs one entry per request, with the httpRequest fields Cloud Logging displays.
+// Registra uma entrada por requisição, com os campos httpRequest que o Cloud Logging exibe.
+func AccessLog(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+		latency := time.Since(start)
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(r.Context(), level, "Request served",
+			slog.String("route", r.Pattern),
+			slog.Group("httpRequest",
+				slog.String("requestMethod", r.Method),
+				slog.String("requestUrl", r.URL.Path), // Without the query string
+				slog.Int("status", recorder.status),
+				slog.String("responseSize", fmt.Sprint(recorder.size)),
+				slog.String("userAgent", r.UserAgent()),
+				slog.String("remoteIp", r.RemoteAddr),
+				slog.String("latency", fmt.Sprintf("%.6fs", latency.Seconds())),
+			),
+		)
+	}
+}
+
+// Timing reports how long the handler took in the Server-Timing header, read by browser
+// developer tools. The time is measured when the handler writes the response headers.
+// Informa quanto tempo o handler levou no cabeçalho Server-Timing, lido pelas ferramentas de
+// desenvolvedor dos navegadores. O tempo é medido quando o handler escreve os cabeçalhos da resposta.
+func Timing(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(&timingWriter{ResponseWriter: w, start: time.Now()}, r)
+	}
+}
+
+// timingWriter adds the Server-Timing header just before the headers are sent.
+// timingWriter adiciona o cabeçalho Server-Timing logo antes de os cabeçalhos serem enviados.
+type timingWriter struct {
+	http.ResponseWriter
+	start       time.Time
+	wroteHeader bool
+}
+
+func (w *timingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		elapsed := float64(time.Since(w.start).Microseconds()) / 1000
+		w.Header().Add("Server-Timing", fmt.Sprintf("app;dur=%.3f", elapsed))
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// gzipWriters reuses the gzip compressors, which are expensive to allocate.
+// gzipWriters reaproveita os compressores gzip, que são caros de alocar.
+var gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// Gzip compresses the response for clients that accept gzip, unless the handler already
+// encoded it or the response has no body.
+// Comprime a resposta para os clientes que aceitam gzip, a menos que o handler já a tenha
+// codificado ou que a resposta não tenha corpo.
+func Gzip(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding") // Caches must keep both versions apart
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next(w, r)
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: w}
+		defer writer.close()
+		next(writer, r)
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+// Informa se um cabeçalho Accept-Encoding permite gzip.
+func acceptsGzip(header string) bool {
+	for _, encoding := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(encoding, ";")
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			quality, _ = strconv.ParseFloat(value, 64) // A malformed weight refuses the encoding
+		}
+		return quality > 0
+	}
+	return false
+}
+
+// gzipWriter compresses the body once the headers show the response can be compressed.
+// gzipWriter comprime o corpo quando os cabeçalhos mostram que a resposta pode ser comprimida.
+type gzipWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer // Nil while the body is written uncompressed
+	wroteHeader bool
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		header := w.Header()
+		hasBody := status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+		if hasBody && header.Get("Content-Encoding") == "" {
+			header.Set("Content-Encoding", "gzip")
+			header.Del("Content-Length") // The length of the uncompressed body
+			w.gz = gzipWriters.Get().(*gzip.Writer)
+			w.gz.Reset(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.gz.Write(b)
+}
+
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close finishes the compressed body and returns the compressor to the pool.
+// Finaliza o corpo comprimido e devolve o compressor ao pool.
+func (w *gzipWriter) close() {
+	if w.gz == nil {
+		return
+	}
+	w.gz.Close()
+	gzipWriters.Put(w.gz)
+	w.gz = nil
+}
+
+// statusRecorder is a ResponseWriter that remembers the status code and the body size written.
+// statusRecorder é um ResponseWriter que guarda o código de status e o tamanho do corpo escritos.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int  // Status written, 200 unless WriteHeader is called
+	size        int  // Bytes of body written
+	wroteHeader bool // Whether the response has started
+}
+
+// WriteHeader records the first status and forwards it.
+// Registra o primeiro status e o repassa.
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write counts the body bytes and forwards them.
+// Conta os bytes do corpo e os repassa.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+	return n, err
+}
+
+// Unwrap exposes the wrapped ResponseWriter to http.ResponseController.
+// Expõe o ResponseWriter envolvido para o http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"strings"
+)
+
+// maxRequestIDLength bounds the X-Request-ID accepted from clients.
+// maxRequestIDLength limita o X-Request-ID aceito dos clientes.
+const maxRequestIDLength = 128
+
+// WithRequestID wraps next so each request carries an ID in its context, logged with every
+// entry and returned in the X-Request-ID response header. The ID comes from the X-Request-ID
+// header, then from the trace of X-Cloud-Trace-Context, set by Google's load balancers, and is
+// generated otherwise.
+// Envolve next para que cada requisição carregue um ID no seu contexto, registrado em todas as
+// entradas de log e devolvido no cabeçalho de resposta X-Request-ID. O ID vem do cabeçalho
+// X-Request-ID, depois do trace de X-Cloud-Trace-Context, definido pelos balanceadores do
+// Google, e é gerado caso contrário.
+func WithRequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		cloudTrace := r.Header.Get("X-Cloud-Trace-Context")
+		if cloudTrace != "" {
+			ctx = shared.ContextWithCloudTrace(ctx, cloudTrace)
+		}
+
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id, _, _ = strings.Cut(cloudTrace, "/")
+		}
+		if !validRequestID(id) {
+			id = shared.NewRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		next(w, r.WithContext(shared.ContextWithRequestID(ctx, id)))
+	}
+}
+
+// validRequestID accepts short IDs made of letters, digits and a few separators, so a client
+// cannot inject arbitrary text into the logs.
+// Aceita IDs curtos formados por letras, dígitos e alguns separadores, para que um cliente não
+// consiga injetar texto arbitrário nos logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		isAlphanumeric := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphanumeric && !strings.ContainsRune("-_.:", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+)
+
+// Router registers routes wrapped by a middleware chain, so cross-cutting behavior is declared
+// once instead of in every handler. Use and UseRoute return a new router on the same routes
+// with a longer chain, which lets some routes skip the middlewares the others get.
+// Router registra rotas envolvidas por uma cadeia de middlewares, para que o comportamento
+// comum seja declarado uma vez em vez de em cada handler. Use e UseRoute retornam um novo
+// roteador sobre as mesmas rotas com uma cadeia maior, o que permite que algumas rotas pulem
+// os middlewares que as outras recebem.
+type Router struct {
+	mux   *http.ServeMux
+	chain []RouteMiddleware // Outermost first
+}
+
+// NewRouter creates a router without middlewares.
+// Cria um roteador sem middlewares.
+func NewRouter() *Router {
+	return &Router{mux: http.NewServeMux()}
+}
+
+// Use returns a router that also wraps its routes with middlewares, inside the current chain.
+// Retorna um roteador que também envolve suas rotas com middlewares, dentro da cadeia atual.
+func (r *Router) Use(middlewares ...Middleware) *Router {
+	routeMiddlewares := make([]RouteMiddleware, len(middlewares))
+	for i, middleware := range middlewares {
+		routeMiddlewares[i] = func(_ string, next http.HandlerFunc) http.HandlerFunc { return middleware(next) }
+	}
+	return r.UseRoute(routeMiddlewares...)
+}
+
+// UseRoute is Use for middlewares that need the registered route.
+// UseRoute é o Use para middlewares que precisam da rota registrada.
+func (r *Router) UseRoute(middlewares ...RouteMiddleware) *Router {
+	return &Router{mux: r.mux, chain: append(slices.Clone(r.chain), middlewares...)}
+}
+
+// Handle registers handler for route, a ServeMux pattern, wrapped by the chain of the router.
+// Registra handler para route, um padrão do ServeMux, envolvido pela cadeia do roteador.
+func (r *Router) Handle(route string, handler http.HandlerFunc) {
+	for i := len(r.chain) - 1; i >= 0; i-- {
+		handler = r.chain[i](route, handler)
+	}
+	r.mux.HandleFunc(route, handler)
+}
+
+// ServeHTTP dispatches the request to the route that matches it.
+// Encaminha a requisição para a rota que corresponde a ela.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.ServeHTTP(w, req)
+}
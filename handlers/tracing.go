@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+)
+
+// Tracing returns TraceRoute as a route middleware using tracer.
+// Retorna o TraceRoute como um middleware de rota usando tracer.
+func Tracing(tracer *shared.Tracer) RouteMiddleware {
+	return func(route string, next http.HandlerFunc) http.HandlerFunc {
+		return TraceRoute(tracer, route, next)
+	}
+}
+
+// TraceRoute wraps next so each request gets a server span under route. A W3C traceparent
+// header on the request makes the span continue the caller's trace, and the trace ID is sent
+// back in the Traceparent response header. A nil tracer returns next unchanged.
+// Envolve next para que cada requisição tenha um span de servidor sob route. Um cabeçalho
+// traceparent do W3C na requisição faz o span continuar o trace de quem chamou, e o ID do trace
+// é devolvido no cabeçalho de resposta Traceparent. Um tracer nil retorna next sem alterações.
+func TraceRoute(tracer *shared.Tracer, route string, next http.HandlerFunc) http.HandlerFunc {
+	if tracer == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if parent, ok := shared.ParseTraceparent(r.Header.Get("traceparent")); ok {
+			ctx = shared.ContextWithRemoteParent(ctx, parent)
+		}
+		ctx, span := tracer.Start(ctx, r.Method+" "+route, shared.SpanKindServer)
+		defer span.End()
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.route", route)
+		w.Header().Set("Traceparent", span.SpanContext.Traceparent())
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", recorder.status)
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetError(fmt.Errorf("HTTP %d", recorder.status)) // The handler logged the cause
+		}
+	}
+}
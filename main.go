@@ -1,29 +1,128 @@
 package main
 
 import (
-	"log"
+	"cmp"
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"post-graduation-exercise-cloud-run-weather-api/config"
 	handlers "post-graduation-exercise-cloud-run-weather-api/handlers"
-	"post-graduation-exercise-cloud-run-weather-api/models"
 	"post-graduation-exercise-cloud-run-weather-api/services"
 	"post-graduation-exercise-cloud-run-weather-api/shared"
 
 	"github.com/joho/godotenv"
 )
 
-// getHandler initializes and returns a new instance of WeatherHandler.
-// Inicializa e retorna uma nova instância de WeatherHandler.
-func getHandler() *handlers.WeatherHandler {
-	// Create channels for receiving location data from APIs
-	// Cria canais para receber dados de localização das APIs
-	chBrasilAPI := make(chan models.Location)
-	chViaCEP := make(chan models.Location)
+// application holds the handlers built from the configuration and the services that run
+// background work, which must be closed on shutdown.
+// application contém os handlers montados a partir da configuração e os serviços que executam
+// trabalho em segundo plano, que devem ser fechados no desligamento.
+type application struct {
+	weather *handlers.WeatherHandler
+	admin   *handlers.AdminHandler // Nil when the admin endpoints are disabled
+	health  *handlers.HealthHandler
+	metrics *handlers.MetricsHandler
+	tracer  *shared.Tracer        // Nil when tracing is disabled
+	keys    *services.APIKeyStore // Nil when no key is configured
+	closers []io.Closer           // Closed in reverse order on shutdown
+}
+
+// routes returns the router with every endpoint of the application.
+// Retorna o roteador com todos os endpoints da aplicação.
+func (a *application) routes() http.Handler {
+	// Identify, log, time and compress the requests of every route
+	// Identifica, registra, cronometra e comprime as requisições de cada rota
+	router := handlers.NewRouter().Use(handlers.WithRequestID, handlers.AccessLog, handlers.Timing, handlers.Gzip)
+
+	// Also count and trace the requests of the API routes, recovering from panics inside them
+	// Também conta e rastreia as requisições das rotas da API, recuperando de panics dentro delas
+	api := router.UseRoute(a.metrics.Instrument, handlers.Tracing(a.tracer)).Use(handlers.Recover)
+
+	// Weather routes spend the quota of an API key, admin routes need an admin key
+	// Rotas de clima gastam a cota de uma chave de API, rotas de administração precisam de uma chave de administrador
+	weather := api.Use(handlers.RequireAPIKey(a.keys))
+	admin := api.Use(handlers.RequireAdminKey(a.keys))
+
+	// Define the route for weather data, and associate it with the WeatherHandler
+	// Define a rota para os dados do clima e associa com o WeatherHandler
+	weather.Handle("/weather", a.weather.WeatherHandlerFunc())
+
+	// Define the route for the full current conditions
+	// Define a rota para as condições atuais completas
+	weather.Handle("/v1/conditions", a.weather.ConditionsHandlerFunc())
+
+	// Define the route for the multi-day forecast
+	// Define a rota para a previsão de vários dias
+	weather.Handle("/v1/forecast", a.weather.ForecastHandlerFunc())
+
+	// Define the route for the weather history
+	// Define a rota para o histórico do clima
+	weather.Handle("/v1/history", a.weather.HistoryHandlerFunc())
+
+	// Define the admin routes only when enabled, since they expose the upstream hosts
+	// Define as rotas de administração só quando habilitadas, já que expõem os hosts de origem
+	if a.admin != nil {
+		// Define the route for the circuit breaker state of each upstream host
+		// Define a rota para o estado do circuit breaker de cada host de origem
+		admin.Handle("/admin/breakers", a.admin.BreakersHandlerFunc())
+
+		// Define the route for the usage of each API key against its quotas
+		// Define a rota para o uso de cada chave de API em relação às suas cotas
+		admin.Handle("/admin/usage", a.admin.UsageHandlerFunc())
+	}
 
-	// Create an HTTP client
-	// Cria um cliente HTTP
-	client := &http.Client{}
+	// Define the routes Cloud Run and the load balancers poll to check the instance
+	// Define as rotas que o Cloud Run e os balanceadores consultam para verificar a instância
+	api.Handle("/healthz", a.health.LivenessHandlerFunc())
+	api.Handle("/readyz", a.health.ReadinessHandlerFunc())
+
+	// Define the route Prometheus scrapes, left out of the request metrics and behind an admin
+	// key, since it exposes the providers and their error rates
+	// Define a rota coletada pelo Prometheus, deixada fora das métricas de requisições e protegida
+	// por uma chave de administrador, já que expõe os provedores e suas taxas de erro
+	router.Use(handlers.Recover, handlers.RequireAdminKey(a.keys)).Handle("/metrics", a.metrics.MetricsHandlerFunc())
+	return router
+}
+
+// Close stops the background work of the services, waiting for it to return.
+// Para o trabalho em segundo plano dos serviços, esperando que ele retorne.
+func (a *application) Close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i].Close(); err != nil {
+			slog.Error("Error closing service", "error", err)
+		}
+	}
+}
+
+// getApplication initializes the WeatherHandler, the AdminHandler and the services behind them.
+// Inicializa o WeatherHandler, o AdminHandler e os serviços por trás deles.
+func getApplication(cfg config.Config) (*application, error) {
+	app := &application{}
+
+	// Register the request, upstream and cache metrics served at /metrics
+	// Registra as métricas de requisições, origens e caches servidas em /metrics
+	metricsRegistry := shared.NewMetricsRegistry()
+	app.metrics = handlers.NewMetricsHandler(metricsRegistry)
+	metrics := services.NewMetrics(metricsRegistry)
+
+	// Trace the requests when an exporter is configured
+	// Rastreia as requisições quando um exportador é configurado
+	tracer, exporter := getTracer(cfg.Tracing)
+	app.tracer = tracer
+	if exporter != nil {
+		app.closers = append(app.closers, exporter) // Closed last, after the spans of the other services
+	}
+
+	// Create an HTTP client that never waits forever on a stuck upstream
+	// Cria um cliente HTTP que nunca espera para sempre por uma origem travada
+	client := services.NewHTTPClient(cfg.Retry.AttemptTimeout)
 
 	// Initialize temperature converter
 	// Inicializa o conversor de temperatura
@@ -31,55 +130,399 @@ func getHandler() *handlers.WeatherHandler {
 
 	// Initialize API client with the HTTP client
 	// Inicializa o cliente da API com o cliente HTTP
-	apiClient := &services.APIClientImpl{Client: client}
+	var apiClient services.APIClient = &services.APIClientImpl{Client: client}
+
+	// Skip failing upstream hosts fast unless the circuit breakers are disabled
+	// Pula rapidamente os hosts de origem com falhas, a menos que os circuit breakers estejam desabilitados
+	var breakerClient *services.BreakerClient
+	if cfg.Breaker.FailureRate > 0 {
+		breakerClient = services.NewBreakerClient(apiClient, getBreakerConfig(cfg.Breaker))
+		apiClient = breakerClient
+	}
+
+	// Retry transient failures outside the breakers, so every attempt is counted and an open
+	// breaker stops the retries
+	// Tenta de novo as falhas transitórias por fora dos breakers, para que cada tentativa seja
+	// contada e um breaker aberto interrompa as novas tentativas
+	apiClient = services.NewRetryClient(apiClient, getRetryConfig(cfg.Retry))
 
-	// Create a new instance of WeatherService with the API client
-	// Cria uma nova instância do WeatherService com o cliente da API
-	weatherService := services.NewWeatherService(apiClient)
+	// Pick the configured weather providers and how they are combined
+	// Escolhe os provedores de clima configurados e como eles são combinados
+	weatherProvider, err := getWeatherProvider(apiClient, cfg.Weather, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a new instance of WeatherService with the weather provider
+	// Cria uma nova instância do WeatherService com o provedor de clima
+	weatherService := services.NewWeatherService(weatherProvider)
+
+	// Share one weather call among concurrent requests for the same city
+	// Compartilha uma chamada de clima entre requisições concorrentes para a mesma cidade
+	weatherService = services.NewCoalescedWeatherService(weatherService)
+
+	// Put the weather cache in front of the weather API unless it is disabled
+	// Coloca o cache de clima na frente da API de clima, a menos que esteja desabilitado
+	if cfg.Weather.Cache.TTL > 0 {
+		cachedWeatherService := services.NewCachedWeatherService(weatherService, getWeatherCacheConfig(cfg.Weather.Cache))
+		cachedWeatherService.Metrics = metrics
+		weatherService = cachedWeatherService
+	}
+
+	// Build the CEP provider registry and apply the provider configuration
+	// Monta o registro de provedores de CEP e aplica a configuração de provedores
+	registry, err := getCEPProviderRegistry(apiClient, cfg.CEP)
+	if err != nil {
+		return nil, err
+	}
 
-	// Initialize LocationService which depends on WeatherService
-	// Inicializa o LocationService, que depende do WeatherService
-	locationService := services.NewLocationService(weatherService)
+	// Initialize LocationService which races the enabled CEP providers
+	// Inicializa o LocationService, que disputa entre os provedores de CEP habilitados
+	var locationService services.LocationService = &services.LocationServiceImpl{Registry: registry, Timeout: cfg.CEP.LookupTimeout, Metrics: metrics}
 
-	// Initialize and return WeatherHandler with the necessary services and channels
-	// Inicializa e retorna o WeatherHandler com os serviços e canais necessários
+	// Share one provider race among concurrent requests for the same CEP
+	// Compartilha uma disputa entre provedores entre requisições concorrentes para o mesmo CEP
+	locationService = services.NewCoalescedLocationService(locationService)
+
+	// Put the CEP cache in front of the providers unless it is disabled
+	// Coloca o cache de CEP na frente dos provedores, a menos que esteja desabilitado
+	if cfg.CEP.Cache.TTL > 0 {
+		cachedLocationService := services.NewCachedLocationService(locationService, getLocationCacheConfig(cfg.CEP.Cache))
+		cachedLocationService.Metrics = metrics
+		app.closers = append(app.closers, cachedLocationService) // Stops its background refreshes
+		locationService = cachedLocationService
+	}
+
+	// Initialize WeatherHandler with the necessary services
+	// Inicializa o WeatherHandler com os serviços necessários
 	handler := handlers.NewWeatherHandler(
 		locationService,
 		weatherService,
 		temperatureConverter,
-		chBrasilAPI,
-		chViaCEP,
 	)
-	return handler
+	handler.CepValidator = shared.NewCepValidator(cfg.CEP.Pattern)
+	app.weather = handler
+
+	// Require an API key, counted against its quotas, when keys are configured
+	// Exige uma chave de API, contada nas suas cotas, quando chaves são configuradas
+	keys, counter := getAPIKeyStore(cfg.Auth)
+	app.keys = keys
+	if counter != nil {
+		app.closers = append(app.closers, counter) // Closes the connections to the shared counters
+	}
+
+	// Serve the admin endpoints only when enabled
+	// Atende os endpoints de administração só quando habilitados
+	if cfg.Admin.Enabled {
+		app.admin = handlers.NewAdminHandler(breakerClient)
+		app.admin.Keys = app.keys
+	}
+
+	// Check the upstreams for readiness when enabled
+	// Verifica as origens para a prontidão quando habilitado
+	healthChecks := getHealthChecks(cfg, registry.Providers(), weatherService)
+	app.health = handlers.NewHealthHandler(services.NewHealthMonitor(healthChecks, getHealthConfig(cfg.Health)))
+	return app, nil
+}
+
+// getHealthChecks builds the readiness checks, which probe the CEP providers and the weather
+// service when enabled. The configuration is not among them: it is validated at startup, and
+// an instance with an invalid one never starts. The CEP providers are critical together, the
+// instance failing only when all of them do; the weather check is only reported.
+// Monta as verificações de prontidão, que testam os provedores de CEP e o serviço de clima
+// quando habilitadas. A configuração não está entre elas: ela é validada na inicialização, e
+// uma instância com uma configuração inválida nunca sobe. Os provedores de CEP são críticos
+// em conjunto, e a instância só falha quando todos falham; a verificação de clima é só informada.
+func getHealthChecks(cfg config.Config, cepProviders []services.CEPProvider, weatherService services.WeatherService) []services.HealthCheck {
+	if !cfg.Health.ProbeUpstreams {
+		return nil
+	}
+	var checks []services.HealthCheck
+	for _, provider := range cepProviders {
+		checks = append(checks, services.NewCEPProviderHealthCheck(provider))
+	}
+	return append(checks, services.NewWeatherHealthCheck(weatherService))
+}
+
+// getWeatherProvider builds the configured weather providers, in priority order, combines
+// them with the configured strategy and optionally hedges slow calls. Each provider records
+// its calls in metrics.
+// Monta os provedores de clima configurados, em ordem de prioridade, os combina com a
+// estratégia configurada e opcionalmente envia reservas para chamadas lentas. Cada provedor
+// registra suas chamadas em metrics.
+func getWeatherProvider(apiClient services.APIClient, cfg config.WeatherConfig, metrics *services.Metrics) (services.WeatherProvider, error) {
+	providerConfig := services.WeatherProviderConfig{
+		WeatherAPIKey:         cfg.WeatherAPIKey,
+		WeatherAPIBaseURL:     cfg.BaseURLs.WeatherAPI,
+		OpenMeteoBaseURL:      cfg.BaseURLs.OpenMeteo,
+		OpenMeteoArchiveURL:   cfg.BaseURLs.OpenMeteoArchive,
+		OpenMeteoGeocodingURL: cfg.BaseURLs.OpenMeteoGeocoding,
+		OpenWeatherMapKey:     cfg.OpenWeatherMapKey,
+		OpenWeatherMapBaseURL: cfg.BaseURLs.OpenWeatherMap,
+	}
+	providers := make([]services.WeatherProvider, 0, len(cfg.Providers))
+	for _, name := range cfg.Providers {
+		provider, err := services.NewWeatherProvider(name, apiClient, providerConfig)
+		if err != nil {
+			return nil, err // Fail fast on an unknown provider name
+		}
+		providers = append(providers, services.NewInstrumentedWeatherProvider(provider, metrics))
+	}
+
+	strategyConfig := services.WeatherStrategyConfig{
+		AttemptTimeout:    cfg.ProviderTimeout,
+		OutlierThresholdC: cfg.ConsensusThreshold,
+	}
+	provider, err := services.NewWeatherStrategy(cfg.Strategy, providers, strategyConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hedge slow weather calls unless the hedge percentile is 0
+	// Envia requisições de reserva para chamadas de clima lentas, a menos que o percentil seja 0
+	if cfg.Hedge.Percentile <= 0 {
+		return provider, nil
+	}
+	var backup services.WeatherProvider // The hedge repeats the call on the same provider by default
+	if cfg.Hedge.Provider != "" {
+		if backup, err = services.NewWeatherProvider(cfg.Hedge.Provider, apiClient, providerConfig); err != nil {
+			return nil, err // Fail fast on an unknown provider name
+		}
+		backup = services.NewInstrumentedWeatherProvider(backup, metrics)
+	}
+	hedgeConfig := services.HedgeConfig{
+		Percentile: cfg.Hedge.Percentile,
+		MinDelay:   cfg.Hedge.MinDelay,
+		MaxDelay:   cfg.Hedge.MaxDelay,
+	}
+	return services.NewHedgedWeatherProvider(provider, backup, hedgeConfig), nil
+}
+
+// getCEPProviderRegistry builds the CEP provider registry with the configured providers and
+// base URLs, registering the "local" provider when a database file is configured.
+// Monta o registro de provedores de CEP com os provedores e URLs base configurados,
+// registrando o provedor "local" quando um arquivo de banco de dados é configurado.
+func getCEPProviderRegistry(apiClient services.APIClient, cfg config.CEPConfig) (*services.CEPProviderRegistry, error) {
+	registry := services.NewDefaultCEPProviderRegistry(apiClient, services.CEPProviderConfig{
+		BrasilAPIBaseURL:  cfg.BaseURLs.BrasilAPI,
+		ViaCEPBaseURL:     cfg.BaseURLs.ViaCEP,
+		OpenCEPBaseURL:    cfg.BaseURLs.OpenCEP,
+		AwesomeAPIBaseURL: cfg.BaseURLs.AwesomeAPI,
+	})
+
+	if cfg.DatabaseFile != "" {
+		localProvider, err := services.NewLocalCEPProvider(cfg.DatabaseFile)
+		if err != nil {
+			return nil, err // Fail fast on an unreadable database
+		}
+		registry.Register(localProvider)
+	}
+
+	if len(cfg.Providers) > 0 {
+		if err := registry.Configure(cfg.Providers); err != nil {
+			return nil, err // Fail fast on an unknown provider name
+		}
+	}
+	return registry, nil
+}
+
+// getLocationCacheConfig converts the CEP cache configuration.
+// Converte a configuração do cache de CEP.
+func getLocationCacheConfig(cfg config.CEPCacheConfig) services.LocationCacheConfig {
+	return services.LocationCacheConfig{
+		TTL:         cfg.TTL,
+		StaleTTL:    cfg.StaleTTL,
+		NegativeTTL: cfg.NegativeTTL,
+		Size:        cfg.Size,
+	}
+}
+
+// getWeatherCacheConfig converts the weather cache configuration.
+// Converte a configuração do cache de clima.
+func getWeatherCacheConfig(cfg config.WeatherCacheConfig) services.WeatherCacheConfig {
+	return services.WeatherCacheConfig{
+		TTL:    cfg.TTL,
+		MinTTL: cfg.MinTTL,
+		Size:   cfg.Size,
+	}
+}
+
+// getBreakerConfig converts the upstream circuit breaker configuration.
+// Converte a configuração dos circuit breakers das origens.
+func getBreakerConfig(cfg config.BreakerConfig) shared.BreakerConfig {
+	return shared.BreakerConfig{
+		FailureRate:    cfg.FailureRate,
+		MinRequests:    cfg.MinRequests,
+		Window:         cfg.Window,
+		Cooldown:       cfg.Cooldown,
+		HalfOpenProbes: cfg.HalfOpenProbes,
+	}
+}
+
+// getServerConfig converts the HTTP server configuration.
+// Converte a configuração do servidor HTTP.
+func getServerConfig(cfg config.ServerConfig) shared.ServerConfig {
+	return shared.ServerConfig{
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ShutdownTimeout:   cfg.ShutdownTimeout,
+	}
+}
+
+// getTracer builds the tracer and its exporter from the tracing configuration. Both are nil
+// when tracing is disabled; the exporter must be closed to send the last spans.
+// Monta o tracer e seu exportador a partir da configuração de tracing. Ambos são nil quando
+// o tracing está desabilitado; o exportador deve ser fechado para enviar os últimos spans.
+func getTracer(cfg config.TracingConfig) (*shared.Tracer, io.Closer) {
+	switch cfg.Exporter {
+	case "stdout":
+		return shared.NewTracer(shared.NewWriterExporter(os.Stdout), cfg.SampleRatio), nil
+	case "otlp":
+		exporter := shared.NewOTLPExporter(cfg.OTLPEndpoint, cfg.ServiceName)
+		return shared.NewTracer(exporter, cfg.SampleRatio), exporter
+	default:
+		return nil, nil
+	}
+}
+
+// getLoggingConfig converts the logging configuration, already validated.
+// Converte a configuração de logs, já validada.
+func getLoggingConfig(cfg config.LoggingConfig) shared.LoggingConfig {
+	level, _ := shared.ParseLogLevel(cfg.Level)
+	return shared.LoggingConfig{
+		Level:     level,
+		Format:    cfg.Format,
+		ProjectID: cfg.ProjectID,
+	}
+}
+
+// getAPIKeyStore builds the API key store, applying the default quotas to the keys without
+// their own and counting the usage in Redis when configured; the Redis client is returned to be
+// closed on shutdown. It returns nil when no key is configured, which leaves the weather routes
+// public while the admin routes stay closed.
+// Monta o repositório de chaves de API, aplicando as cotas padrão às chaves sem cotas próprias
+// e contando o uso no Redis quando configurado; o cliente Redis é retornado para ser fechado no
+// desligamento. Retorna nil quando nenhuma chave está configurada, o que deixa as rotas de
+// clima públicas enquanto as rotas de administração continuam fechadas.
+func getAPIKeyStore(cfg config.AuthConfig) (*services.APIKeyStore, io.Closer) {
+	if len(cfg.Keys) == 0 {
+		return nil, nil
+	}
+	keys := make([]services.APIKey, 0, len(cfg.Keys))
+	limited := false
+	for _, key := range cfg.Keys {
+		apiKey := services.APIKey{
+			Client:       key.Client,
+			Key:          key.Key,
+			DailyQuota:   cmp.Or(key.DailyQuota, cfg.DailyQuota),
+			MonthlyQuota: cmp.Or(key.MonthlyQuota, cfg.MonthlyQuota),
+			Admin:        key.Admin,
+		}
+		limited = limited || apiKey.DailyQuota > 0 || apiKey.MonthlyQuota > 0
+		keys = append(keys, apiKey)
+	}
+	store := services.NewAPIKeyStore(keys)
+
+	// Share the counters among the instances, so the quotas hold for the whole service
+	// Compartilha os contadores entre as instâncias, para que as cotas valham para o serviço inteiro
+	if cfg.RedisAddr == "" {
+		if limited {
+			slog.Warn("API key quotas are counted per instance; set AUTH_REDIS_ADDR or run a single instance")
+		}
+		return store, nil
+	}
+	client := shared.NewRedisClient(cfg.RedisAddr, cfg.RedisPassword)
+	store.Counter = services.NewRedisUsageCounter(client)
+	return store, client
+}
+
+// getHealthConfig converts the readiness check configuration.
+// Converte a configuração das verificações de prontidão.
+func getHealthConfig(cfg config.HealthConfig) services.HealthConfig {
+	return services.HealthConfig{
+		CacheTTL: cfg.CacheTTL,
+		Timeout:  cfg.Timeout,
+	}
+}
+
+// getRetryConfig converts the upstream retry configuration.
+// Converte a configuração de novas tentativas nas origens.
+func getRetryConfig(cfg config.RetryConfig) services.RetryConfig {
+	return services.RetryConfig{
+		MaxAttempts:    cfg.MaxAttempts,
+		AttemptTimeout: cfg.AttemptTimeout,
+		BaseDelay:      cfg.BaseDelay,
+		MaxDelay:       cfg.MaxDelay,
+		Budget:         cfg.Budget,
+	}
 }
 
 // main function that starts the HTTP server
 // Função main que inicia o servidor HTTP
 func main() {
+	configFile := flag.String("config", "", "YAML or JSON configuration file (default $CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration, secrets redacted, and exit")
+	flag.Parse()
+
 	// Load environment variables from the .env file
 	// Carrega as variáveis de ambiente do arquivo .env
 	godotenv.Load()
 
-	// Get the weather handler to handle incoming weather-related requests
-	// Obtém o handler de clima para lidar com requisições relacionadas ao clima
-	weatherHandler := getHandler()
+	// Load and validate the configuration from the defaults, the file and the environment
+	// Carrega e valida a configuração a partir dos padrões, do arquivo e do ambiente
+	cfg, err := config.Load(*configFile)
+	if *printConfig {
+		cfg.Print(os.Stdout) // Print even an invalid configuration, it helps finding the mistake
+	}
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		return
+	}
 
-	// Define the route for weather data, and associate it with the WeatherHandler
-	// Define a rota para os dados do clima e associa com o WeatherHandler
-	http.HandleFunc("/weather", weatherHandler.WeatherHandlerFunc())
+	// Write structured logs, in the format Cloud Logging reads by default
+	// Escreve logs estruturados, no formato que o Cloud Logging lê por padrão
+	slog.SetDefault(shared.NewLogger(os.Stdout, getLoggingConfig(cfg.Logging)))
+
+	// Build the handlers and the services behind them
+	// Monta os handlers e os serviços por trás deles
+	app, err := getApplication(cfg)
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
 
-	// Get the port number from environment variable, default to "8080" if not set
-	// Obtém o número da porta da variável de ambiente, padrão para "8080" se não estiver definida
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default port if not provided
+	// Stop on SIGTERM, sent by Cloud Run before stopping an instance, or on Ctrl+C
+	// Para ao receber SIGTERM, enviado pelo Cloud Run antes de parar uma instância, ou com Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	listener, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		slog.Error("Error listening", "port", cfg.Port, "error", err)
+		app.Close()
+		os.Exit(1)
 	}
 
 	// Log the port the server is running on
 	// Registra o número da porta em que o servidor está rodando
-	log.Printf("Server running on port %s", port)
+	slog.Info("Server running", "port", cfg.Port)
+
+	// Serve until a signal arrives, then drain the in-flight requests
+	// Atende até chegar um sinal e então conclui as requisições em andamento
+	server := shared.NewServer(app.routes(), getServerConfig(cfg.Server))
+	err = shared.Serve(ctx, server, listener, cfg.Server.ShutdownTimeout)
 
-	// Start the HTTP server and log any fatal errors
-	// Inicia o servidor HTTP e registra qualquer erro fatal
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	// Cancel the background work once no request needs it anymore
+	// Cancela o trabalho em segundo plano quando nenhuma requisição precisa mais dele
+	app.Close()
+	if err != nil {
+		slog.Error("Server stopped with error", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Server stopped")
 }
@@ -1,10 +1,14 @@
 package models
 
+import "time"
+
 type Location struct {
-	Cep        *string `json:"cep"`
-	Localidade *string `json:"localidade"` // City
-	Uf         *string `json:"uf"`
-	City       *string // alias for Localidade
+	Cep        *string  `json:"cep"`
+	Localidade *string  `json:"localidade"` // City
+	Uf         *string  `json:"uf"`
+	City       *string  // alias for Localidade
+	Latitude   *float64 `json:"latitude,omitempty"`  // Set when the CEP provider knows the coordinates
+	Longitude  *float64 `json:"longitude,omitempty"` // Set when the CEP provider knows the coordinates
 }
 
 type WeatherResponse struct {
@@ -55,14 +59,445 @@ type WeatherResponse struct {
 	} `json:"current"`
 }
 
+// Struct para a resposta de previsão (e histórico) da weatherapi.com
+// Struct to hold the forecast (and history) response from weatherapi.com
+type WeatherForecastResponse struct {
+	Location struct {
+		Name    string  `json:"name"`
+		Region  string  `json:"region"`
+		Country string  `json:"country"`
+		Lat     float64 `json:"lat"`
+		Lon     float64 `json:"lon"`
+		TzID    string  `json:"tz_id"`
+	} `json:"location"`
+	Forecast struct {
+		ForecastDay []WeatherAPIForecastDay `json:"forecastday"`
+	} `json:"forecast"`
+}
+
+// WeatherAPIForecastDay is a single day of a weatherapi.com forecast or history.
+// WeatherAPIForecastDay é um único dia de uma previsão ou histórico da weatherapi.com.
+type WeatherAPIForecastDay struct {
+	Date      string `json:"date"`
+	DateEpoch int64  `json:"date_epoch"`
+	Day       struct {
+		MaxTempC          float64 `json:"maxtemp_c"`
+		MinTempC          float64 `json:"mintemp_c"`
+		AvgTempC          float64 `json:"avgtemp_c"`
+		MaxWindKph        float64 `json:"maxwind_kph"`
+		TotalPrecipMm     float64 `json:"totalprecip_mm"`
+		AvgHumidity       float64 `json:"avghumidity"`
+		DailyChanceOfRain int     `json:"daily_chance_of_rain"`
+		UV                float64 `json:"uv"`
+		Condition         struct {
+			Text string `json:"text"`
+			Icon string `json:"icon"`
+			Code int    `json:"code"`
+		} `json:"condition"`
+	} `json:"day"`
+	Hour []WeatherAPIForecastHour `json:"hour"`
+}
+
+// WeatherAPIForecastHour is a single hour of a weatherapi.com forecast or history.
+// WeatherAPIForecastHour é uma única hora de uma previsão ou histórico da weatherapi.com.
+type WeatherAPIForecastHour struct {
+	TimeEpoch    int64   `json:"time_epoch"`
+	Time         string  `json:"time"`
+	TempC        float64 `json:"temp_c"`
+	FeelsLikeC   float64 `json:"feelslike_c"`
+	WindKph      float64 `json:"wind_kph"`
+	Humidity     int     `json:"humidity"`
+	ChanceOfRain int     `json:"chance_of_rain"`
+	Condition    struct {
+		Text string `json:"text"`
+		Icon string `json:"icon"`
+		Code int    `json:"code"`
+	} `json:"condition"`
+}
+
+// CurrentWeather is a provider-neutral current-conditions observation, in metric units.
+// CurrentWeather é uma observação das condições atuais neutra em relação ao provedor, em unidades métricas.
+type CurrentWeather struct {
+	Providers   []string         // Weather providers whose answer was used
+	Outliers    []string         // Weather providers whose answer was discarded by consensus
+	TempC       float64          // Air temperature
+	FeelsLikeC  float64          // Apparent temperature
+	Condition   WeatherCondition // Sky condition
+	Humidity    int              // Relative humidity, in percent
+	Cloud       int              // Cloud cover, in percent
+	UV          float64          // UV index
+	IsDay       bool             // Whether the sun is up at the location
+	WindKph     float64          // Wind speed
+	GustKph     float64          // Wind gust speed
+	WindDegree  int              // Wind direction, in degrees
+	WindDir     string           // Wind direction as a compass point, e.g. "NNE"
+	PressureMb  float64          // Sea-level pressure
+	PrecipMm    float64          // Recent precipitation
+	VisKm       float64          // Visibility
+	LastUpdated time.Time        // When the provider last updated the observation, zero if unknown
+	FetchedAt   time.Time        // When the observation was fetched from the provider
+}
+
+// WeatherCondition describes the sky condition. Codes are provider specific.
+// WeatherCondition descreve a condição do céu. Os códigos dependem do provedor.
+type WeatherCondition struct {
+	Text string // Human-readable condition, e.g. "Partly cloudy"
+	Icon string // Icon URL, empty when the provider has none
+	Code int    // Provider condition code
+}
+
+// Forecast is a provider-neutral daily and hourly forecast (or history) and the moment it was fetched.
+// Forecast é uma previsão (ou histórico) diária e horária neutra em relação ao provedor e o momento em que foi buscada.
+type Forecast struct {
+	Providers []string      // Weather providers whose answer was used
+	Outliers  []string      // Weather providers whose answer was discarded by consensus
+	Days      []ForecastDay // One entry per local day, in order
+	FetchedAt time.Time     // When the forecast was fetched from the provider
+}
+
+// ForecastDay is a single day of a forecast or history.
+// ForecastDay é um único dia de uma previsão ou histórico.
+type ForecastDay struct {
+	Date         string           // Local date, YYYY-MM-DD
+	MinTempC     float64          // Minimum temperature
+	MaxTempC     float64          // Maximum temperature
+	AvgTempC     float64          // Average temperature
+	Condition    WeatherCondition // Prevailing sky condition
+	ChanceOfRain int              // Chance of rain, in percent
+	PrecipMm     float64          // Total precipitation
+	Hours        []ForecastHour   // Hourly breakdown, may be empty
+}
+
+// ForecastHour is a single hour of a forecast or history.
+// ForecastHour é uma única hora de uma previsão ou histórico.
+type ForecastHour struct {
+	Time         string           // Local time, YYYY-MM-DD HH:MM
+	TempC        float64          // Air temperature
+	Condition    WeatherCondition // Sky condition
+	ChanceOfRain int              // Chance of rain, in percent
+}
+
+// ForecastResponse is the document returned by /v1/forecast.
+// ForecastResponse é o documento retornado por /v1/forecast.
+type ForecastResponse struct {
+	Address   AddressResponse       `json:"address"`
+	Days      []ForecastDayResponse `json:"days"`
+	Providers []string              `json:"providers,omitempty"` // Weather providers whose answer was used
+	Outliers  []string              `json:"outliers,omitempty"`  // Weather providers discarded by consensus
+}
+
+// ForecastDayResponse summarizes one forecast day with its hourly breakdown.
+// ForecastDayResponse resume um dia de previsão com o detalhamento por hora.
+type ForecastDayResponse struct {
+	Date         string                 `json:"date"`
+	Min          TemperatureResponse    `json:"min"`
+	Max          TemperatureResponse    `json:"max"`
+	Avg          TemperatureResponse    `json:"avg"`
+	Condition    ConditionResponse      `json:"condition"`
+	ChanceOfRain int                    `json:"chance_of_rain_percent"`
+	PrecipMm     float64                `json:"total_precipitation_mm"`
+	Hours        []ForecastHourResponse `json:"hours"`
+}
+
+// ForecastHourResponse is the temperature and condition for one hour, in local time.
+// ForecastHourResponse é a temperatura e a condição de uma hora, no horário local.
+type ForecastHourResponse struct {
+	Time         string              `json:"time"`
+	Temperature  TemperatureResponse `json:"temperature"`
+	Condition    ConditionResponse   `json:"condition"`
+	ChanceOfRain int                 `json:"chance_of_rain_percent"`
+}
+
+// HistoryResponse is the document returned by /v1/history.
+// HistoryResponse é o documento retornado por /v1/history.
+type HistoryResponse struct {
+	Address   AddressResponse      `json:"address"`
+	Days      []HistoryDayResponse `json:"days"`
+	Providers []string             `json:"providers,omitempty"` // Weather providers whose answer was used
+	Outliers  []string             `json:"outliers,omitempty"`  // Weather providers discarded by consensus
+}
+
+// HistoryDayResponse is the observed weather of one day: the average temperature in the
+// same format as TemperatureResponse, plus the extremes and the hourly breakdown.
+// HistoryDayResponse é o clima observado em um dia: a temperatura média no mesmo formato
+// de TemperatureResponse, além dos extremos e do detalhamento por hora.
+type HistoryDayResponse struct {
+	Date string `json:"date"`
+	TemperatureResponse
+	Min       TemperatureResponse    `json:"min"`
+	Max       TemperatureResponse    `json:"max"`
+	Condition ConditionResponse      `json:"condition"`
+	PrecipMm  float64                `json:"total_precipitation_mm"`
+	Hours     []ForecastHourResponse `json:"hours"`
+}
+
 type TemperatureResponse struct {
 	Celsius    float64 `json:"temp_C"`
 	Fahrenheit float64 `json:"temp_F"`
 	Kelvin     float64 `json:"temp_K"`
 }
 
+// ConditionsResponse is the normalized current-conditions document returned by /v1/conditions.
+// ConditionsResponse é o documento normalizado de condições atuais retornado por /v1/conditions.
+type ConditionsResponse struct {
+	Address       AddressResponse       `json:"address"`
+	Temperature   TemperatureResponse   `json:"temperature"`
+	FeelsLike     TemperatureResponse   `json:"feels_like"`
+	Condition     ConditionResponse     `json:"condition"`
+	Humidity      int                   `json:"humidity_percent"`
+	Cloud         int                   `json:"cloud_percent"`
+	UV            float64               `json:"uv_index"`
+	IsDay         bool                  `json:"is_day"`
+	Wind          WindResponse          `json:"wind"`
+	Pressure      PressureResponse      `json:"pressure"`
+	Precipitation PrecipitationResponse `json:"precipitation"`
+	Visibility    VisibilityResponse    `json:"visibility"`
+	LastUpdated   *time.Time            `json:"last_updated,omitempty"` // When the weather API last updated the observation
+	Providers     []string              `json:"providers,omitempty"`    // Weather providers whose answer was used
+	Outliers      []string              `json:"outliers,omitempty"`     // Weather providers discarded by consensus
+}
+
+// AddressResponse is the location resolved from the CEP.
+// AddressResponse é a localização resolvida a partir do CEP.
+type AddressResponse struct {
+	Cep          string   `json:"cep"`
+	City         string   `json:"city"`
+	UF           string   `json:"uf,omitempty"`
+	Neighborhood string   `json:"neighborhood,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+}
+
+// ConditionResponse describes the sky condition, e.g. "Partly cloudy".
+// ConditionResponse descreve a condição do céu, ex.: "Partly cloudy".
+type ConditionResponse struct {
+	Text string `json:"text"`
+	Icon string `json:"icon"`
+	Code int    `json:"code"`
+}
+
+// WindResponse holds wind speed, gusts and direction in several units.
+// WindResponse contém velocidade, rajadas e direção do vento em várias unidades.
+type WindResponse struct {
+	Kph       float64 `json:"speed_kph"`
+	Mph       float64 `json:"speed_mph"`
+	Ms        float64 `json:"speed_ms"`
+	GustKph   float64 `json:"gust_kph"`
+	GustMph   float64 `json:"gust_mph"`
+	GustMs    float64 `json:"gust_ms"`
+	Degree    int     `json:"degree"`
+	Direction string  `json:"direction"`
+}
+
+// PressureResponse holds the atmospheric pressure in millibars and inches of mercury.
+// PressureResponse contém a pressão atmosférica em milibares e polegadas de mercúrio.
+type PressureResponse struct {
+	Mb float64 `json:"mb"`
+	In float64 `json:"in"`
+}
+
+// PrecipitationResponse holds the precipitation amount in millimeters and inches.
+// PrecipitationResponse contém a precipitação em milímetros e polegadas.
+type PrecipitationResponse struct {
+	Mm float64 `json:"mm"`
+	In float64 `json:"in"`
+}
+
+// VisibilityResponse holds the visibility in kilometers and miles.
+// VisibilityResponse contém a visibilidade em quilômetros e milhas.
+type VisibilityResponse struct {
+	Km    float64 `json:"km"`
+	Miles float64 `json:"miles"`
+}
+
+// BreakersResponse lists the circuit breaker of every upstream host, returned by /admin/breakers.
+// BreakersResponse lista o circuit breaker de cada host de origem, retornado por /admin/breakers.
+type BreakersResponse struct {
+	Breakers []BreakerResponse `json:"breakers"`
+}
+
+// BreakerResponse is the state of the circuit breaker of one upstream host.
+// BreakerResponse é o estado do circuit breaker de um host de origem.
+type BreakerResponse struct {
+	Host     string     `json:"host"`
+	State    string     `json:"state"`               // "closed", "open" or "half-open"
+	Requests int        `json:"requests"`            // Calls counted in the current window
+	Failures int        `json:"failures"`            // Failures counted in the current window
+	OpenedAt *time.Time `json:"opened_at,omitempty"` // When the breaker last opened
+}
+
+// UsageResponse lists the usage of every API key, returned by /admin/usage.
+// UsageResponse lista o uso de cada chave de API, retornado por /admin/usage.
+type UsageResponse struct {
+	Keys []KeyUsageResponse `json:"keys"`
+}
+
+// KeyUsageResponse is the usage of one API key in the current UTC day and month.
+// KeyUsageResponse é o uso de uma chave de API no dia e no mês UTC atuais.
+type KeyUsageResponse struct {
+	Client       string `json:"client"`
+	Admin        bool   `json:"admin"`
+	DailyUsed    int    `json:"daily_used"`
+	DailyQuota   int    `json:"daily_quota"` // 0 when unlimited
+	MonthlyUsed  int    `json:"monthly_used"`
+	MonthlyQuota int    `json:"monthly_quota"` // 0 when unlimited
+}
+
+// HealthResponse is the overall status returned by /healthz and /readyz.
+// HealthResponse é o estado geral retornado por /healthz e /readyz.
+type HealthResponse struct {
+	Status       string               `json:"status"`                 // "ok" or "unavailable"
+	Dependencies []DependencyResponse `json:"dependencies,omitempty"` // Status of each dependency, only on /readyz
+}
+
+// DependencyResponse is the status of one dependency checked by /readyz.
+// DependencyResponse é o estado de uma dependência verificada por /readyz.
+type DependencyResponse struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`          // "ok" or "failing"
+	Critical  bool      `json:"critical"`        // Whether a failure makes the instance not ready
+	Group     string    `json:"group,omitempty"` // Critical dependencies covering for each other, failing only together
+	Error     string    `json:"error,omitempty"` // Why the check failed: "timeout", "auth", "quota", "not_found" or "unavailable"
+	LatencyMs int64     `json:"latency_ms"`      // How long the check took
+	CheckedAt time.Time `json:"checked_at"`      // When the check ran; results are cached for a while
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
+	Code  string `json:"code,omitempty"` // Machine-readable error code, when the failure has one
+}
+
+// Struct para o objeto de erro da weatherapi.com
+// Struct to hold the error payload returned by weatherapi.com
+type WeatherAPIErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Struct para a resposta de previsão da Open-Meteo (com timeformat=unixtime)
+// Struct to hold the forecast and archive response from Open-Meteo (with timeformat=unixtime)
+type OpenMeteoResponse struct {
+	Latitude         float64 `json:"latitude"`
+	Longitude        float64 `json:"longitude"`
+	UTCOffsetSeconds int     `json:"utc_offset_seconds"`
+	Current          struct {
+		Time                int64   `json:"time"`
+		Temperature         float64 `json:"temperature_2m"`
+		RelativeHumidity    int     `json:"relative_humidity_2m"`
+		ApparentTemperature float64 `json:"apparent_temperature"`
+		IsDay               int     `json:"is_day"`
+		Precipitation       float64 `json:"precipitation"`
+		WeatherCode         int     `json:"weather_code"`
+		CloudCover          int     `json:"cloud_cover"`
+		PressureMSL         float64 `json:"pressure_msl"`
+		WindSpeed           float64 `json:"wind_speed_10m"`
+		WindDirection       int     `json:"wind_direction_10m"`
+		WindGusts           float64 `json:"wind_gusts_10m"`
+		UVIndex             float64 `json:"uv_index"`
+		Visibility          float64 `json:"visibility"` // Meters
+	} `json:"current"`
+	Hourly struct {
+		Time                     []int64   `json:"time"`
+		Temperature              []float64 `json:"temperature_2m"`
+		PrecipitationProbability []int     `json:"precipitation_probability"` // Not returned by the archive API
+		WeatherCode              []int     `json:"weather_code"`
+	} `json:"hourly"`
+	Daily struct {
+		Time                        []int64   `json:"time"`
+		WeatherCode                 []int     `json:"weather_code"`
+		TemperatureMax              []float64 `json:"temperature_2m_max"`
+		TemperatureMin              []float64 `json:"temperature_2m_min"`
+		PrecipitationSum            []float64 `json:"precipitation_sum"`
+		PrecipitationProbabilityMax []int     `json:"precipitation_probability_max"` // Not returned by the archive API
+	} `json:"daily"`
+}
+
+// Struct para a resposta da API de geocodificação da Open-Meteo
+// Struct to hold the response from the Open-Meteo geocoding API
+type OpenMeteoGeocodingResponse struct {
+	Results []struct {
+		Name        string  `json:"name"`
+		Latitude    float64 `json:"latitude"`
+		Longitude   float64 `json:"longitude"`
+		CountryCode string  `json:"country_code"`
+		Admin1      string  `json:"admin1"` // State name, e.g. "São Paulo"
+	} `json:"results"`
+}
+
+// Struct para o objeto de erro da Open-Meteo
+// Struct to hold the error payload returned by Open-Meteo
+type OpenMeteoErrorResponse struct {
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+}
+
+// OpenWeatherMapCondition is a condition entry of an OpenWeatherMap response.
+// OpenWeatherMapCondition é uma condição de uma resposta da OpenWeatherMap.
+type OpenWeatherMapCondition struct {
+	ID          int    `json:"id"`
+	Main        string `json:"main"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// Struct para a resposta de clima atual da OpenWeatherMap (units=metric)
+// Struct to hold the current weather response from OpenWeatherMap (units=metric)
+type OpenWeatherMapResponse struct {
+	Weather []OpenWeatherMapCondition `json:"weather"`
+	Main    struct {
+		Temp      float64 `json:"temp"`
+		FeelsLike float64 `json:"feels_like"`
+		Pressure  float64 `json:"pressure"`
+		Humidity  int     `json:"humidity"`
+	} `json:"main"`
+	Visibility float64 `json:"visibility"` // Meters
+	Wind       struct {
+		Speed float64 `json:"speed"` // Meters per second
+		Deg   int     `json:"deg"`
+		Gust  float64 `json:"gust"` // Meters per second
+	} `json:"wind"`
+	Clouds struct {
+		All int `json:"all"`
+	} `json:"clouds"`
+	Rain struct {
+		OneHour float64 `json:"1h"`
+	} `json:"rain"`
+	Dt  int64 `json:"dt"`
+	Sys struct {
+		Sunrise int64 `json:"sunrise"`
+		Sunset  int64 `json:"sunset"`
+	} `json:"sys"`
+	Timezone int    `json:"timezone"` // Offset from UTC, in seconds
+	Name     string `json:"name"`
+}
+
+// Struct para a resposta de previsão em intervalos de 3 horas da OpenWeatherMap
+// Struct to hold the 3-hour step forecast response from OpenWeatherMap
+type OpenWeatherMapForecastResponse struct {
+	List []struct {
+		Dt   int64 `json:"dt"`
+		Main struct {
+			Temp    float64 `json:"temp"`
+			TempMin float64 `json:"temp_min"`
+			TempMax float64 `json:"temp_max"`
+		} `json:"main"`
+		Weather []OpenWeatherMapCondition `json:"weather"`
+		Pop     float64                   `json:"pop"` // Probability of precipitation, from 0 to 1
+		Rain    struct {
+			ThreeHours float64 `json:"3h"`
+		} `json:"rain"`
+	} `json:"list"`
+	City struct {
+		Timezone int `json:"timezone"` // Offset from UTC, in seconds
+	} `json:"city"`
+}
+
+// Struct para o objeto de erro da OpenWeatherMap
+// Struct to hold the error payload returned by OpenWeatherMap
+type OpenWeatherMapErrorResponse struct {
+	Message string `json:"message"`
 }
 
 // Structs para as respostas das APIs
@@ -92,4 +527,35 @@ type BrasilAPIResponse struct {
 	Neighborhood string `json:"neighborhood"`
 	Street       string `json:"street"`
 	Service      string `json:"service"`
+	Location     struct {
+		Type        string `json:"type"`
+		Coordinates struct {
+			Longitude string `json:"longitude"`
+			Latitude  string `json:"latitude"`
+		} `json:"coordinates"`
+	} `json:"location"` // Only returned by the v2 endpoint
+}
+
+// Struct para a resposta da AwesomeAPI
+// Struct to hold the response from AwesomeAPI
+type AwesomeAPIResponse struct {
+	CEP         string `json:"cep"`
+	AddressType string `json:"address_type"`
+	AddressName string `json:"address_name"`
+	Address     string `json:"address"`
+	State       string `json:"state"`
+	District    string `json:"district"`
+	Lat         string `json:"lat"`
+	Lng         string `json:"lng"`
+	City        string `json:"city"`
+	CityIBGE    string `json:"city_ibge"`
+	DDD         string `json:"ddd"`
+}
+
+// Struct para um registro do banco de dados local de CEPs
+// Struct to hold an entry of the local CEP database
+type LocalCEPEntry struct {
+	City         string `json:"city"`
+	UF           string `json:"uf"`
+	Neighborhood string `json:"neighborhood"`
 }
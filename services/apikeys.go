@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrAPIKeyMissing and ErrAPIKeyInvalid are returned when a request has no API key or one
+// that is not configured.
+// ErrAPIKeyMissing e ErrAPIKeyInvalid são retornados quando uma requisição não tem chave de API
+// ou tem uma que não está configurada.
+var (
+	ErrAPIKeyMissing = errors.New("API key required")
+	ErrAPIKeyInvalid = errors.New("invalid API key")
+)
+
+// QuotaExceededError is returned when a key has used up its daily or monthly quota.
+// QuotaExceededError é retornado quando uma chave esgotou sua cota diária ou mensal.
+type QuotaExceededError struct {
+	Client  string    // Client owning the key
+	Period  string    // "daily" or "monthly"
+	Limit   int       // Requests allowed in the period
+	ResetAt time.Time // When the period ends and the quota is available again
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %d requests exceeded", e.Period, e.Limit)
+}
+
+// APIKey is a key accepted by the API and the quotas of the client owning it.
+// APIKey é uma chave aceita pela API e as cotas do cliente dono dela.
+type APIKey struct {
+	Client       string // Name shown in the usage report
+	Key          string // Secret sent by the client
+	DailyQuota   int    // Requests per UTC day, 0 for unlimited
+	MonthlyQuota int    // Requests per UTC month, 0 for unlimited
+	Admin        bool   // Whether the key can reach the /admin endpoints
+}
+
+// KeyUsage is the usage of a key in the current UTC day and month.
+// KeyUsage é o uso de uma chave no dia e no mês UTC atuais.
+type KeyUsage struct {
+	Client       string
+	Admin        bool
+	DailyUsed    int // Requests counted today
+	DailyQuota   int // 0 for unlimited
+	MonthlyUsed  int // Requests counted this month
+	MonthlyQuota int // 0 for unlimited
+}
+
+// ErrUsageUnavailable is returned when the usage counters cannot be reached, e.g. when the
+// shared backend is down. The request is rejected, since it could not be counted.
+// ErrUsageUnavailable é retornado quando os contadores de uso não podem ser alcançados, por
+// exemplo quando o backend compartilhado está fora do ar. A requisição é rejeitada, já que não
+// pôde ser contada.
+var ErrUsageUnavailable = errors.New("API key usage unavailable")
+
+// UsageCounter keeps the request counters of the API keys, per UTC day and month. Counters
+// shared by every instance, such as RedisUsageCounter, make the quotas hold for the whole
+// service; MemoryUsageCounter only counts the requests of its own instance.
+// UsageCounter guarda os contadores de requisições das chaves de API, por dia e mês UTC.
+// Contadores compartilhados por todas as instâncias, como o RedisUsageCounter, fazem as cotas
+// valerem para o serviço inteiro; o MemoryUsageCounter só conta as requisições da sua instância.
+type UsageCounter interface {
+	// Add adds delta to the counters of the key id in the day and the month starting at day
+	// and month, and returns them.
+	// Soma delta aos contadores da chave id no dia e no mês que começam em day e month, e os retorna.
+	Add(ctx context.Context, id string, day, month time.Time, delta int) (daily, monthly int, err error)
+
+	// Get returns the counters of the key id in the day and the month starting at day and month.
+	// Retorna os contadores da chave id no dia e no mês que começam em day e month.
+	Get(ctx context.Context, id string, day, month time.Time) (daily, monthly int, err error)
+}
+
+// APIKeyStore authenticates the API keys and counts their requests against the quotas in
+// Counter.
+// APIKeyStore autentica as chaves de API e conta suas requisições contra as cotas em Counter.
+type APIKeyStore struct {
+	Counter UsageCounter     // Where requests are counted, in memory unless replaced by a shared one
+	Now     func() time.Time // Clock, replaceable in tests
+
+	keys map[[sha256.Size]byte]APIKey // By key hash, so lookups do not compare the secrets
+}
+
+// NewAPIKeyStore creates a store accepting keys, counting their requests in memory.
+// Cria um repositório que aceita keys, contando as suas requisições em memória.
+func NewAPIKeyStore(keys []APIKey) *APIKeyStore {
+	store := &APIKeyStore{Counter: NewMemoryUsageCounter(), Now: time.Now, keys: make(map[[sha256.Size]byte]APIKey, len(keys))}
+	for _, key := range keys {
+		store.keys[sha256.Sum256([]byte(key.Key))] = key
+	}
+	return store
+}
+
+// Authenticate returns the configured key matching key, without counting a request.
+// Retorna a chave configurada correspondente a key, sem contar uma requisição.
+func (s *APIKeyStore) Authenticate(key string) (APIKey, error) {
+	if key == "" {
+		return APIKey{}, ErrAPIKeyMissing
+	}
+	apiKey, ok := s.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return APIKey{}, ErrAPIKeyInvalid
+	}
+	return apiKey, nil
+}
+
+// Consume authenticates key and counts one request against its quotas. A request over a quota
+// is rejected with a *QuotaExceededError and not counted; one that cannot be counted is
+// rejected with ErrUsageUnavailable.
+// Autentica key e conta uma requisição contra suas cotas. Uma requisição acima de uma cota é
+// rejeitada com um *QuotaExceededError e não é contada; uma que não pode ser contada é
+// rejeitada com ErrUsageUnavailable.
+func (s *APIKeyStore) Consume(ctx context.Context, key string) (KeyUsage, error) {
+	apiKey, err := s.Authenticate(key)
+	if err != nil {
+		return KeyUsage{}, err
+	}
+
+	// Count first and undo when over a quota, so concurrent requests on other instances never
+	// both take the last request of a quota
+	// Conta primeiro e desfaz quando acima de uma cota, para que requisições simultâneas em
+	// outras instâncias nunca levem ambas a última requisição de uma cota
+	id := usageID(key)
+	day, month := s.periods()
+	daily, monthly, err := s.Counter.Add(ctx, id, day, month, 1)
+	if err != nil {
+		return KeyUsage{}, fmt.Errorf("%w: %w", ErrUsageUnavailable, err)
+	}
+	usage := newKeyUsage(apiKey, daily, monthly)
+
+	var quotaErr *QuotaExceededError
+	switch {
+	case apiKey.DailyQuota > 0 && daily > apiKey.DailyQuota:
+		quotaErr = &QuotaExceededError{Client: apiKey.Client, Period: "daily", Limit: apiKey.DailyQuota, ResetAt: day.AddDate(0, 0, 1)}
+	case apiKey.MonthlyQuota > 0 && monthly > apiKey.MonthlyQuota:
+		quotaErr = &QuotaExceededError{Client: apiKey.Client, Period: "monthly", Limit: apiKey.MonthlyQuota, ResetAt: month.AddDate(0, 1, 0)}
+	default:
+		return usage, nil
+	}
+
+	// Undo even when the client is gone, so the rejected request does not stay counted
+	// Desfaz mesmo quando o cliente foi embora, para que a requisição rejeitada não fique contada
+	if _, _, err := s.Counter.Add(context.WithoutCancel(ctx), id, day, month, -1); err != nil {
+		return usage, fmt.Errorf("%w: %w", ErrUsageUnavailable, err)
+	}
+	usage.DailyUsed--
+	usage.MonthlyUsed--
+	return usage, quotaErr
+}
+
+// Usage returns the usage of every key, sorted by client.
+// Retorna o uso de todas as chaves, ordenado por cliente.
+func (s *APIKeyStore) Usage(ctx context.Context) ([]KeyUsage, error) {
+	day, month := s.periods()
+	usage := make([]KeyUsage, 0, len(s.keys))
+	for _, apiKey := range s.keys {
+		daily, monthly, err := s.Counter.Get(ctx, usageID(apiKey.Key), day, month)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUsageUnavailable, err)
+		}
+		usage = append(usage, newKeyUsage(apiKey, daily, monthly))
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Client < usage[j].Client })
+	return usage, nil
+}
+
+// periods returns the start of the current UTC day and month.
+// Retorna o início do dia e do mês UTC atuais.
+func (s *APIKeyStore) periods() (day, month time.Time) {
+	now := s.Now().UTC()
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month
+}
+
+// usageID identifies the counters of a key by its hash, so the secret never reaches a
+// shared backend.
+// Identifica os contadores de uma chave pelo seu hash, para que o segredo nunca chegue a um
+// backend compartilhado.
+func usageID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// newKeyUsage combines a key and its counters.
+// Combina uma chave e seus contadores.
+func newKeyUsage(key APIKey, daily, monthly int) KeyUsage {
+	return KeyUsage{
+		Client:       key.Client,
+		Admin:        key.Admin,
+		DailyUsed:    daily,
+		DailyQuota:   key.DailyQuota,
+		MonthlyUsed:  monthly,
+		MonthlyQuota: key.MonthlyQuota,
+	}
+}
+
+// MemoryUsageCounter keeps the counters in memory. Each instance counts only the requests it
+// serves and starts from zero, so the quotas hold for the whole service only when it runs a
+// single instance.
+// MemoryUsageCounter guarda os contadores em memória. Cada instância conta só as requisições
+// que atende e começa do zero, então as cotas só valem para o serviço inteiro quando ele roda
+// uma única instância.
+type MemoryUsageCounter struct {
+	mu       sync.Mutex
+	counters map[string]*memoryUsage
+}
+
+// memoryUsage is the counters of a key and the periods they count.
+// memoryUsage são os contadores de uma chave e os períodos que eles contam.
+type memoryUsage struct {
+	day, month     time.Time // Start of the periods counted
+	daily, monthly int
+}
+
+// NewMemoryUsageCounter creates an empty counter.
+// Cria um contador vazio.
+func NewMemoryUsageCounter() *MemoryUsageCounter {
+	return &MemoryUsageCounter{counters: make(map[string]*memoryUsage)}
+}
+
+// Add adds delta to the counters of the key id, zeroing those of the periods that have ended.
+// Soma delta aos contadores da chave id, zerando os dos períodos que terminaram.
+func (c *MemoryUsageCounter) Add(ctx context.Context, id string, day, month time.Time, delta int) (int, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	usage := c.current(id, day, month)
+	usage.daily += delta
+	usage.monthly += delta
+	return usage.daily, usage.monthly, nil
+}
+
+// Get returns the counters of the key id.
+// Retorna os contadores da chave id.
+func (c *MemoryUsageCounter) Get(ctx context.Context, id string, day, month time.Time) (int, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	usage := c.current(id, day, month)
+	return usage.daily, usage.monthly, nil
+}
+
+// current returns the counters of the key id in the given periods. The caller holds c.mu.
+// Retorna os contadores da chave id nos períodos informados. Quem chama detém c.mu.
+func (c *MemoryUsageCounter) current(id string, day, month time.Time) *memoryUsage {
+	usage, ok := c.counters[id]
+	if !ok {
+		usage = &memoryUsage{}
+		c.counters[id] = usage
+	}
+	if !usage.day.Equal(day) {
+		usage.day, usage.daily = day, 0
+	}
+	if !usage.month.Equal(month) {
+		usage.month, usage.monthly = month, 0
+	}
+	return usage
+}
+
+// RedisUsageCounter keeps the counters in Redis, shared by every instance, so the quotas hold
+// for the whole service however many instances it runs and survive restarts. Each period has
+// its own key, which expires a day after the period ends.
+// RedisUsageCounter guarda os contadores no Redis, compartilhados por todas as instâncias, para
+// que as cotas valham para o serviço inteiro não importa quantas instâncias ele rode e
+// sobrevivam a reinícios. Cada período tem sua própria chave, que expira um dia depois de o
+// período terminar.
+type RedisUsageCounter struct {
+	Client *shared.RedisClient // Connection to the shared server
+	Prefix string              // Prepended to the Redis keys, so several services can share a server
+}
+
+// NewRedisUsageCounter creates a counter on the Redis server of client.
+// Cria um contador no servidor Redis de client.
+func NewRedisUsageCounter(client *shared.RedisClient) *RedisUsageCounter {
+	return &RedisUsageCounter{Client: client, Prefix: "weather-api:usage:"}
+}
+
+// Add atomically adds delta to the counters of the key id.
+// Soma delta atomicamente aos contadores da chave id.
+func (c *RedisUsageCounter) Add(ctx context.Context, id string, day, month time.Time, delta int) (int, int, error) {
+	dayKey, monthKey := c.keys(id, day, month)
+	amount := strconv.Itoa(delta)
+	replies, err := c.Client.Pipeline(ctx,
+		[]string{"INCRBY", dayKey, amount},
+		[]string{"EXPIREAT", dayKey, strconv.FormatInt(day.AddDate(0, 0, 2).Unix(), 10)},
+		[]string{"INCRBY", monthKey, amount},
+		[]string{"EXPIREAT", monthKey, strconv.FormatInt(month.AddDate(0, 1, 1).Unix(), 10)},
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	daily, dailyOK := replies[0].(int64)
+	monthly, monthlyOK := replies[2].(int64)
+	if !dailyOK || !monthlyOK {
+		return 0, 0, fmt.Errorf("redis: unexpected INCRBY replies %v and %v", replies[0], replies[2])
+	}
+	return int(daily), int(monthly), nil
+}
+
+// Get returns the counters of the key id, zero for a period without requests.
+// Retorna os contadores da chave id, zero para um período sem requisições.
+func (c *RedisUsageCounter) Get(ctx context.Context, id string, day, month time.Time) (int, int, error) {
+	dayKey, monthKey := c.keys(id, day, month)
+	reply, err := c.Client.Do(ctx, "MGET", dayKey, monthKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	values, ok := reply.([]any)
+	if !ok || len(values) != 2 {
+		return 0, 0, fmt.Errorf("redis: unexpected MGET reply %v", reply)
+	}
+	var counts [2]int
+	for i, value := range values {
+		if value == nil {
+			continue // No request in the period yet
+		}
+		text, _ := value.(string)
+		if counts[i], err = strconv.Atoi(text); err != nil {
+			return 0, 0, fmt.Errorf("redis: counter is not a number: %w", err)
+		}
+	}
+	return counts[0], counts[1], nil
+}
+
+// keys returns the Redis keys of the counters of the key id in the given periods.
+// Retorna as chaves Redis dos contadores da chave id nos períodos informados.
+func (c *RedisUsageCounter) keys(id string, day, month time.Time) (dayKey, monthKey string) {
+	return c.Prefix + id + ":" + day.Format(time.DateOnly), c.Prefix + id + ":" + month.Format("2006-01")
+}
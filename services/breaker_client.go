@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"sync"
+	"time"
+)
+
+// DefaultBreakerConfig is used when no breaker configuration is given.
+// DefaultBreakerConfig é usado quando nenhuma configuração de breaker é fornecida.
+var DefaultBreakerConfig = shared.BreakerConfig{
+	FailureRate:    0.5,
+	MinRequests:    10,
+	Window:         time.Minute,
+	Cooldown:       30 * time.Second,
+	HalfOpenProbes: 1,
+}
+
+// BreakerClient is an APIClient decorator that keeps one circuit breaker per upstream host,
+// so a host that keeps failing is skipped fast instead of holding every request until its
+// timeout, and is probed again once the cooldown ends. Transport errors, timeouts included,
+// and 5xx responses count as failures; calls cancelled by the caller do not count at all.
+// BreakerClient é um decorador de APIClient que mantém um circuit breaker por host de origem,
+// para que um host que continua falhando seja pulado rapidamente em vez de segurar todas as
+// requisições até o seu timeout, e seja testado novamente quando o resfriamento terminar.
+// Erros de transporte, incluindo timeouts, e respostas 5xx contam como falhas; chamadas
+// canceladas por quem chama não contam.
+type BreakerClient struct {
+	Next   APIClient            // Client that performs the requests
+	Config shared.BreakerConfig // Configuration of every host breaker
+
+	mu       sync.Mutex
+	breakers map[string]*shared.CircuitBreaker // Breakers by upstream host
+}
+
+// NewBreakerClient wraps next with a circuit breaker per upstream host.
+// Envolve next com um circuit breaker por host de origem.
+func NewBreakerClient(next APIClient, config shared.BreakerConfig) *BreakerClient {
+	return &BreakerClient{Next: next, Config: config, breakers: make(map[string]*shared.CircuitBreaker)}
+}
+
+// Get performs the request unless the breaker of the URL host is open, in which case it
+// returns a *CircuitOpenError right away.
+// Realiza a requisição, a menos que o breaker do host da URL esteja aberto; nesse caso
+// retorna um *CircuitOpenError imediatamente.
+func (c *BreakerClient) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, redactURLError(err) // Return error if the URL cannot be parsed
+	}
+
+	done, err := c.breaker(parsed.Host).Allow()
+	if err != nil {
+		return nil, &CircuitOpenError{Host: parsed.Host}
+	}
+
+	resp, err := c.Next.Get(ctx, rawURL)
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		done(shared.BreakerIgnored) // The caller gave up, e.g. a race loser was cancelled
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		done(shared.BreakerFailure)
+	default:
+		done(shared.BreakerSuccess) // Any other answer, even a 4xx, shows the host is up
+	}
+	return resp, err
+}
+
+// Snapshot returns the state of the breaker of every host called so far.
+// Retorna o estado do breaker de cada host chamado até agora.
+func (c *BreakerClient) Snapshot() map[string]shared.BreakerSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshots := make(map[string]shared.BreakerSnapshot, len(c.breakers))
+	for host, breaker := range c.breakers {
+		snapshots[host] = breaker.Snapshot()
+	}
+	return snapshots
+}
+
+// breaker returns the breaker of a host, creating it on first use.
+// Retorna o breaker de um host, criando-o no primeiro uso.
+func (c *BreakerClient) breaker(host string) *shared.CircuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	breaker, ok := c.breakers[host]
+	if !ok {
+		breaker = shared.NewCircuitBreaker(c.Config)
+		c.breakers[host] = breaker
+	}
+	return breaker
+}
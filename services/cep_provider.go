@@ -0,0 +1,432 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCEPProviders lists the providers enabled, in order, when no configuration is given.
+// DefaultCEPProviders lista os provedores habilitados, em ordem, quando nenhuma configuração é fornecida.
+var DefaultCEPProviders = []string{"brasilapi", "viacep"}
+
+// CEPProviderNames lists the built-in CEP providers; "local" also needs a database file.
+// CEPProviderNames lista os provedores de CEP embutidos; "local" também precisa de um arquivo de banco de dados.
+var CEPProviderNames = []string{"brasilapi", "viacep", "opencep", "awesomeapi", "local"}
+
+// Public endpoints of the built-in CEP providers.
+// Endpoints públicos dos provedores de CEP embutidos.
+const (
+	DefaultBrasilAPIBaseURL  = "https://brasilapi.com.br/api/cep/v2"
+	DefaultViaCEPBaseURL     = "http://viacep.com.br/ws"
+	DefaultOpenCEPBaseURL    = "https://opencep.com/v1"
+	DefaultAwesomeAPIBaseURL = "https://cep.awesomeapi.com.br/json"
+)
+
+// CEPProviderConfig holds the base URLs of the built-in CEP providers. Empty URLs fall back
+// to the public endpoints; others point the providers at a mirror or a local stand-in.
+// CEPProviderConfig contém as URLs base dos provedores de CEP embutidos. URLs vazias usam os
+// endpoints públicos; as demais apontam os provedores para um espelho ou um substituto local.
+type CEPProviderConfig struct {
+	BrasilAPIBaseURL  string // BrasilAPI v2 CEP endpoint
+	ViaCEPBaseURL     string // ViaCEP endpoint
+	OpenCEPBaseURL    string // OpenCEP endpoint
+	AwesomeAPIBaseURL string // AwesomeAPI CEP endpoint
+}
+
+// CEPProvider is a source of location data for a CEP.
+// CEPProvider é uma fonte de dados de localização para um CEP.
+type CEPProvider interface {
+	Name() string                                                           // Unique name used by the registry and configuration.
+	FetchLocation(ctx context.Context, cep string) (models.Location, error) // Fetch the location for the given CEP.
+}
+
+// CEPProviderRegistry keeps the registered CEP providers, their order and whether they are enabled.
+// CEPProviderRegistry mantém os provedores de CEP registrados, sua ordem e se estão habilitados.
+type CEPProviderRegistry struct {
+	mu        sync.RWMutex
+	providers []CEPProvider   // Registered providers in lookup order
+	disabled  map[string]bool // Names of the providers currently disabled
+}
+
+// NewCEPProviderRegistry creates an empty CEPProviderRegistry.
+// Cria um CEPProviderRegistry vazio.
+func NewCEPProviderRegistry() *CEPProviderRegistry {
+	return &CEPProviderRegistry{
+		disabled: make(map[string]bool), // No provider starts disabled
+	}
+}
+
+// NewDefaultCEPProviderRegistry creates a registry with every built-in provider registered,
+// at the base URLs of config, and only DefaultCEPProviders enabled.
+// Cria um registro com todos os provedores embutidos registrados, nas URLs base de config, e
+// apenas DefaultCEPProviders habilitados.
+func NewDefaultCEPProviderRegistry(client APIClient, config CEPProviderConfig) *CEPProviderRegistry {
+	registry := NewCEPProviderRegistry()
+	registry.Register(NewBrasilAPIProvider(client, orDefault(config.BrasilAPIBaseURL, DefaultBrasilAPIBaseURL)))
+	registry.Register(NewViaCEPProvider(client, orDefault(config.ViaCEPBaseURL, DefaultViaCEPBaseURL)))
+	registry.Register(NewOpenCEPProvider(client, orDefault(config.OpenCEPBaseURL, DefaultOpenCEPBaseURL)))
+	registry.Register(NewAwesomeAPIProvider(client, orDefault(config.AwesomeAPIBaseURL, DefaultAwesomeAPIBaseURL)))
+	registry.Configure(DefaultCEPProviders) // Built-in names always exist, so this cannot fail
+	return registry
+}
+
+// Register adds a provider to the end of the lookup order, enabled.
+// Adiciona um provedor ao final da ordem de busca, habilitado.
+func (r *CEPProviderRegistry) Register(provider CEPProvider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.providers {
+		if p.Name() == provider.Name() {
+			return fmt.Errorf("CEP provider %q already registered", provider.Name()) // Names must be unique
+		}
+	}
+	r.providers = append(r.providers, provider)
+	return nil
+}
+
+// Enable turns on a registered provider.
+// Habilita um provedor registrado.
+func (r *CEPProviderRegistry) Enable(name string) error {
+	return r.setEnabled(name, true)
+}
+
+// Disable turns off a registered provider without removing it.
+// Desabilita um provedor registrado sem removê-lo.
+func (r *CEPProviderRegistry) Disable(name string) error {
+	return r.setEnabled(name, false)
+}
+
+// Configure enables exactly the named providers, moving them to the front in the given order.
+// Providers not listed stay registered but disabled.
+// Habilita exatamente os provedores informados, movendo-os para o início na ordem dada.
+// Provedores não listados continuam registrados, porém desabilitados.
+func (r *CEPProviderRegistry) Configure(names []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ordered := make([]CEPProvider, 0, len(r.providers))
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		provider := r.find(name)
+		if provider == nil {
+			return fmt.Errorf("unknown CEP provider %q", name) // Reject typos instead of silently ignoring them
+		}
+		if selected[name] {
+			continue // Ignore duplicated names
+		}
+		selected[name] = true
+		ordered = append(ordered, provider)
+	}
+
+	// Keep the remaining providers registered, after the selected ones
+	// Mantém os demais provedores registrados, após os selecionados
+	for _, provider := range r.providers {
+		if !selected[provider.Name()] {
+			ordered = append(ordered, provider)
+		}
+	}
+
+	r.providers = ordered
+	r.disabled = make(map[string]bool, len(r.providers))
+	for _, provider := range r.providers {
+		r.disabled[provider.Name()] = !selected[provider.Name()]
+	}
+	return nil
+}
+
+// Providers returns the enabled providers in lookup order.
+// Retorna os provedores habilitados na ordem de busca.
+func (r *CEPProviderRegistry) Providers() []CEPProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	enabled := make([]CEPProvider, 0, len(r.providers))
+	for _, provider := range r.providers {
+		if !r.disabled[provider.Name()] {
+			enabled = append(enabled, provider)
+		}
+	}
+	return enabled
+}
+
+// Names returns the names of every registered provider, enabled or not, in lookup order.
+// Retorna os nomes de todos os provedores registrados, habilitados ou não, na ordem de busca.
+func (r *CEPProviderRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for _, provider := range r.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+// setEnabled flips the enabled flag of a registered provider.
+// Altera o estado de habilitação de um provedor registrado.
+func (r *CEPProviderRegistry) setEnabled(name string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.find(name) == nil {
+		return fmt.Errorf("unknown CEP provider %q", name)
+	}
+	r.disabled[name] = !enabled
+	return nil
+}
+
+// find returns the registered provider with the given name, or nil. Callers must hold the lock.
+// Retorna o provedor registrado com o nome informado, ou nil. Quem chama deve manter o lock.
+func (r *CEPProviderRegistry) find(name string) CEPProvider {
+	for _, provider := range r.providers {
+		if provider.Name() == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+// BrasilAPIProvider fetches CEP data from BrasilAPI.
+// BrasilAPIProvider busca dados de CEP na BrasilAPI.
+type BrasilAPIProvider struct {
+	Client  APIClient // The API client used for making requests.
+	BaseURL string    // Base URL of the API, replaceable by a mirror or a local stand-in
+}
+
+// NewBrasilAPIProvider creates and returns a new BrasilAPIProvider.
+// Cria e retorna um novo BrasilAPIProvider.
+func NewBrasilAPIProvider(client APIClient, baseURL string) *BrasilAPIProvider {
+	return &BrasilAPIProvider{Client: client, BaseURL: baseURL}
+}
+
+// Name returns the provider name.
+// Retorna o nome do provedor.
+func (p *BrasilAPIProvider) Name() string {
+	return "brasilapi"
+}
+
+// FetchLocation fetches location data, including coordinates when known, from BrasilAPI v2.
+// Busca dados de localização, incluindo coordenadas quando conhecidas, da BrasilAPI v2.
+func (p *BrasilAPIProvider) FetchLocation(ctx context.Context, cep string) (models.Location, error) {
+	url := fmt.Sprintf("%s/%s", p.BaseURL, cep) // BrasilAPI URL
+
+	var address models.BrasilAPIResponse
+	if err := getJSON(ctx, p.Client, url, &address); err != nil {
+		return models.Location{}, err
+	}
+
+	latitude, longitude := parseCoordinates(address.Location.Coordinates.Latitude, address.Location.Coordinates.Longitude)
+	return models.Location{
+		Cep:        &cep,
+		Localidade: &address.Neighborhood,
+		Uf:         &address.State,
+		City:       &address.City,
+		Latitude:   latitude,
+		Longitude:  longitude,
+	}, nil
+}
+
+// ViaCEPProvider fetches CEP data from ViaCEP.
+// ViaCEPProvider busca dados de CEP no ViaCEP.
+type ViaCEPProvider struct {
+	Client  APIClient // The API client used for making requests.
+	BaseURL string    // Base URL of the API, replaceable by a mirror or a local stand-in
+}
+
+// NewViaCEPProvider creates and returns a new ViaCEPProvider.
+// Cria e retorna um novo ViaCEPProvider.
+func NewViaCEPProvider(client APIClient, baseURL string) *ViaCEPProvider {
+	return &ViaCEPProvider{Client: client, BaseURL: baseURL}
+}
+
+// Name returns the provider name.
+// Retorna o nome do provedor.
+func (p *ViaCEPProvider) Name() string {
+	return "viacep"
+}
+
+// FetchLocation fetches location data from ViaCEP.
+// Busca dados de localização do ViaCEP.
+func (p *ViaCEPProvider) FetchLocation(ctx context.Context, cep string) (models.Location, error) {
+	url := fmt.Sprintf("%s/%s/json", p.BaseURL, cep) // ViaCEP URL
+
+	var address models.ViaCEPResponse
+	if err := getJSON(ctx, p.Client, url, &address); err != nil {
+		return models.Location{}, err
+	}
+
+	return models.Location{
+		Cep:        &cep,
+		Localidade: &address.Localidade,
+		Uf:         &address.UF,
+		City:       &address.Localidade,
+	}, nil
+}
+
+// OpenCEPProvider fetches CEP data from OpenCEP.
+// OpenCEPProvider busca dados de CEP no OpenCEP.
+type OpenCEPProvider struct {
+	Client  APIClient // The API client used for making requests.
+	BaseURL string    // Base URL of the API, replaceable by a mirror or a local stand-in
+}
+
+// NewOpenCEPProvider creates and returns a new OpenCEPProvider.
+// Cria e retorna um novo OpenCEPProvider.
+func NewOpenCEPProvider(client APIClient, baseURL string) *OpenCEPProvider {
+	return &OpenCEPProvider{Client: client, BaseURL: baseURL}
+}
+
+// Name returns the provider name.
+// Retorna o nome do provedor.
+func (p *OpenCEPProvider) Name() string {
+	return "opencep"
+}
+
+// FetchLocation fetches location data from OpenCEP, which answers in the ViaCEP format.
+// Busca dados de localização do OpenCEP, que responde no formato do ViaCEP.
+func (p *OpenCEPProvider) FetchLocation(ctx context.Context, cep string) (models.Location, error) {
+	url := fmt.Sprintf("%s/%s", p.BaseURL, cep) // OpenCEP URL
+
+	var address models.ViaCEPResponse
+	if err := getJSON(ctx, p.Client, url, &address); err != nil {
+		return models.Location{}, err
+	}
+
+	return models.Location{
+		Cep:        &cep,
+		Localidade: &address.Localidade,
+		Uf:         &address.UF,
+		City:       &address.Localidade,
+	}, nil
+}
+
+// AwesomeAPIProvider fetches CEP data from AwesomeAPI.
+// AwesomeAPIProvider busca dados de CEP na AwesomeAPI.
+type AwesomeAPIProvider struct {
+	Client  APIClient // The API client used for making requests.
+	BaseURL string    // Base URL of the API, replaceable by a mirror or a local stand-in
+}
+
+// NewAwesomeAPIProvider creates and returns a new AwesomeAPIProvider.
+// Cria e retorna um novo AwesomeAPIProvider.
+func NewAwesomeAPIProvider(client APIClient, baseURL string) *AwesomeAPIProvider {
+	return &AwesomeAPIProvider{Client: client, BaseURL: baseURL}
+}
+
+// Name returns the provider name.
+// Retorna o nome do provedor.
+func (p *AwesomeAPIProvider) Name() string {
+	return "awesomeapi"
+}
+
+// FetchLocation fetches location data from AwesomeAPI.
+// Busca dados de localização da AwesomeAPI.
+func (p *AwesomeAPIProvider) FetchLocation(ctx context.Context, cep string) (models.Location, error) {
+	url := fmt.Sprintf("%s/%s", p.BaseURL, cep) // AwesomeAPI URL
+
+	var address models.AwesomeAPIResponse
+	if err := getJSON(ctx, p.Client, url, &address); err != nil {
+		return models.Location{}, err
+	}
+
+	latitude, longitude := parseCoordinates(address.Lat, address.Lng)
+	return models.Location{
+		Cep:        &cep,
+		Localidade: &address.District,
+		Uf:         &address.State,
+		City:       &address.City,
+		Latitude:   latitude,
+		Longitude:  longitude,
+	}, nil
+}
+
+// LocalCEPProvider answers CEP lookups from a local JSON database file.
+// LocalCEPProvider responde buscas de CEP a partir de um arquivo JSON local.
+type LocalCEPProvider struct {
+	Entries map[string]models.LocalCEPEntry // CEP data indexed by the 8-digit CEP
+}
+
+// NewLocalCEPProvider loads a LocalCEPProvider from a JSON file mapping CEPs to entries.
+// Carrega um LocalCEPProvider a partir de um arquivo JSON que mapeia CEPs para registros.
+func NewLocalCEPProvider(path string) (*LocalCEPProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err // Return error if the file cannot be read
+	}
+
+	entries := make(map[string]models.LocalCEPEntry)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid CEP database %s: %w", path, err)
+	}
+
+	// Normalize keys so "01001-000" and "01001000" are the same CEP
+	// Normaliza as chaves para que "01001-000" e "01001000" sejam o mesmo CEP
+	normalized := make(map[string]models.LocalCEPEntry, len(entries))
+	for cep, entry := range entries {
+		normalized[strings.ReplaceAll(cep, "-", "")] = entry
+	}
+	return &LocalCEPProvider{Entries: normalized}, nil
+}
+
+// Name returns the provider name.
+// Retorna o nome do provedor.
+func (p *LocalCEPProvider) Name() string {
+	return "local"
+}
+
+// FetchLocation looks the CEP up in the local database.
+// Busca o CEP no banco de dados local.
+func (p *LocalCEPProvider) FetchLocation(ctx context.Context, cep string) (models.Location, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Location{}, err // Lookup already cancelled
+	}
+
+	entry, ok := p.Entries[cep]
+	if !ok {
+		return models.Location{}, ErrCEPNotFound
+	}
+
+	return models.Location{
+		Cep:        &cep,
+		Localidade: &entry.Neighborhood,
+		Uf:         &entry.UF,
+		City:       &entry.City,
+	}, nil
+}
+
+// parseCoordinates converts textual coordinates, returning nil for both when either is missing or invalid.
+// Converte coordenadas em texto, retornando nil para ambas quando alguma estiver ausente ou inválida.
+func parseCoordinates(latitude, longitude string) (*float64, *float64) {
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(latitude), 64)
+	lon, lonErr := strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+	if latErr != nil || lonErr != nil {
+		return nil, nil
+	}
+	return &lat, &lon
+}
+
+// getJSON performs a GET request and decodes a successful JSON response into target.
+// Realiza uma requisição GET e decodifica uma resposta JSON de sucesso em target.
+func getJSON(ctx context.Context, client APIClient, url string, target any) error {
+	resp, err := client.Get(ctx, url)
+	if err != nil {
+		return err // Return error if the request fails
+	}
+	defer resp.Body.Close() // Close response body when done
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return ErrCEPNotFound // The provider does not know the CEP
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(target) // Decode the JSON body into the target
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"time"
+)
+
+// CoalescedLocationService is a LocationService decorator that shares a single upstream
+// lookup among concurrent requests for the same CEP.
+// CoalescedLocationService é um decorador de LocationService que compartilha uma única
+// busca na origem entre requisições concorrentes para o mesmo CEP.
+type CoalescedLocationService struct {
+	Next  LocationService // Service called once per group of identical lookups
+	group shared.SingleFlight[string, models.Location]
+}
+
+// NewCoalescedLocationService wraps next with request coalescing.
+// Envolve next com agrupamento de requisições.
+func NewCoalescedLocationService(next LocationService) *CoalescedLocationService {
+	return &CoalescedLocationService{Next: next}
+}
+
+// GetLocationFromCEP joins an in-flight lookup for the same CEP or starts a new one.
+// Entra em uma busca em andamento para o mesmo CEP ou inicia uma nova.
+func (c *CoalescedLocationService) GetLocationFromCEP(ctx context.Context, cep string) (models.Location, error) {
+	location, err, _ := c.group.Do(ctx, cep, func(ctx context.Context) (models.Location, error) {
+		return c.Next.GetLocationFromCEP(ctx, cep)
+	})
+	return location, err
+}
+
+// CoalescedWeatherService is a WeatherService decorator that shares a single upstream
+// weather call among concurrent requests for the same city.
+// CoalescedWeatherService é um decorador de WeatherService que compartilha uma única
+// chamada de clima entre requisições concorrentes para a mesma cidade.
+type CoalescedWeatherService struct {
+	Next          WeatherService // Service called once per group of identical lookups
+	group         shared.SingleFlight[string, models.CurrentWeather]
+	forecastGroup shared.SingleFlight[string, models.Forecast]
+	historyGroup  shared.SingleFlight[string, models.Forecast]
+}
+
+// NewCoalescedWeatherService wraps next with request coalescing.
+// Envolve next com agrupamento de requisições.
+func NewCoalescedWeatherService(next WeatherService) *CoalescedWeatherService {
+	return &CoalescedWeatherService{Next: next}
+}
+
+// GetCurrentWeather joins an in-flight weather call for the same location or starts a new one.
+// Entra em uma chamada de clima em andamento para a mesma localização ou inicia uma nova.
+func (c *CoalescedWeatherService) GetCurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	weather, err, _ := c.group.Do(ctx, weatherCacheKey(location), func(ctx context.Context) (models.CurrentWeather, error) {
+		return c.Next.GetCurrentWeather(ctx, location)
+	})
+	return weather, err
+}
+
+// GetForecast joins an in-flight forecast call for the same location and days or starts a new one.
+// Entra em uma chamada de previsão em andamento para a mesma localização e dias ou inicia uma nova.
+func (c *CoalescedWeatherService) GetForecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	key := fmt.Sprintf("%s|%d", weatherCacheKey(location), days)
+	forecast, err, _ := c.forecastGroup.Do(ctx, key, func(ctx context.Context) (models.Forecast, error) {
+		return c.Next.GetForecast(ctx, location, days)
+	})
+	return forecast, err
+}
+
+// GetHistory joins an in-flight history call for the same location and dates or starts a new one.
+// Entra em uma chamada de histórico em andamento para a mesma localização e datas ou inicia uma nova.
+func (c *CoalescedWeatherService) GetHistory(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	key := fmt.Sprintf("%s|%s|%s", weatherCacheKey(location), start.Format(time.DateOnly), end.Format(time.DateOnly))
+	history, err, _ := c.historyGroup.Do(ctx, key, func(ctx context.Context) (models.Forecast, error) {
+		return c.Next.GetHistory(ctx, location, start, end)
+	})
+	return history, err
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// ErrCEPNotFound is returned by a CEP provider that answered but does not know the CEP.
+// ErrCEPNotFound é retornado por um provedor de CEP que respondeu, mas não conhece o CEP.
+var ErrCEPNotFound = errors.New("CEP not found")
+
+// logUpstreamFailure logs why a provider call failed. Calls cancelled because the caller no
+// longer needed them are not failures, and a CEP unknown to a provider is only logged at debug level.
+// Registra no log por que uma chamada a um provedor falhou. Chamadas canceladas porque quem chamou
+// não precisava mais delas não são falhas, e um CEP desconhecido por um provedor só é registrado
+// no nível debug.
+func logUpstreamFailure(ctx context.Context, provider, operation string, err error) {
+	switch {
+	case err == nil || errors.Is(ctx.Err(), context.Canceled):
+		return
+	case errors.Is(err, ErrCEPNotFound):
+		slog.DebugContext(ctx, "CEP unknown to provider", "provider", provider)
+	default:
+		slog.WarnContext(ctx, "Upstream call failed", "provider", provider, "operation", operation, "error", err)
+	}
+}
+
+// ProviderError records why a single CEP or weather provider failed during a lookup.
+// ProviderError registra por que um único provedor de CEP ou de clima falhou durante uma busca.
+type ProviderError struct {
+	Provider string // Name of the provider that failed
+	Err      error  // Reason reported by the provider
+}
+
+// Error formats the failure as "provider: reason".
+// Formata a falha como "provedor: motivo".
+func (e ProviderError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Provider, e.Err)
+}
+
+// Unwrap returns the underlying provider error.
+// Retorna o erro original do provedor.
+func (e ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// LocationLookupError is returned when no CEP provider produced a valid location.
+// It carries one ProviderError per enabled provider for diagnostics.
+// LocationLookupError é retornado quando nenhum provedor de CEP produziu uma localização válida.
+// Ele carrega um ProviderError por provedor habilitado para diagnóstico.
+type LocationLookupError struct {
+	Errors   []ProviderError // Per-provider failures, in the order they were observed
+	TimedOut bool            // Whether the lookup deadline was hit before every provider answered
+}
+
+// Error summarizes every provider failure in a single line.
+// Resume todas as falhas dos provedores em uma única linha.
+func (e *LocationLookupError) Error() string {
+	reasons := make([]string, 0, len(e.Errors))
+	for _, providerErr := range e.Errors {
+		reasons = append(reasons, providerErr.Error())
+	}
+
+	message := "error searching for CEP data"
+	if e.TimedOut {
+		message = "timeout searching for CEP data"
+	}
+	if len(reasons) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s (%s)", message, strings.Join(reasons, "; "))
+}
+
+// Unwrap exposes the provider errors to errors.Is and errors.As.
+// Expõe os erros dos provedores para errors.Is e errors.As.
+func (e *LocationLookupError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, providerErr := range e.Errors {
+		errs = append(errs, providerErr)
+	}
+	return errs
+}
+
+// NotFound reports whether every provider answered that the CEP does not exist.
+// Informa se todos os provedores responderam que o CEP não existe.
+func (e *LocationLookupError) NotFound() bool {
+	if e.TimedOut || len(e.Errors) == 0 {
+		return false
+	}
+	for _, providerErr := range e.Errors {
+		if !errors.Is(providerErr.Err, ErrCEPNotFound) {
+			return false
+		}
+	}
+	return true
+}
+
+// Upstream failure kinds, matched with errors.Is against an *UpstreamError.
+// Tipos de falha na origem, comparados com errors.Is contra um *UpstreamError.
+var (
+	ErrUpstreamAuth        = errors.New("upstream authentication failed") // Invalid, missing or disabled API key
+	ErrUpstreamQuota       = errors.New("upstream quota exceeded")        // Calls quota exhausted or rate limited
+	ErrUpstreamNotFound    = errors.New("upstream location not found")    // The upstream does not know the location
+	ErrUpstreamBadRequest  = errors.New("upstream rejected the request")  // The request was malformed
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")           // 5xx or internal upstream error
+)
+
+// UpstreamError is a failure reported by an upstream API, classified by Kind.
+// UpstreamError é uma falha informada por uma API de origem, classificada por Kind.
+type UpstreamError struct {
+	Provider   string // Name of the upstream, e.g. "weatherapi"
+	Kind       error  // One of the ErrUpstream* kinds
+	StatusCode int    // HTTP status code returned by the upstream
+	Code       int    // Upstream-specific error code, when present
+	Message    string // Upstream error message, when present
+}
+
+// Error describes the failure with the upstream details.
+// Descreve a falha com os detalhes da origem.
+func (e *UpstreamError) Error() string {
+	message := fmt.Sprintf("%s: %v (status %d", e.Provider, e.Kind, e.StatusCode)
+	if e.Code != 0 {
+		message += fmt.Sprintf(", code %d", e.Code)
+	}
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message + ")"
+}
+
+// Unwrap returns the failure kind so errors.Is(err, ErrUpstreamQuota) works.
+// Retorna o tipo da falha para que errors.Is(err, ErrUpstreamQuota) funcione.
+func (e *UpstreamError) Unwrap() error {
+	return e.Kind
+}
+
+// ErrUnsupported is returned when the configured weather provider cannot serve an operation,
+// e.g. history on a plan that does not include it.
+// ErrUnsupported é retornado quando o provedor de clima configurado não atende uma operação,
+// ex.: histórico em um plano que não o inclui.
+var ErrUnsupported = errors.New("operation not supported by the weather provider")
+
+// WeatherLookupError is returned when no weather provider of a strategy produced an answer.
+// It carries one ProviderError per provider tried.
+// WeatherLookupError é retornado quando nenhum provedor de clima de uma estratégia produziu
+// uma resposta. Ele carrega um ProviderError por provedor tentado.
+type WeatherLookupError struct {
+	Errors []ProviderError // Per-provider failures, in the order they were observed
+}
+
+// Error summarizes every provider failure in a single line.
+// Resume todas as falhas dos provedores em uma única linha.
+func (e *WeatherLookupError) Error() string {
+	reasons := make([]string, 0, len(e.Errors))
+	for _, providerErr := range e.Errors {
+		reasons = append(reasons, providerErr.Error())
+	}
+	return fmt.Sprintf("every weather provider failed (%s)", strings.Join(reasons, "; "))
+}
+
+// Unwrap exposes the provider errors to errors.Is and errors.As, so the failure kinds of
+// the providers (e.g. ErrUpstreamQuota) still map to HTTP statuses.
+// Expõe os erros dos provedores para errors.Is e errors.As, para que os tipos de falha dos
+// provedores (ex.: ErrUpstreamQuota) continuem sendo mapeados para status HTTP.
+func (e *WeatherLookupError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, providerErr := range e.Errors {
+		errs = append(errs, providerErr)
+	}
+	return errs
+}
+
+// CircuitOpenError is returned by BreakerClient when the circuit breaker of an upstream host
+// is open, so the call fails fast without reaching the network. It unwraps to
+// ErrUpstreamUnavailable so it maps to the same HTTP status as a failing upstream.
+// CircuitOpenError é retornado pelo BreakerClient quando o circuit breaker de um host de origem
+// está aberto, então a chamada falha rápido sem chegar à rede. Ele se desdobra em
+// ErrUpstreamUnavailable para ser mapeado para o mesmo status HTTP de uma origem com falhas.
+type CircuitOpenError struct {
+	Host string // Upstream host whose breaker is open
+}
+
+// Error names the host whose breaker rejected the call.
+// Informa o host cujo breaker rejeitou a chamada.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for %s", e.Host)
+}
+
+// Unwrap returns ErrUpstreamUnavailable so errors.Is(err, ErrUpstreamUnavailable) works.
+// Retorna ErrUpstreamUnavailable para que errors.Is(err, ErrUpstreamUnavailable) funcione.
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrUpstreamUnavailable
+}
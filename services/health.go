@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"sync"
+	"time"
+)
+
+// HealthProbeCEP is a well-known CEP (Praça da Sé, São Paulo) looked up by the CEP provider probes.
+// HealthProbeCEP é um CEP conhecido (Praça da Sé, São Paulo) buscado pelos testes dos provedores de CEP.
+const HealthProbeCEP = "01001000"
+
+// HealthCheck is one dependency verified by the readiness endpoint.
+// HealthCheck é uma dependência verificada pelo endpoint de prontidão.
+type HealthCheck struct {
+	Name     string                          // Dependency name shown in the readiness response
+	Critical bool                            // Whether a failure makes the instance not ready
+	Group    string                          // Critical checks covering for each other, failing only when all of them fail
+	Check    func(ctx context.Context) error // Returns nil when the dependency works
+}
+
+// HealthStatus is the outcome of the last run of a health check.
+// HealthStatus é o resultado da última execução de uma verificação de saúde.
+type HealthStatus struct {
+	Name      string        // Dependency name
+	Critical  bool          // Whether a failure makes the instance not ready
+	Group     string        // Critical checks covering for each other
+	Err       error         // Failure, nil when the dependency works
+	Latency   time.Duration // How long the check took
+	CheckedAt time.Time     // When the check ran
+}
+
+// HealthConfig configures how the dependencies are checked.
+// HealthConfig configura como as dependências são verificadas.
+type HealthConfig struct {
+	CacheTTL time.Duration // How long check results are reused, so probes do not follow the polling rate
+	Timeout  time.Duration // Maximum time of each check
+}
+
+// DefaultHealthConfig is used when no health configuration is given.
+// DefaultHealthConfig é usado quando nenhuma configuração de saúde é fornecida.
+var DefaultHealthConfig = HealthConfig{
+	CacheTTL: 30 * time.Second,
+	Timeout:  3 * time.Second,
+}
+
+// HealthMonitor runs the health checks concurrently and caches their results for a while,
+// so frequent readiness polls do not turn into upstream traffic.
+// HealthMonitor executa as verificações de saúde em paralelo e guarda seus resultados por um
+// tempo, para que consultas frequentes de prontidão não virem tráfego para as origens.
+type HealthMonitor struct {
+	Checks []HealthCheck    // Dependencies to verify
+	Config HealthConfig     // Monitor configuration
+	Now    func() time.Time // Clock, replaceable in tests
+
+	mu        sync.Mutex
+	statuses  []HealthStatus // Results of the last run
+	expiresAt time.Time      // Results are reused until this instant
+}
+
+// NewHealthMonitor creates a monitor for the given checks.
+// Cria um monitor para as verificações informadas.
+func NewHealthMonitor(checks []HealthCheck, config HealthConfig) *HealthMonitor {
+	return &HealthMonitor{Checks: checks, Config: config, Now: time.Now}
+}
+
+// Status returns the status of every check, running them again when the cached results have
+// expired, and whether every critical check passed. The checks of a group pass when at least
+// one of them does.
+// Retorna o estado de cada verificação, executando-as novamente quando os resultados em cache
+// expiraram, e se todas as verificações críticas passaram. As verificações de um grupo passam
+// quando ao menos uma delas passa.
+func (m *HealthMonitor) Status(ctx context.Context) ([]HealthStatus, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock() // Concurrent polls wait for the same run instead of starting their own
+
+	if m.statuses == nil || !m.Now().Before(m.expiresAt) {
+		m.statuses = m.run(ctx)
+		m.expiresAt = m.Now().Add(m.Config.CacheTTL)
+	}
+
+	groups := make(map[string]bool) // Whether some check of each group passed
+	ready := true
+	for _, status := range m.statuses {
+		switch {
+		case !status.Critical:
+		case status.Group != "":
+			groups[status.Group] = groups[status.Group] || status.Err == nil
+		case status.Err != nil:
+			ready = false
+		}
+	}
+	for _, passed := range groups {
+		ready = ready && passed
+	}
+	return m.statuses, ready
+}
+
+// run executes every check concurrently, each within the configured timeout.
+// Executa todas as verificações em paralelo, cada uma dentro do timeout configurado.
+func (m *HealthMonitor) run(ctx context.Context) []HealthStatus {
+	// Detached from the poll that triggered it, since the results are shared
+	// Desvinculado da consulta que o disparou, já que os resultados são compartilhados
+	ctx = context.WithoutCancel(ctx)
+
+	statuses := make([]HealthStatus, len(m.Checks))
+	var wg sync.WaitGroup
+	for i, check := range m.Checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, m.Config.Timeout)
+			defer cancel()
+
+			start := m.Now()
+			err := check.Check(checkCtx)
+			statuses[i] = HealthStatus{
+				Name:      check.Name,
+				Critical:  check.Critical,
+				Group:     check.Group,
+				Err:       err,
+				Latency:   m.Now().Sub(start),
+				CheckedAt: start,
+			}
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+// HealthGroupCEP groups the CEP provider checks: the lookups race over every enabled provider,
+// so the instance only fails when all of them do.
+// HealthGroupCEP agrupa as verificações dos provedores de CEP: as buscas disputam entre todos
+// os provedores habilitados, então a instância só falha quando todos falham.
+const HealthGroupCEP = "cep"
+
+// NewCEPProviderHealthCheck creates a critical check in HealthGroupCEP that looks up
+// HealthProbeCEP on the provider.
+// Cria uma verificação crítica em HealthGroupCEP que busca HealthProbeCEP no provedor.
+func NewCEPProviderHealthCheck(provider CEPProvider) HealthCheck {
+	return HealthCheck{
+		Name:     "cep:" + provider.Name(),
+		Critical: true,
+		Group:    HealthGroupCEP,
+		Check: func(ctx context.Context) error {
+			_, err := provider.FetchLocation(ctx, HealthProbeCEP)
+			return err
+		},
+	}
+}
+
+// NewWeatherHealthCheck creates a check that fetches the current weather of São Paulo through
+// the weather service, so the weather cache answers most probes instead of a paid upstream
+// call. The check is never critical: an outage or an exhausted quota of the weather API hits
+// every instance at once, and failing all of them would take the whole service down instead of
+// routing away from a broken instance. It is only reported.
+// Cria uma verificação que busca o clima atual de São Paulo pelo serviço de clima, para que o
+// cache de clima responda à maioria dos testes em vez de uma chamada paga à origem. A
+// verificação nunca é crítica: uma indisponibilidade ou cota esgotada da API de clima atinge
+// todas as instâncias de uma vez, e derrubar todas elas tiraria o serviço inteiro do ar em vez
+// de desviar de uma instância com problema. Ela só é informada.
+func NewWeatherHealthCheck(service WeatherService) HealthCheck {
+	city, uf := "São Paulo", "SP"
+	location := models.Location{Localidade: &city, City: &city, Uf: &uf}
+	return HealthCheck{
+		Name: "weather",
+		Check: func(ctx context.Context) error {
+			_, err := service.GetCurrentWeather(ctx, location)
+			return err
+		},
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"sync"
+	"time"
+)
+
+// LocationCacheConfig configures the CEP lookup cache.
+// LocationCacheConfig configura o cache de buscas de CEP.
+type LocationCacheConfig struct {
+	TTL         time.Duration // How long a found location is served without asking the providers
+	StaleTTL    time.Duration // Extra time an expired location is served while it is refreshed in background
+	NegativeTTL time.Duration // How long a CEP unknown to every provider is remembered
+	Size        int           // Maximum number of cached CEPs
+}
+
+// DefaultLocationCacheConfig is used when no cache configuration is given.
+// CEP-to-city mappings almost never change, so entries live for a long time.
+// DefaultLocationCacheConfig é usado quando nenhuma configuração de cache é fornecida.
+// O mapeamento CEP-cidade quase nunca muda, então as entradas duram bastante.
+var DefaultLocationCacheConfig = LocationCacheConfig{
+	TTL:         24 * time.Hour,
+	StaleTTL:    7 * 24 * time.Hour,
+	NegativeTTL: time.Hour,
+	Size:        10000,
+}
+
+// CachedLocationService is a LocationService decorator that caches CEP lookups.
+// CachedLocationService é um decorador de LocationService que armazena buscas de CEP em cache.
+type CachedLocationService struct {
+	Next    LocationService     // Service called on cache misses and refreshes
+	Config  LocationCacheConfig // Cache configuration
+	Now     func() time.Time    // Clock, replaceable in tests
+	Metrics *Metrics            // Records hits, stale hits and misses, optional
+	cache   *shared.LRUCache[string, locationCacheEntry]
+
+	mu         sync.Mutex
+	refreshing map[string]bool // CEPs with a background refresh in progress
+
+	ctx     context.Context    // Base context of the background refreshes, cancelled by Close
+	cancel  context.CancelFunc // Cancels ctx
+	running sync.WaitGroup     // Background refreshes in progress
+}
+
+// locationCacheEntry is a cached lookup outcome, either a location or a negative result.
+// locationCacheEntry é o resultado de uma busca em cache, seja uma localização ou um resultado negativo.
+type locationCacheEntry struct {
+	location   models.Location // Location found, when err is nil
+	err        error           // Lookup error for negative entries
+	expiresAt  time.Time       // Entry is fresh until this instant
+	staleUntil time.Time       // Entry may be served stale until this instant
+}
+
+// NewCachedLocationService wraps next with a cache using the given configuration.
+// Envolve next com um cache usando a configuração informada.
+func NewCachedLocationService(next LocationService, config LocationCacheConfig) *CachedLocationService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &CachedLocationService{
+		Next:       next,
+		Config:     config,
+		Now:        time.Now,
+		cache:      shared.NewLRUCache[string, locationCacheEntry](config.Size),
+		refreshing: make(map[string]bool),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Close cancels the background refreshes and waits for them to return. Stale entries are
+// still served afterwards, but no longer refreshed.
+// Cancela as atualizações em segundo plano e espera que elas retornem. Entradas expiradas
+// continuam sendo servidas depois disso, mas não são mais atualizadas.
+func (c *CachedLocationService) Close() error {
+	c.mu.Lock()
+	c.cancel()
+	c.mu.Unlock()
+	c.running.Wait()
+	return nil
+}
+
+// GetLocationFromCEP serves the CEP from cache when possible. Fresh entries are returned
+// directly; stale ones are returned immediately while a background refresh runs.
+// Serve o CEP a partir do cache quando possível. Entradas frescas são retornadas diretamente;
+// entradas expiradas são retornadas imediatamente enquanto uma atualização roda em segundo plano.
+func (c *CachedLocationService) GetLocationFromCEP(ctx context.Context, cep string) (models.Location, error) {
+	now := c.Now()
+	if entry, ok := c.cache.Get(cep); ok {
+		if now.Before(entry.expiresAt) {
+			c.Metrics.cacheLookup("location", "hit")
+			return entry.location, entry.err // Fresh hit, positive or negative
+		}
+		if entry.err == nil && now.Before(entry.staleUntil) {
+			c.Metrics.cacheLookup("location", "stale")
+			c.refresh(cep)             // Revalidate without making the caller wait
+			return entry.location, nil // Serve the stale location
+		}
+	}
+
+	c.Metrics.cacheLookup("location", "miss")
+	location, err := c.Next.GetLocationFromCEP(ctx, cep)
+	c.store(cep, location, err)
+	return location, err
+}
+
+// refresh reloads a CEP in background, at most once at a time per CEP.
+// Recarrega um CEP em segundo plano, no máximo uma vez por vez para cada CEP.
+func (c *CachedLocationService) refresh(cep string) {
+	c.mu.Lock()
+	if c.refreshing[cep] || c.ctx.Err() != nil {
+		c.mu.Unlock()
+		return // A refresh for this CEP is already running, or the cache is closed
+	}
+	c.refreshing[cep] = true
+	c.running.Add(1)
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, cep)
+			c.mu.Unlock()
+			c.running.Done()
+		}()
+
+		// Detached from the request that triggered it, which has already been answered,
+		// but cancelled on shutdown
+		// Desvinculado da requisição que o disparou, que já foi respondida, mas cancelado
+		// no desligamento
+		location, err := c.Next.GetLocationFromCEP(c.ctx, cep)
+		c.store(cep, location, err)
+	}()
+}
+
+// store caches a lookup outcome. Found locations and CEPs unknown to every provider are
+// cached; transient failures are not, so the stale entry (if any) is kept.
+// Armazena o resultado de uma busca. Localizações encontradas e CEPs desconhecidos por todos os
+// provedores são armazenados; falhas transitórias não, mantendo a entrada antiga (se houver).
+func (c *CachedLocationService) store(cep string, location models.Location, err error) {
+	now := c.Now()
+	if err == nil {
+		c.cache.Add(cep, locationCacheEntry{
+			location:   location,
+			expiresAt:  now.Add(c.Config.TTL),
+			staleUntil: now.Add(c.Config.TTL + c.Config.StaleTTL),
+		})
+		return
+	}
+
+	var lookupErr *LocationLookupError
+	if c.Config.NegativeTTL > 0 && errors.As(err, &lookupErr) && lookupErr.NotFound() {
+		c.cache.Add(cep, locationCacheEntry{
+			err:        err,
+			expiresAt:  now.Add(c.Config.NegativeTTL),
+			staleUntil: now.Add(c.Config.NegativeTTL), // Negative entries are never served stale
+		})
+	}
+}
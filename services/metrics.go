@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"time"
+)
+
+// Metrics holds the service metrics: upstream provider latencies and errors, CEP race winners
+// and cache lookups. A nil *Metrics records nothing, so the services work without it.
+// Metrics contém as métricas dos serviços: latências e erros dos provedores de origem, vencedores
+// da disputa de CEP e consultas aos caches. Um *Metrics nil não registra nada, então os serviços
+// funcionam sem ele.
+type Metrics struct {
+	UpstreamDuration *shared.Histogram // Provider call latency by provider, operation and outcome
+	UpstreamErrors   *shared.Counter   // Failed provider calls by provider and operation
+	CEPRaceWins      *shared.Counter   // CEP lookups won by each provider
+	CacheLookups     *shared.Counter   // Cache lookups by cache and result (hit, stale or miss)
+}
+
+// NewMetrics registers the service metrics in registry.
+// Registra as métricas dos serviços em registry.
+func NewMetrics(registry *shared.MetricsRegistry) *Metrics {
+	return &Metrics{
+		UpstreamDuration: registry.NewHistogram("upstream_request_duration_seconds",
+			"Latency of the calls to the CEP and weather providers.", shared.DefaultLatencyBuckets, "provider", "operation", "outcome"),
+		UpstreamErrors: registry.NewCounter("upstream_errors_total",
+			"Calls to the CEP and weather providers that failed.", "provider", "operation"),
+		CEPRaceWins: registry.NewCounter("cep_race_wins_total",
+			"CEP lookups answered first by each provider.", "provider"),
+		CacheLookups: registry.NewCounter("cache_lookups_total",
+			"Cache lookups by cache and result.", "cache", "result"),
+	}
+}
+
+// observeUpstream records a provider call that started at start. Calls cancelled because the
+// caller no longer needed them, such as race losers, are not counted as errors.
+// Registra uma chamada a um provedor iniciada em start. Chamadas canceladas porque quem chamou
+// não precisava mais delas, como os perdedores da disputa, não contam como erros.
+func (m *Metrics) observeUpstream(ctx context.Context, provider, operation string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	outcome := "success"
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		outcome = "cancelled"
+	case err != nil:
+		outcome = "error"
+		m.UpstreamErrors.Inc(provider, operation)
+	}
+	m.UpstreamDuration.Observe(time.Since(start).Seconds(), provider, operation, outcome)
+}
+
+// cepRaceWon records the provider that answered a CEP lookup first.
+// Registra o provedor que respondeu primeiro a uma busca de CEP.
+func (m *Metrics) cepRaceWon(provider string) {
+	if m != nil {
+		m.CEPRaceWins.Inc(provider)
+	}
+}
+
+// cacheLookup records a cache lookup result.
+// Registra o resultado de uma consulta a um cache.
+func (m *Metrics) cacheLookup(cache, result string) {
+	if m != nil {
+		m.CacheLookups.Inc(cache, result)
+	}
+}
+
+// InstrumentedWeatherProvider is a WeatherProvider decorator that records the latency and the
+// errors of each call, and logs the reason of each failure.
+// InstrumentedWeatherProvider é um decorador de WeatherProvider que registra a latência e os
+// erros de cada chamada, e o motivo de cada falha no log.
+type InstrumentedWeatherProvider struct {
+	Next    WeatherProvider // Provider being measured
+	Metrics *Metrics        // Where the calls are recorded
+}
+
+// NewInstrumentedWeatherProvider wraps next so its calls are recorded in metrics.
+// Envolve next para que suas chamadas sejam registradas em metrics.
+func NewInstrumentedWeatherProvider(next WeatherProvider, metrics *Metrics) *InstrumentedWeatherProvider {
+	return &InstrumentedWeatherProvider{Next: next, Metrics: metrics}
+}
+
+// Name returns the name of the wrapped provider.
+// Retorna o nome do provedor envolvido.
+func (p *InstrumentedWeatherProvider) Name() string {
+	return p.Next.Name()
+}
+
+// CurrentWeather fetches the current weather from the wrapped provider and records the call.
+// Busca o clima atual no provedor envolvido e registra a chamada.
+func (p *InstrumentedWeatherProvider) CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	start := time.Now()
+	weather, err := p.Next.CurrentWeather(ctx, location)
+	p.Metrics.observeUpstream(ctx, p.Name(), "current", start, err)
+	logUpstreamFailure(ctx, p.Name(), "current", err)
+	return weather, err
+}
+
+// Forecast fetches the forecast from the wrapped provider and records the call.
+// Busca a previsão no provedor envolvido e registra a chamada.
+func (p *InstrumentedWeatherProvider) Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	start := time.Now()
+	forecast, err := p.Next.Forecast(ctx, location, days)
+	p.Metrics.observeUpstream(ctx, p.Name(), "forecast", start, err)
+	logUpstreamFailure(ctx, p.Name(), "forecast", err)
+	return forecast, err
+}
+
+// History fetches the weather history from the wrapped provider and records the call.
+// Busca o histórico do clima no provedor envolvido e registra a chamada.
+func (p *InstrumentedWeatherProvider) History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	callStart := time.Now()
+	history, err := p.Next.History(ctx, location, start, end)
+	p.Metrics.observeUpstream(ctx, p.Name(), "history", callStart, err)
+	logUpstreamFailure(ctx, p.Name(), "history", err)
+	return history, err
+}
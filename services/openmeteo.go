@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Public Open-Meteo endpoints. Open-Meteo needs no API key.
+// Endpoints públicos da Open-Meteo. A Open-Meteo não exige chave de API.
+const (
+	DefaultOpenMeteoBaseURL      = "https://api.open-meteo.com/v1"
+	DefaultOpenMeteoArchiveURL   = "https://archive-api.open-meteo.com/v1"
+	DefaultOpenMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1"
+)
+
+// Variables requested from Open-Meteo for each operation.
+// Variáveis solicitadas à Open-Meteo em cada operação.
+const (
+	openMeteoCurrent      = "temperature_2m,relative_humidity_2m,apparent_temperature,is_day,precipitation,weather_code,cloud_cover,pressure_msl,wind_speed_10m,wind_direction_10m,wind_gusts_10m,uv_index,visibility"
+	openMeteoDaily        = "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max"
+	openMeteoHourly       = "temperature_2m,precipitation_probability,weather_code"
+	openMeteoArchiveDaily = "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum"
+	openMeteoArchiveHours = "temperature_2m,weather_code"
+)
+
+// OpenMeteoProvider fetches weather data from Open-Meteo. Open-Meteo only queries by
+// coordinates, so locations without them are geocoded by city and UF first.
+// OpenMeteoProvider busca dados de clima na Open-Meteo. A Open-Meteo só consulta por
+// coordenadas, então localizações sem elas são geocodificadas por cidade e UF antes.
+type OpenMeteoProvider struct {
+	Client       APIClient // The API client used for making requests.
+	BaseURL      string    // Base URL of the forecast API
+	ArchiveURL   string    // Base URL of the historical weather API
+	GeocodingURL string    // Base URL of the geocoding API
+	geocoded     *shared.LRUCache[string, [2]float64]
+}
+
+// NewOpenMeteoProvider creates and returns a new OpenMeteoProvider.
+// Cria e retorna um novo OpenMeteoProvider.
+func NewOpenMeteoProvider(client APIClient, baseURL, archiveURL, geocodingURL string) *OpenMeteoProvider {
+	return &OpenMeteoProvider{
+		Client:       client,
+		BaseURL:      baseURL,
+		ArchiveURL:   archiveURL,
+		GeocodingURL: geocodingURL,
+		geocoded:     shared.NewLRUCache[string, [2]float64](1000), // Cities do not move
+	}
+}
+
+// Name returns the provider name.
+// Retorna o nome do provedor.
+func (p *OpenMeteoProvider) Name() string {
+	return "openmeteo"
+}
+
+// CurrentWeather retrieves the current conditions for a resolved location.
+// Recupera as condições atuais para uma localização resolvida.
+func (p *OpenMeteoProvider) CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	fetchedAt := time.Now()
+	params := url.Values{"current": {openMeteoCurrent}}
+
+	var response models.OpenMeteoResponse
+	if err := p.get(ctx, p.BaseURL+"/forecast", location, params, &response); err != nil {
+		return models.CurrentWeather{}, err
+	}
+
+	current := response.Current
+	return models.CurrentWeather{
+		Providers:   []string{p.Name()},
+		TempC:       current.Temperature,
+		FeelsLikeC:  current.ApparentTemperature,
+		Condition:   wmoCondition(current.WeatherCode),
+		Humidity:    current.RelativeHumidity,
+		Cloud:       current.CloudCover,
+		UV:          current.UVIndex,
+		IsDay:       current.IsDay == 1,
+		WindKph:     current.WindSpeed,
+		GustKph:     current.WindGusts,
+		WindDegree:  current.WindDirection,
+		WindDir:     compassDirection(current.WindDirection),
+		PressureMb:  current.PressureMSL,
+		PrecipMm:    current.Precipitation,
+		VisKm:       current.Visibility / 1000,
+		LastUpdated: time.Unix(current.Time, 0),
+		FetchedAt:   fetchedAt,
+	}, nil
+}
+
+// Forecast retrieves a daily and hourly forecast of the given number of days (up to 16).
+// Recupera uma previsão diária e horária com o número de dias informado (até 16).
+func (p *OpenMeteoProvider) Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	params := url.Values{
+		"daily":         {openMeteoDaily},
+		"hourly":        {openMeteoHourly},
+		"forecast_days": {strconv.Itoa(days)},
+	}
+	return p.forecast(ctx, p.BaseURL+"/forecast", location, params)
+}
+
+// History retrieves the observed weather for each day from start to end, inclusive, from the
+// reanalysis archive. The archive has no precipitation probability.
+// Recupera o clima observado em cada dia de start até end, inclusive, a partir do arquivo de
+// reanálise. O arquivo não tem probabilidade de precipitação.
+func (p *OpenMeteoProvider) History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	params := url.Values{
+		"daily":      {openMeteoArchiveDaily},
+		"hourly":     {openMeteoArchiveHours},
+		"start_date": {start.Format(time.DateOnly)},
+		"end_date":   {end.Format(time.DateOnly)},
+	}
+	return p.forecast(ctx, p.ArchiveURL+"/archive", location, params)
+}
+
+// forecast calls a daily/hourly endpoint and groups the hourly series under each local day.
+// Chama um endpoint diário/horário e agrupa a série horária em cada dia local.
+func (p *OpenMeteoProvider) forecast(ctx context.Context, endpoint string, location models.Location, params url.Values) (models.Forecast, error) {
+	fetchedAt := time.Now()
+	var response models.OpenMeteoResponse
+	if err := p.get(ctx, endpoint, location, params, &response); err != nil {
+		return models.Forecast{}, err
+	}
+
+	// Group the hours by local date
+	// Agrupa as horas pela data local
+	hourly := response.Hourly
+	hoursByDate := make(map[string][]models.ForecastHour)
+	for i, unix := range hourly.Time {
+		hourTime := localTime(unix, response.UTCOffsetSeconds)
+		date := hourTime.Format(time.DateOnly)
+		hoursByDate[date] = append(hoursByDate[date], models.ForecastHour{
+			Time:         hourTime.Format("2006-01-02 15:04"),
+			TempC:        valueAt(hourly.Temperature, i),
+			Condition:    wmoCondition(valueAt(hourly.WeatherCode, i)),
+			ChanceOfRain: valueAt(hourly.PrecipitationProbability, i),
+		})
+	}
+
+	daily := response.Daily
+	forecast := models.Forecast{Providers: []string{p.Name()}, FetchedAt: fetchedAt}
+	for i, unix := range daily.Time {
+		date := localTime(unix, response.UTCOffsetSeconds).Format(time.DateOnly)
+		day := models.ForecastDay{
+			Date:         date,
+			MinTempC:     valueAt(daily.TemperatureMin, i),
+			MaxTempC:     valueAt(daily.TemperatureMax, i),
+			Condition:    wmoCondition(valueAt(daily.WeatherCode, i)),
+			ChanceOfRain: valueAt(daily.PrecipitationProbabilityMax, i),
+			PrecipMm:     valueAt(daily.PrecipitationSum, i),
+			Hours:        hoursByDate[date],
+		}
+		day.AvgTempC = averageTemperature(day)
+		forecast.Days = append(forecast.Days, day)
+	}
+	return forecast, nil
+}
+
+// get calls an Open-Meteo endpoint for the coordinates of a location and decodes a successful
+// response into target. Times come back as Unix timestamps plus the local UTC offset.
+// Chama um endpoint da Open-Meteo para as coordenadas de uma localização e decodifica uma
+// resposta de sucesso em target. Os horários vêm como timestamps Unix mais o deslocamento local.
+func (p *OpenMeteoProvider) get(ctx context.Context, endpoint string, location models.Location, params url.Values, target any) error {
+	latitude, longitude, err := p.coordinates(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	params.Set("latitude", strconv.FormatFloat(latitude, 'f', 4, 64))
+	params.Set("longitude", strconv.FormatFloat(longitude, 'f', 4, 64))
+	params.Set("timezone", "auto")       // Days and hours in the local time of the location
+	params.Set("timeformat", "unixtime") // Unambiguous timestamps
+	return p.getJSON(ctx, fmt.Sprintf("%s?%s", endpoint, params.Encode()), target)
+}
+
+// coordinates returns the coordinates of a location, geocoding its city and UF when the CEP
+// provider did not know them. Geocoded cities are cached.
+// Retorna as coordenadas de uma localização, geocodificando sua cidade e UF quando o provedor
+// de CEP não as conhecia. Cidades geocodificadas ficam em cache.
+func (p *OpenMeteoProvider) coordinates(ctx context.Context, location models.Location) (float64, float64, error) {
+	if location.Latitude != nil && location.Longitude != nil {
+		return *location.Latitude, *location.Longitude, nil
+	}
+	if location.City == nil || *location.City == "" {
+		return 0, 0, errNoWeatherQuery
+	}
+
+	key := weatherCacheKey(location)
+	if coordinates, ok := p.geocoded.Get(key); ok {
+		return coordinates[0], coordinates[1], nil
+	}
+
+	params := url.Values{
+		"name":        {*location.City},
+		"count":       {"10"}, // Enough to tell homonymous cities apart by state
+		"language":    {"pt"},
+		"countryCode": {"BR"},
+	}
+	var response models.OpenMeteoGeocodingResponse
+	if err := p.getJSON(ctx, fmt.Sprintf("%s/search?%s", p.GeocodingURL, params.Encode()), &response); err != nil {
+		return 0, 0, err
+	}
+
+	state := ""
+	if location.Uf != nil {
+		state = brazilianStates[strings.ToUpper(*location.Uf)]
+	}
+	for _, result := range response.Results {
+		if result.CountryCode != "BR" || (state != "" && !strings.EqualFold(result.Admin1, state)) {
+			continue // Another country or a homonymous city in another state
+		}
+		p.geocoded.Add(key, [2]float64{result.Latitude, result.Longitude})
+		return result.Latitude, result.Longitude, nil
+	}
+	return 0, 0, &UpstreamError{
+		Provider:   p.Name(),
+		Kind:       ErrUpstreamNotFound,
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("no geocoding result for %s", key),
+	}
+}
+
+// getJSON performs a GET and decodes a successful response into target, or classifies the failure.
+// Realiza um GET e decodifica uma resposta de sucesso em target, ou classifica a falha.
+func (p *OpenMeteoProvider) getJSON(ctx context.Context, url string, target any) error {
+	resp, err := p.Client.Get(ctx, url)
+	if err != nil {
+		return err // Return error if the request fails
+	}
+	defer resp.Body.Close() // Close response body when done
+
+	if resp.StatusCode != http.StatusOK {
+		var payload models.OpenMeteoErrorResponse
+		json.NewDecoder(resp.Body).Decode(&payload) // Best effort: the body may not be JSON
+		return &UpstreamError{
+			Provider:   p.Name(),
+			Kind:       upstreamStatusKind(resp.StatusCode),
+			StatusCode: resp.StatusCode,
+			Message:    payload.Reason,
+		}
+	}
+	return json.NewDecoder(resp.Body).Decode(target) // Return error if the response cannot be decoded
+}
+
+// averageTemperature averages the hourly temperatures of a day, falling back to the midpoint
+// of the extremes when there is no hourly breakdown. Rounded to one decimal like the others.
+// Calcula a média das temperaturas horárias de um dia, usando o ponto médio dos extremos quando
+// não há detalhamento por hora. Arredondada para uma casa decimal como as demais.
+func averageTemperature(day models.ForecastDay) float64 {
+	average := (day.MinTempC + day.MaxTempC) / 2
+	if len(day.Hours) > 0 {
+		sum := 0.0
+		for _, hour := range day.Hours {
+			sum += hour.TempC
+		}
+		average = sum / float64(len(day.Hours))
+	}
+	return math.Round(average*10) / 10
+}
+
+// wmoCondition describes a WMO weather interpretation code, as returned by Open-Meteo.
+// Descreve um código de interpretação do tempo da OMM, como retornado pela Open-Meteo.
+func wmoCondition(code int) models.WeatherCondition {
+	text, ok := wmoConditions[code]
+	if !ok {
+		text = "Unknown"
+	}
+	return models.WeatherCondition{Text: text, Code: code}
+}
+
+// wmoConditions maps the WMO weather interpretation codes to their descriptions.
+// wmoConditions mapeia os códigos de interpretação do tempo da OMM para suas descrições.
+var wmoConditions = map[int]string{
+	0:  "Clear sky",
+	1:  "Mainly clear",
+	2:  "Partly cloudy",
+	3:  "Overcast",
+	45: "Fog",
+	48: "Depositing rime fog",
+	51: "Light drizzle",
+	53: "Moderate drizzle",
+	55: "Dense drizzle",
+	56: "Light freezing drizzle",
+	57: "Dense freezing drizzle",
+	61: "Slight rain",
+	63: "Moderate rain",
+	65: "Heavy rain",
+	66: "Light freezing rain",
+	67: "Heavy freezing rain",
+	71: "Slight snow fall",
+	73: "Moderate snow fall",
+	75: "Heavy snow fall",
+	77: "Snow grains",
+	80: "Slight rain showers",
+	81: "Moderate rain showers",
+	82: "Violent rain showers",
+	85: "Slight snow showers",
+	86: "Heavy snow showers",
+	95: "Thunderstorm",
+	96: "Thunderstorm with slight hail",
+	99: "Thunderstorm with heavy hail",
+}
+
+// brazilianStates maps each UF to the state name used by the geocoding API.
+// brazilianStates mapeia cada UF para o nome do estado usado pela API de geocodificação.
+var brazilianStates = map[string]string{
+	"AC": "Acre", "AL": "Alagoas", "AP": "Amapá", "AM": "Amazonas", "BA": "Bahia",
+	"CE": "Ceará", "DF": "Distrito Federal", "ES": "Espírito Santo", "GO": "Goiás",
+	"MA": "Maranhão", "MT": "Mato Grosso", "MS": "Mato Grosso do Sul", "MG": "Minas Gerais",
+	"PA": "Pará", "PB": "Paraíba", "PR": "Paraná", "PE": "Pernambuco", "PI": "Piauí",
+	"RJ": "Rio de Janeiro", "RN": "Rio Grande do Norte", "RS": "Rio Grande do Sul",
+	"RO": "Rondônia", "RR": "Roraima", "SC": "Santa Catarina", "SP": "São Paulo",
+	"SE": "Sergipe", "TO": "Tocantins",
+}
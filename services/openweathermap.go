@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"strconv"
+	"time"
+)
+
+// DefaultOpenWeatherMapBaseURL is the public OpenWeatherMap endpoint.
+// DefaultOpenWeatherMapBaseURL é o endpoint público da OpenWeatherMap.
+const DefaultOpenWeatherMapBaseURL = "https://api.openweathermap.org"
+
+// maxOpenWeatherMapForecastDays is how far the free 3-hour step forecast reaches.
+// maxOpenWeatherMapForecastDays é o alcance da previsão gratuita em intervalos de 3 horas.
+const maxOpenWeatherMapForecastDays = 5
+
+// OpenWeatherMapProvider fetches weather data from OpenWeatherMap. History needs a paid
+// plan, so it is not supported.
+// OpenWeatherMapProvider busca dados de clima na OpenWeatherMap. O histórico exige um plano
+// pago, então não é suportado.
+type OpenWeatherMapProvider struct {
+	Client  APIClient // The API client used for making requests.
+	BaseURL string    // Base URL of the API, replaceable in tests
+	APIKey  string    // OpenWeatherMap API key
+}
+
+// NewOpenWeatherMapProvider creates and returns a new OpenWeatherMapProvider.
+// Cria e retorna um novo OpenWeatherMapProvider.
+func NewOpenWeatherMapProvider(client APIClient, baseURL, apiKey string) *OpenWeatherMapProvider {
+	return &OpenWeatherMapProvider{Client: client, BaseURL: baseURL, APIKey: apiKey}
+}
+
+// Name returns the provider name.
+// Retorna o nome do provedor.
+func (p *OpenWeatherMapProvider) Name() string {
+	return "openweathermap"
+}
+
+// CurrentWeather retrieves the current conditions for a resolved location.
+// Recupera as condições atuais para uma localização resolvida.
+func (p *OpenWeatherMapProvider) CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	fetchedAt := time.Now()
+	var response models.OpenWeatherMapResponse
+	if err := p.get(ctx, "/data/2.5/weather", location, url.Values{}, &response); err != nil {
+		return models.CurrentWeather{}, err
+	}
+
+	return models.CurrentWeather{
+		Providers:   []string{p.Name()},
+		TempC:       response.Main.Temp,
+		FeelsLikeC:  response.Main.FeelsLike,
+		Condition:   openWeatherMapCondition(response.Weather),
+		Humidity:    response.Main.Humidity,
+		Cloud:       response.Clouds.All,
+		IsDay:       response.Dt >= response.Sys.Sunrise && response.Dt < response.Sys.Sunset,
+		WindKph:     msToKph(response.Wind.Speed),
+		GustKph:     msToKph(response.Wind.Gust),
+		WindDegree:  response.Wind.Deg,
+		WindDir:     compassDirection(response.Wind.Deg),
+		PressureMb:  response.Main.Pressure,
+		PrecipMm:    response.Rain.OneHour,
+		VisKm:       response.Visibility / 1000,
+		LastUpdated: time.Unix(response.Dt, 0),
+		FetchedAt:   fetchedAt,
+	}, nil
+}
+
+// Forecast retrieves a forecast of up to 5 days, aggregating the 3-hour steps into days.
+// Recupera uma previsão de até 5 dias, agregando os intervalos de 3 horas em dias.
+func (p *OpenWeatherMapProvider) Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	if days > maxOpenWeatherMapForecastDays {
+		return models.Forecast{}, fmt.Errorf("%w: openweathermap forecasts cover at most %d days", ErrUnsupported, maxOpenWeatherMapForecastDays)
+	}
+
+	fetchedAt := time.Now()
+	var response models.OpenWeatherMapForecastResponse
+	if err := p.get(ctx, "/data/2.5/forecast", location, url.Values{}, &response); err != nil {
+		return models.Forecast{}, err
+	}
+
+	forecast := models.Forecast{Providers: []string{p.Name()}, FetchedAt: fetchedAt}
+	for _, step := range response.List {
+		stepTime := localTime(step.Dt, response.City.Timezone)
+		date := stepTime.Format(time.DateOnly)
+		if len(forecast.Days) == 0 || forecast.Days[len(forecast.Days)-1].Date != date {
+			if len(forecast.Days) == days {
+				break // Enough days
+			}
+			forecast.Days = append(forecast.Days, models.ForecastDay{
+				Date:     date,
+				MinTempC: step.Main.TempMin,
+				MaxTempC: step.Main.TempMax,
+			})
+		}
+
+		day := &forecast.Days[len(forecast.Days)-1]
+		hour := models.ForecastHour{
+			Time:         stepTime.Format("2006-01-02 15:04"),
+			TempC:        step.Main.Temp,
+			Condition:    openWeatherMapCondition(step.Weather),
+			ChanceOfRain: int(math.Round(step.Pop * 100)),
+		}
+		day.Hours = append(day.Hours, hour)
+		day.MinTempC = math.Min(day.MinTempC, step.Main.TempMin)
+		day.MaxTempC = math.Max(day.MaxTempC, step.Main.TempMax)
+		day.ChanceOfRain = max(day.ChanceOfRain, hour.ChanceOfRain)
+		day.PrecipMm += step.Rain.ThreeHours
+		if stepTime.Hour() <= 12 || day.Condition.Text == "" {
+			day.Condition = hour.Condition // The condition closest to midday describes the day
+		}
+	}
+
+	for i := range forecast.Days {
+		forecast.Days[i].AvgTempC = averageTemperature(forecast.Days[i])
+		forecast.Days[i].PrecipMm = math.Round(forecast.Days[i].PrecipMm*100) / 100
+	}
+	return forecast, nil
+}
+
+// History is not available on the free OpenWeatherMap plans.
+// O histórico não está disponível nos planos gratuitos da OpenWeatherMap.
+func (p *OpenWeatherMapProvider) History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	return models.Forecast{}, fmt.Errorf("%w: openweathermap has no history on the free plan", ErrUnsupported)
+}
+
+// get calls an OpenWeatherMap endpoint for a location and decodes a successful response into
+// target. States are only understood for the US, so cities are queried as "city,BR".
+// Chama um endpoint da OpenWeatherMap para uma localização e decodifica uma resposta de sucesso
+// em target. Estados só são entendidos para os EUA, então cidades são consultadas como "cidade,BR".
+func (p *OpenWeatherMapProvider) get(ctx context.Context, path string, location models.Location, params url.Values, target any) error {
+	switch {
+	case location.Latitude != nil && location.Longitude != nil:
+		params.Set("lat", strconv.FormatFloat(*location.Latitude, 'f', 4, 64))
+		params.Set("lon", strconv.FormatFloat(*location.Longitude, 'f', 4, 64))
+	case location.City != nil && *location.City != "":
+		params.Set("q", *location.City+",BR")
+	default:
+		return errNoWeatherQuery
+	}
+	params.Set("appid", p.APIKey)
+	params.Set("units", "metric")
+
+	resp, err := p.Client.Get(ctx, fmt.Sprintf("%s%s?%s", p.BaseURL, path, params.Encode()))
+	if err != nil {
+		return err // Return error if the request fails
+	}
+	defer resp.Body.Close() // Close response body when done
+
+	if resp.StatusCode != http.StatusOK {
+		var payload models.OpenWeatherMapErrorResponse
+		json.NewDecoder(resp.Body).Decode(&payload) // Best effort: the body may not be JSON
+		return &UpstreamError{
+			Provider:   p.Name(),
+			Kind:       upstreamStatusKind(resp.StatusCode),
+			StatusCode: resp.StatusCode,
+			Message:    payload.Message,
+		}
+	}
+	return json.NewDecoder(resp.Body).Decode(target) // Return error if the response cannot be decoded
+}
+
+// openWeatherMapCondition converts the first OpenWeatherMap condition, which is the primary one.
+// Converte a primeira condição da OpenWeatherMap, que é a principal.
+func openWeatherMapCondition(conditions []models.OpenWeatherMapCondition) models.WeatherCondition {
+	if len(conditions) == 0 {
+		return models.WeatherCondition{}
+	}
+	return models.WeatherCondition{
+		Text: conditions[0].Description,
+		Icon: fmt.Sprintf("https://openweathermap.org/img/wn/%s@2x.png", conditions[0].Icon),
+		Code: conditions[0].ID,
+	}
+}
+
+// msToKph converts meters per second to kilometers per hour, rounded to one decimal.
+// Converte metros por segundo para quilômetros por hora, arredondando para uma casa.
+func msToKph(ms float64) float64 {
+	return math.Round(ms*3.6*10) / 10
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RetryConfig configures the retries of a RetryClient.
+// RetryConfig configura as novas tentativas de um RetryClient.
+type RetryConfig struct {
+	MaxAttempts    int           // Attempts per call, including the first one; 1 disables retries
+	AttemptTimeout time.Duration // Maximum time of a single attempt
+	BaseDelay      time.Duration // Backoff before the first retry, doubled on each retry
+	MaxDelay       time.Duration // Upper bound of the backoff
+	Budget         time.Duration // Maximum total time of a call, retries and backoff included
+}
+
+// DefaultRetryConfig is used when no retry configuration is given. The budget stays below the
+// 10 s CEP lookup timeout so a retried provider can still win the race.
+// DefaultRetryConfig é usado quando nenhuma configuração de novas tentativas é fornecida. O
+// orçamento fica abaixo do timeout de 10 s da busca de CEP para que um provedor que tentou de
+// novo ainda possa vencer a disputa.
+var DefaultRetryConfig = RetryConfig{
+	MaxAttempts:    3,
+	AttemptTimeout: 4 * time.Second,
+	BaseDelay:      100 * time.Millisecond,
+	MaxDelay:       2 * time.Second,
+	Budget:         8 * time.Second,
+}
+
+// RetryClient is an APIClient decorator that bounds each attempt with a timeout and retries
+// transient failures (connection errors, timeouts, 502, 503, 504 and 429) with exponential
+// backoff and jitter. A 429 waits for its Retry-After instead. No retry is made once it
+// would not fit the budget or the caller's deadline; the last answer is returned instead.
+// RetryClient é um decorador de APIClient que limita cada tentativa com um timeout e tenta
+// de novo falhas transitórias (erros de conexão, timeouts, 502, 503, 504 e 429) com backoff
+// exponencial e jitter. Um 429 espera o seu Retry-After. Nenhuma nova tentativa é feita
+// quando ela não caberia no orçamento ou no prazo de quem chama; a última resposta é retornada.
+type RetryClient struct {
+	Next   APIClient   // Client that performs each attempt
+	Config RetryConfig // Retry configuration
+}
+
+// NewRetryClient wraps next with timeouts and retries.
+// Envolve next com timeouts e novas tentativas.
+func NewRetryClient(next APIClient, config RetryConfig) *RetryClient {
+	return &RetryClient{Next: next, Config: config}
+}
+
+// Get performs the request, retrying transient failures. The attempt context lives until the
+// response body is closed, so the caller can still read it.
+// Realiza a requisição, tentando de novo as falhas transitórias. O contexto da tentativa vive
+// até o corpo da resposta ser fechado, para que quem chama ainda possa lê-lo.
+func (c *RetryClient) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	callCtx, cancelCall := ctx, context.CancelFunc(func() {})
+	if c.Config.Budget > 0 {
+		callCtx, cancelCall = context.WithTimeout(ctx, c.Config.Budget)
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancelAttempt := callCtx, context.CancelFunc(func() {})
+		if c.Config.AttemptTimeout > 0 {
+			attemptCtx, cancelAttempt = context.WithTimeout(callCtx, c.Config.AttemptTimeout)
+		}
+		resp, err := c.Next.Get(attemptCtx, rawURL)
+
+		delay, retry := c.retryDelay(attempt, resp, err)
+		if retry && callCtx.Err() == nil && fitsDeadline(callCtx, delay) {
+			if resp != nil {
+				io.Copy(io.Discard, resp.Body) // Drain so the connection can be reused
+				resp.Body.Close()
+			}
+			cancelAttempt()
+
+			select {
+			case <-time.After(delay):
+				continue
+			case <-callCtx.Done():
+				cancelCall()
+				return nil, callCtx.Err() // The deadline hit while backing off
+			}
+		}
+
+		if err != nil {
+			cancelAttempt()
+			cancelCall()
+			return nil, err
+		}
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: func() { cancelAttempt(); cancelCall() }}
+		return resp, nil
+	}
+}
+
+// retryDelay reports whether an attempt should be retried and how long to wait before it.
+// Informa se uma tentativa deve ser repetida e quanto esperar antes dela.
+func (c *RetryClient) retryDelay(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= c.Config.MaxAttempts {
+		return 0, false // Out of attempts
+	}
+	if err != nil {
+		return c.backoff(attempt), retryableError(err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		if delay, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return delay, true // The upstream told us when to come back
+		}
+		return c.backoff(attempt), true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return c.backoff(attempt), true
+	default:
+		return 0, false
+	}
+}
+
+// backoff returns the exponential backoff of a retry with equal jitter: half of it is fixed
+// and the other half random, so concurrent callers do not retry in lockstep.
+// Retorna o backoff exponencial de uma nova tentativa com jitter igual: metade é fixa e a
+// outra metade aleatória, para que chamadas concorrentes não tentem de novo ao mesmo tempo.
+func (c *RetryClient) backoff(attempt int) time.Duration {
+	delay := c.Config.BaseDelay << (attempt - 1)
+	if delay > c.Config.MaxDelay || delay <= 0 {
+		delay = c.Config.MaxDelay // Capped, also guarding against overflow
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryableError reports whether a failed attempt may succeed if made again.
+// Informa se uma tentativa que falhou pode ter sucesso se feita novamente.
+func retryableError(err error) bool {
+	var openErr *CircuitOpenError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &openErr):
+		return false // The breaker already knows the host is down
+	case errors.As(err, &urlErr) && urlErr.Op == "parse":
+		return false // A malformed URL fails the same way every time
+	}
+	return true // Connection errors and attempt timeouts are transient
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+// Interpreta um cabeçalho Retry-After informado em segundos ou como data HTTP.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// fitsDeadline reports whether waiting delay still leaves time before the context deadline.
+// Informa se esperar delay ainda deixa tempo antes do prazo do contexto.
+func fitsDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > delay
+}
+
+// cancelOnClose releases the attempt context once the response body is closed.
+// cancelOnClose libera o contexto da tentativa quando o corpo da resposta é fechado.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases the attempt context.
+// Fecha o corpo e libera o contexto da tentativa.
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
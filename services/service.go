@@ -1,39 +1,42 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
+	"net"
 	"net/http"
 	"net/url"
-	"os"
 	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"strings"
+	"sync"
 	"time"
 )
 
 // APIClient defines the behavior of an external API client.
 // APIClient define o comportamento de um cliente para consumir APIs externas.
 type APIClient interface {
-	Get(url string) (*http.Response, error)
+	Get(ctx context.Context, url string) (*http.Response, error)
 }
 
 // LocationService is an interface that defines the methods to interact with location services.
 // LocationService é uma interface que define os métodos para interagir com serviços de localização.
 type LocationService interface {
-	GetLocationFromCEP(cep string, chBrasilAPI, chViaCEP chan models.Location) (models.Location, error)
+	GetLocationFromCEP(ctx context.Context, cep string) (models.Location, error)
 }
 
 // WeatherService is an interface that defines the methods for interacting with weather services.
 // WeatherService é uma interface que define os métodos para interagir com serviços de clima.
 type WeatherService interface {
-	GetTemperature(city string) (float64, error) // Get the temperature for a given city.
-	GetClient() APIClient                        // Return the API client used by the service.
+	GetCurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error)          // Get the current weather for a resolved location.
+	GetForecast(ctx context.Context, location models.Location, days int) (models.Forecast, error)            // Get a forecast of the given number of days.
+	GetHistory(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) // Get the observed weather for each day from start to end.
 }
 
 // WeatherServiceImpl is the concrete implementation of the WeatherService interface.
 // WeatherServiceImpl é a implementação concreta da interface WeatherService.
 type WeatherServiceImpl struct {
-	Client APIClient // The API client used for making requests.
+	Provider WeatherProvider // The weather provider answering the requests.
 }
 
 // APIClientImpl is the concrete implementation of the APIClient interface.
@@ -45,22 +48,28 @@ type APIClientImpl struct {
 // LocationServiceImpl is the concrete implementation of the LocationService interface.
 // LocationServiceImpl é a implementação concreta da interface LocationService.
 type LocationServiceImpl struct {
-	WeatherService WeatherService // Weather service instance to interact with weather data
+	Registry *CEPProviderRegistry // Registry holding the CEP providers raced on each lookup
+	Timeout  time.Duration        // Maximum time of a lookup, DefaultLocationLookupTimeout when zero
+	Metrics  *Metrics             // Records provider latencies and race winners, optional
 }
 
+// DefaultLocationLookupTimeout bounds a CEP lookup when no timeout is configured.
+// DefaultLocationLookupTimeout limita uma busca de CEP quando nenhum timeout é configurado.
+const DefaultLocationLookupTimeout = 10 * time.Second
+
 // NewWeatherService creates and returns a new instance of WeatherServiceImpl.
 // Cria e retorna uma nova instância do WeatherServiceImpl.
-func NewWeatherService(client APIClient) WeatherService {
+func NewWeatherService(provider WeatherProvider) WeatherService {
 	return &WeatherServiceImpl{
-		Client: client, // Assign the provided API client
+		Provider: provider, // Assign the provided weather provider
 	}
 }
 
 // NewLocationService creates and returns a new LocationServiceImpl instance.
 // Cria e retorna uma nova instância do LocationServiceImpl.
-func NewLocationService(weatherService WeatherService) LocationService {
+func NewLocationService(registry *CEPProviderRegistry) LocationService {
 	return &LocationServiceImpl{
-		WeatherService: weatherService, // Assign the provided weather service
+		Registry: registry, // Assign the provided CEP provider registry
 	}
 }
 
@@ -72,114 +81,230 @@ func NewAPIClient(client *http.Client) *APIClientImpl {
 	}
 }
 
-// GetTemperature retrieves the current temperature for a given city.
-// Recupera a temperatura atual para uma cidade específica.
-func (ws *WeatherServiceImpl) GetTemperature(city string) (float64, error) {
-	apiKey := os.Getenv("WEATHER_API_KEY") // Retrieve API key from environment variable
-	// Fix spaces on names
-	encodedCity := url.QueryEscape(city) // Encode the city name to ensure it works in a URL
-	url := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s", apiKey, encodedCity)
+// NewHTTPClient creates an HTTP client whose connection setup and response headers are bounded
+// by timeout, and whose whole request never exceeds twice that, as a last resort for callers
+// without a deadline.
+// Cria um cliente HTTP cuja conexão e cabeçalhos de resposta são limitados por timeout, e cuja
+// requisição inteira nunca passa do dobro disso, como último recurso para chamadas sem prazo.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+	transport.MaxIdleConnsPerHost = 10 // Keep connections to the few upstream hosts warm
+	return &http.Client{Transport: transport, Timeout: 2 * timeout}
+}
 
-	resp, err := ws.Client.Get(url) // Send GET request to the weather API
-	if err != nil {
-		return 0, err // Return error if the request fails
-	}
-	defer resp.Body.Close() // Close response body when done
+// GetCurrentWeather retrieves the current weather for a resolved location from the provider.
+// Recupera o clima atual para uma localização resolvida a partir do provedor.
+func (ws *WeatherServiceImpl) GetCurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	ctx, span := ws.startSpan(ctx, "weather current")
+	defer span.End()
+	weather, err := ws.Provider.CurrentWeather(ctx, location)
+	span.SetError(err)
+	return weather, err
+}
 
-	var weather models.WeatherResponse
-	if err := json.NewDecoder(resp.Body).Decode(&weather); err != nil {
-		return 0, err // Return error if the response cannot be decoded
-	}
+// GetForecast retrieves a daily and hourly forecast of the given number of days for a resolved location.
+// Recupera uma previsão diária e horária com o número de dias informado para uma localização resolvida.
+func (ws *WeatherServiceImpl) GetForecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	ctx, span := ws.startSpan(ctx, "weather forecast")
+	defer span.End()
+	forecast, err := ws.Provider.Forecast(ctx, location, days)
+	span.SetError(err)
+	return forecast, err
+}
 
-	return weather.Current.TempC, nil // Return the temperature in Celsius
+// GetHistory retrieves the observed weather for each day from start to end, inclusive.
+// Recupera o clima observado em cada dia de start até end, inclusive.
+func (ws *WeatherServiceImpl) GetHistory(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	ctx, span := ws.startSpan(ctx, "weather history")
+	defer span.End()
+	history, err := ws.Provider.History(ctx, location, start, end)
+	span.SetError(err)
+	return history, err
 }
 
-// GetClient returns the APIClient used in WeatherServiceImpl.
-// Retorna o APIClient usado no WeatherServiceImpl.
-func (ws *WeatherServiceImpl) GetClient() APIClient {
-	return ws.Client // Return the client used for HTTP requests
+// startSpan starts the span of a weather call, naming the provider that answers it.
+// Inicia o span de uma chamada de clima, indicando o provedor que a atende.
+func (ws *WeatherServiceImpl) startSpan(ctx context.Context, name string) (context.Context, *shared.Span) {
+	ctx, span := shared.StartSpan(ctx, name, shared.SpanKindInternal)
+	span.SetAttribute("weather.provider", ws.Provider.Name())
+	return ctx, span
 }
 
 // GetLocationFromCEP retrieves location data based on a given CEP.
+// Every enabled provider is queried concurrently and the first valid answer wins; the
+// lookup only fails once every provider has failed or the deadline is hit, and the
+// others are cancelled as soon as it returns.
 // Recupera dados de localização com base em um CEP fornecido.
-func (ls *LocationServiceImpl) GetLocationFromCEP(cep string, chBrasilAPI, chViaCEP chan models.Location) (models.Location, error) {
-	timeout := time.After(10 * time.Second) // Set a timeout for the operation
-
-	// Asynchronously fetch data from the APIs
-	// Busca os dados de forma assíncrona das APIs
-	go ls.fetchFromBrasilAPI(cep, chBrasilAPI)
-	go ls.fetchFromViaCEP(cep, chViaCEP)
-
-	select {
-	case res := <-chBrasilAPI: // Handle response from BrasilAPI
-		if res.Localidade != nil {
-			return res, nil // Return location data if valid
-		}
-	case res := <-chViaCEP: // Handle response from ViaCEP
-		if res.Localidade != nil {
-			return res, nil // Return location data if valid
+// Todos os provedores habilitados são consultados em paralelo e a primeira resposta válida vence;
+// a busca só falha quando todos os provedores falham ou o prazo expira, e os demais são
+// cancelados assim que ela retorna.
+func (ls *LocationServiceImpl) GetLocationFromCEP(ctx context.Context, cep string) (models.Location, error) {
+	providers := ls.Registry.Providers()
+	if len(providers) == 0 {
+		return models.Location{}, errors.New("no CEP provider enabled") // Nothing to race
+	}
+
+	// Bound the lookup and cancel the losing fetchers once we return
+	// Limita o tempo da busca e cancela os provedores perdedores ao retornar
+	timeout := ls.Timeout
+	if timeout <= 0 {
+		timeout = DefaultLocationLookupTimeout
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Buffered so fetchers that lose the race can still deliver their result and exit
+	// Com buffer para que os provedores que perderem a corrida ainda entreguem o resultado e terminem
+	race := &cepRace{results: make(chan providerResult, len(providers))}
+
+	// Asynchronously fetch data from every provider
+	// Busca os dados de forma assíncrona em todos os provedores
+	for _, provider := range providers {
+		go ls.fetchFromProvider(lookupCtx, provider, cep, race)
+	}
+
+	lookupErr := &LocationLookupError{}
+	answered := make(map[string]bool, len(providers))
+	for range providers {
+		select {
+		case res := <-race.results: // Handle each provider response as it arrives
+			if res.err == nil {
+				ls.Metrics.cepRaceWon(res.provider)
+				return res.location, nil // First valid answer wins
+			}
+			answered[res.provider] = true
+			lookupErr.Errors = append(lookupErr.Errors, ProviderError{Provider: res.provider, Err: res.err})
+		case <-lookupCtx.Done():
+			if err := ctx.Err(); err != nil {
+				return models.Location{}, err // The caller gave up, e.g. the client disconnected
+			}
+
+			// Record the providers that did not answer in time
+			// Registra os provedores que não responderam a tempo
+			lookupErr.TimedOut = true
+			for _, provider := range providers {
+				if !answered[provider.Name()] {
+					lookupErr.Errors = append(lookupErr.Errors, ProviderError{Provider: provider.Name(), Err: lookupCtx.Err()})
+				}
+			}
+			return models.Location{}, lookupErr
 		}
-	case <-timeout: // Timeout after 10 seconds
-		return models.Location{}, errors.New("timeout after 10 seconds") // Return timeout error
 	}
 
-	return models.Location{}, errors.New("error searching for CEP data") // Return error if neither API returns valid data
+	return models.Location{}, lookupErr // Every provider failed
+}
+
+// providerResult is the outcome of a single provider fetch.
+// providerResult é o resultado da busca em um único provedor.
+type providerResult struct {
+	provider string          // Name of the provider that answered
+	location models.Location // Location found, valid only when err is nil
+	err      error           // Reason the provider failed
 }
 
-// fetchFromBrasilAPI fetches location data from the BrasilAPI.
-// Busca dados de localização da API BrasilAPI.
-func (ls *LocationServiceImpl) fetchFromBrasilAPI(cep string, ch chan models.Location) {
-	url := fmt.Sprintf("https://brasilapi.com.br/api/cep/v1/%s", cep) // BrasilAPI URL
-	resp, err := ls.WeatherService.GetClient().Get(url)               // Use GetClient to avoid casting
-	if err != nil || resp.StatusCode != http.StatusOK {
-		ch <- models.Location{} // Send empty location if error occurs
-		return
+// cepRace is the state shared by the fetchers of one CEP lookup.
+// cepRace é o estado compartilhado pelos provedores de uma busca de CEP.
+type cepRace struct {
+	mu      sync.Mutex
+	decided bool                // A provider already delivered a valid answer
+	results chan providerResult // Has room for every provider, so sending never blocks
+}
+
+// deliver sends a provider outcome and reports whether it is the first valid answer, the one
+// GetLocationFromCEP returns. Sending under the lock keeps the channel in the same order.
+// Envia o resultado de um provedor e informa se é a primeira resposta válida, a que
+// GetLocationFromCEP retorna. Enviar sob o lock mantém o canal na mesma ordem.
+func (r *cepRace) deliver(res providerResult) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	won := res.err == nil && !r.decided
+	if won {
+		r.decided = true
 	}
-	defer resp.Body.Close() // Close response body when done
+	r.results <- res
+	return won
+}
 
-	var address models.BrasilAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&address); err != nil {
-		ch <- models.Location{} // Send empty location if decoding fails
-		return
+// fetchFromProvider fetches location data from a single provider and delivers the outcome to the race.
+// Busca dados de localização de um único provedor e entrega o resultado para a disputa.
+func (ls *LocationServiceImpl) fetchFromProvider(ctx context.Context, provider CEPProvider, cep string, race *cepRace) {
+	ctx, span := shared.StartSpan(ctx, "cep "+provider.Name(), shared.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("cep.provider", provider.Name())
+
+	start := time.Now()
+	location, err := provider.FetchLocation(ctx, cep)
+	if err == nil && (location.City == nil || *location.City == "") {
+		err = ErrCEPNotFound // An answer without a city is not a valid location
 	}
+	ls.Metrics.observeUpstream(ctx, provider.Name(), "cep", start, err)
+	logUpstreamFailure(ctx, provider.Name(), "cep", err)
 
-	// Send location data to the channel
-	ch <- models.Location{
-		Cep:        &cep,
-		Localidade: &address.Neighborhood,
-		Uf:         &address.State,
-		City:       &address.City,
+	// Mark how this provider did in the race: winner, late, cancelled or failed
+	// Marca como este provedor se saiu na disputa: vencedor, atrasado, cancelado ou com falha
+	won := race.deliver(providerResult{provider: provider.Name(), location: location, err: err})
+	switch {
+	case won:
+		span.SetAttribute("cep.outcome", "winner")
+	case err == nil:
+		span.SetAttribute("cep.outcome", "late") // Valid, but another provider answered first
+	case errors.Is(ctx.Err(), context.Canceled):
+		span.SetAttribute("cep.outcome", "cancelled") // The race was already decided
+	default:
+		span.SetAttribute("cep.outcome", "failed")
+		span.SetError(err)
 	}
 }
 
-// fetchFromViaCEP fetches location data from the ViaCEP API.
-// Busca dados de localização da API ViaCEP.
-func (ls *LocationServiceImpl) fetchFromViaCEP(cep string, ch chan models.Location) {
-	url := fmt.Sprintf("http://viacep.com.br/ws/%s/json", cep) // ViaCEP URL
-	resp, err := ls.WeatherService.GetClient().Get(url)        // Use GetClient to avoid casting
-	if err != nil || resp.StatusCode != http.StatusOK {
-		ch <- models.Location{} // Send empty location if error occurs
-		return
+// Get performs an HTTP GET request bound to the given context.
+// Realiza uma requisição HTTP GET vinculada ao contexto informado.
+func (api *APIClientImpl) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, redactURLError(err) // Return error if the request cannot be built
 	}
-	defer resp.Body.Close() // Close response body when done
 
-	var address models.ViaCEPResponse
-	if err := json.NewDecoder(resp.Body).Decode(&address); err != nil {
-		ch <- models.Location{} // Send empty location if decoding fails
-		return
+	// Trace the call and let the upstream join the trace through the traceparent header
+	// Rastreia a chamada e permite que a origem participe do trace pelo cabeçalho traceparent
+	_, span := shared.StartSpan(ctx, "GET "+req.URL.Host, shared.SpanKindClient)
+	defer span.End()
+	if span != nil {
+		span.SetAttribute("http.method", http.MethodGet)
+		span.SetAttribute("server.address", req.URL.Host)
+		span.SetAttribute("url.path", req.URL.Path) // The query may hold an API key
+		req.Header.Set("traceparent", span.SpanContext.Traceparent())
 	}
 
-	// Send location data to the channel
-	ch <- models.Location{
-		Cep:        &cep,
-		Localidade: &address.Localidade,
-		Uf:         &address.UF,
-		City:       &address.Localidade,
+	resp, err := api.Client.Do(req) // Perform the GET request using the HTTP client
+	if err != nil {
+		err = redactURLError(err) // Before the error reaches the spans, logs and responses
+		span.SetError(err)
+		return nil, err
 	}
+	span.SetAttribute("http.status_code", resp.StatusCode)
+	if resp.StatusCode >= 500 {
+		span.SetError(errors.New(resp.Status))
+	}
+	return resp, nil
 }
 
-// Get performs an HTTP GET request.
-// Realiza uma requisição HTTP GET.
-func (api *APIClientImpl) Get(url string) (*http.Response, error) {
-	return api.Client.Get(url) // Perform the GET request using the HTTP client
+// redactURLError removes the query from the URL of a *url.Error, which net/http includes in the
+// message of every transport failure, since the query may hold an API key. Other errors are
+// returned unchanged.
+// Remove a query da URL de um *url.Error, que o net/http inclui na mensagem de toda falha de
+// transporte, já que a query pode conter uma chave de API. Outros erros são retornados sem
+// alterações.
+func redactURLError(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	redacted := *urlErr // Copy, the original may be shared
+	if withoutQuery, _, found := strings.Cut(redacted.URL, "?"); found {
+		redacted.URL = withoutQuery
+	}
+	return &redacted
 }
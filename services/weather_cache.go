@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"strings"
+	"time"
+)
+
+// WeatherCacheConfig configures the current-weather cache.
+// WeatherCacheConfig configura o cache do clima atual.
+type WeatherCacheConfig struct {
+	TTL    time.Duration // How long an observation stays fresh, counted from when the weather API last updated it
+	MinTTL time.Duration // Minimum time an observation is kept after being fetched, even if already old
+	Size   int           // Maximum number of cached locations
+}
+
+// DefaultWeatherCacheConfig is used when no cache configuration is given.
+// DefaultWeatherCacheConfig é usado quando nenhuma configuração de cache é fornecida.
+var DefaultWeatherCacheConfig = WeatherCacheConfig{
+	TTL:    10 * time.Minute,
+	MinTTL: time.Minute,
+	Size:   1000,
+}
+
+// CachedWeatherService is a WeatherService decorator that caches current weather per location.
+// CachedWeatherService é um decorador de WeatherService que armazena o clima atual por localização.
+type CachedWeatherService struct {
+	Next    WeatherService     // Service called on cache misses
+	Config  WeatherCacheConfig // Cache configuration
+	Now     func() time.Time   // Clock, replaceable in tests
+	Metrics *Metrics           // Records hits and misses, optional
+	cache   *shared.LRUCache[string, weatherCacheEntry]
+}
+
+// weatherCacheEntry is a cached observation and the instant it stops being fresh.
+// weatherCacheEntry é uma observação em cache e o instante em que deixa de ser fresca.
+type weatherCacheEntry struct {
+	weather   models.CurrentWeather
+	expiresAt time.Time
+}
+
+// NewCachedWeatherService wraps next with a cache using the given configuration.
+// Envolve next com um cache usando a configuração informada.
+func NewCachedWeatherService(next WeatherService, config WeatherCacheConfig) *CachedWeatherService {
+	return &CachedWeatherService{
+		Next:   next,
+		Config: config,
+		Now:    time.Now,
+		cache:  shared.NewLRUCache[string, weatherCacheEntry](config.Size),
+	}
+}
+
+// GetCurrentWeather serves the observation from cache while it is fresh, otherwise fetches it.
+// Serve a observação do cache enquanto estiver fresca; caso contrário, busca novamente.
+func (c *CachedWeatherService) GetCurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	key := weatherCacheKey(location)
+	if entry, ok := c.cache.Get(key); ok && c.Now().Before(entry.expiresAt) {
+		c.Metrics.cacheLookup("weather", "hit")
+		return entry.weather, nil // Fresh hit
+	}
+	c.Metrics.cacheLookup("weather", "miss")
+
+	weather, err := c.Next.GetCurrentWeather(ctx, location)
+	if err != nil {
+		return weather, err // Failures are never cached
+	}
+
+	c.cache.Add(key, weatherCacheEntry{weather: weather, expiresAt: c.expiresAt(weather)})
+	return weather, nil
+}
+
+// GetForecast is not cached: forecasts are requested far less often than current weather.
+// GetForecast não usa cache: previsões são pedidas bem menos que o clima atual.
+func (c *CachedWeatherService) GetForecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	return c.Next.GetForecast(ctx, location, days)
+}
+
+// GetHistory is not cached: history lookups are rare and bound to a specific date range.
+// GetHistory não usa cache: consultas de histórico são raras e ligadas a um período específico.
+func (c *CachedWeatherService) GetHistory(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	return c.Next.GetHistory(ctx, location, start, end)
+}
+
+// expiresAt computes when an observation stops being fresh. It expires TTL after the
+// weather API last updated it, so an already old observation is refreshed sooner, but
+// never earlier than MinTTL after it was fetched.
+// Calcula quando uma observação deixa de ser fresca. Ela expira TTL depois da última
+// atualização da API de clima, para que uma observação já antiga seja renovada antes,
+// mas nunca antes de MinTTL após ter sido buscada.
+func (c *CachedWeatherService) expiresAt(weather models.CurrentWeather) time.Time {
+	fetchedAt := weather.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = c.Now()
+	}
+
+	expiresAt := fetchedAt.Add(c.Config.TTL)
+	if lastUpdated := weather.LastUpdated; !lastUpdated.IsZero() && lastUpdated.Before(fetchedAt) {
+		expiresAt = lastUpdated.Add(c.Config.TTL)
+	}
+	if minimum := fetchedAt.Add(c.Config.MinTTL); expiresAt.Before(minimum) {
+		expiresAt = minimum
+	}
+	return expiresAt
+}
+
+// weatherCacheKey turns a location into a cache key: its coordinates rounded to about
+// 1 km when known, otherwise its normalized city and UF, so "São Paulo/SP" and
+// " são paulo /sp" share the same entry.
+// Transforma uma localização em uma chave de cache: suas coordenadas arredondadas para
+// cerca de 1 km quando conhecidas, senão a cidade e a UF normalizadas, para que
+// "São Paulo/SP" e " são paulo /sp" compartilhem a mesma entrada.
+func weatherCacheKey(location models.Location) string {
+	if location.Latitude != nil && location.Longitude != nil {
+		return fmt.Sprintf("%.2f,%.2f", *location.Latitude, *location.Longitude)
+	}
+
+	normalize := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return strings.ToLower(strings.Join(strings.Fields(*value), " "))
+	}
+	return normalize(location.City) + "|" + normalize(location.Uf)
+}
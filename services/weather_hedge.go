@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"time"
+)
+
+// HedgeConfig configures request hedging on the weather call.
+// HedgeConfig configura o envio de requisições de reserva na chamada de clima.
+type HedgeConfig struct {
+	Percentile float64       // Latency percentile (0 to 100) of the primary after which the hedge is sent, 0 disables hedging
+	MinDelay   time.Duration // Shortest wait before hedging, so a fast primary is never doubled
+	MaxDelay   time.Duration // Longest wait before hedging, also used until enough latencies are known
+}
+
+// DefaultHedgeConfig is used when no hedging configuration is given. Hedging is off by default.
+// DefaultHedgeConfig é usado quando nenhuma configuração de reserva é fornecida. Vem desligado.
+var DefaultHedgeConfig = HedgeConfig{
+	Percentile: 0,
+	MinDelay:   50 * time.Millisecond,
+	MaxDelay:   time.Second,
+}
+
+// hedgeLatencySamples is how many recent latencies of each operation drive the hedge delay.
+// hedgeLatencySamples é quantas latências recentes de cada operação definem a espera da reserva.
+const hedgeLatencySamples = 200
+
+// HedgedWeatherProvider cuts the tail latency of the weather call: when the primary has not
+// answered after the configured percentile of its recent latencies, a second request is sent
+// to the backup (or to the primary again) and the first success wins, cancelling the other.
+// Failures are left to the strategies: a primary that fails before the delay is not hedged.
+// HedgedWeatherProvider reduz a latência de cauda da chamada de clima: quando o primário não
+// respondeu após o percentil configurado de suas latências recentes, uma segunda requisição é
+// enviada à reserva (ou ao primário de novo) e o primeiro sucesso vence, cancelando o outro.
+// Falhas ficam com as estratégias: um primário que falha antes da espera não ganha reserva.
+type HedgedWeatherProvider struct {
+	Primary WeatherProvider // Provider asked first
+	Backup  WeatherProvider // Provider asked by the hedge, the primary itself when nil
+	Config  HedgeConfig     // Hedging configuration
+
+	current  *shared.LatencyTracker // Recent latencies of the primary for CurrentWeather
+	forecast *shared.LatencyTracker // Recent latencies of the primary for Forecast
+	history  *shared.LatencyTracker // Recent latencies of the primary for History
+}
+
+// NewHedgedWeatherProvider wraps primary with request hedging. A nil backup hedges to the primary.
+// Envolve primary com requisições de reserva. Uma reserva nil repete o primário.
+func NewHedgedWeatherProvider(primary, backup WeatherProvider, config HedgeConfig) *HedgedWeatherProvider {
+	minSamples := hedgeLatencySamples / 10 // Enough for the tail to mean something
+	return &HedgedWeatherProvider{
+		Primary:  primary,
+		Backup:   backup,
+		Config:   config,
+		current:  shared.NewLatencyTracker(hedgeLatencySamples, minSamples),
+		forecast: shared.NewLatencyTracker(hedgeLatencySamples, minSamples),
+		history:  shared.NewLatencyTracker(hedgeLatencySamples, minSamples),
+	}
+}
+
+// Name returns the primary and the backup, e.g. "hedge(weatherapi,openmeteo)".
+// Retorna o primário e a reserva, ex.: "hedge(weatherapi,openmeteo)".
+func (p *HedgedWeatherProvider) Name() string {
+	return fmt.Sprintf("hedge(%s,%s)", p.Primary.Name(), p.backup().Name())
+}
+
+// CurrentWeather returns the current conditions of the first of the primary and the hedge to answer.
+// Retorna as condições atuais do primeiro entre o primário e a reserva a responder.
+func (p *HedgedWeatherProvider) CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	return hedge(ctx, p, p.current, func(ctx context.Context, provider WeatherProvider) (models.CurrentWeather, error) {
+		return provider.CurrentWeather(ctx, location)
+	})
+}
+
+// Forecast returns the forecast of the first of the primary and the hedge to answer.
+// Retorna a previsão do primeiro entre o primário e a reserva a responder.
+func (p *HedgedWeatherProvider) Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	return hedge(ctx, p, p.forecast, func(ctx context.Context, provider WeatherProvider) (models.Forecast, error) {
+		return provider.Forecast(ctx, location, days)
+	})
+}
+
+// History returns the history of the first of the primary and the hedge to answer.
+// Retorna o histórico do primeiro entre o primário e a reserva a responder.
+func (p *HedgedWeatherProvider) History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	return hedge(ctx, p, p.history, func(ctx context.Context, provider WeatherProvider) (models.Forecast, error) {
+		return provider.History(ctx, location, start, end)
+	})
+}
+
+// backup returns the provider asked by the hedge.
+// Retorna o provedor consultado pela reserva.
+func (p *HedgedWeatherProvider) backup() WeatherProvider {
+	if p.Backup == nil {
+		return p.Primary
+	}
+	return p.Backup
+}
+
+// delay returns how long to wait for the primary before hedging: the configured percentile of
+// its recent latencies, bounded by MinDelay and MaxDelay.
+// Retorna quanto esperar pelo primário antes da reserva: o percentil configurado de suas
+// latências recentes, limitado por MinDelay e MaxDelay.
+func (p *HedgedWeatherProvider) delay(latencies *shared.LatencyTracker) time.Duration {
+	delay, ok := latencies.Percentile(p.Config.Percentile)
+	if !ok {
+		return p.Config.MaxDelay // Too few samples yet, hedge only on clearly slow calls
+	}
+	return min(max(delay, p.Config.MinDelay), p.Config.MaxDelay)
+}
+
+// hedge calls the primary and, if it is still running after the hedge delay, the backup too,
+// returning the first success and cancelling the other call.
+// Chama o primário e, se ele ainda estiver rodando após a espera da reserva, a reserva também,
+// retornando o primeiro sucesso e cancelando a outra chamada.
+func hedge[T any](ctx context.Context, p *HedgedWeatherProvider, latencies *shared.LatencyTracker, call func(context.Context, WeatherProvider) (T, error)) (T, error) {
+	var zero T
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel() // Cancel the loser once we return
+
+	// Buffered so the loser can still deliver its result and exit
+	// Com buffer para que o perdedor ainda entregue o resultado e termine
+	type result struct {
+		provider string
+		value    T
+		err      error
+	}
+	results := make(chan result, 2)
+	launch := func(provider WeatherProvider) {
+		go func() {
+			value, err := call(hedgeCtx, provider)
+			results <- result{provider: provider.Name(), value: value, err: err}
+		}()
+	}
+
+	start := time.Now()
+	launch(p.Primary)
+	timer := time.NewTimer(p.delay(latencies))
+	defer timer.Stop()
+
+	inFlight, hedged := 1, false
+	lookupErr := &WeatherLookupError{}
+	for {
+		select {
+		case res := <-results:
+			inFlight--
+			if res.err == nil {
+				// A winning hedge only tells that the primary takes at least this long
+				// Uma reserva vencedora só indica que o primário leva ao menos esse tempo
+				latencies.Observe(time.Since(start))
+				return res.value, nil
+			}
+			if !hedged {
+				return zero, res.err // The primary failed before the hedge, leave it to the strategies
+			}
+			lookupErr.Errors = append(lookupErr.Errors, ProviderError{Provider: res.provider, Err: res.err})
+			if inFlight == 0 {
+				return zero, lookupErr // Both failed
+			}
+		case <-timer.C:
+			hedged = true
+			inFlight++
+			launch(p.backup())
+		case <-ctx.Done():
+			return zero, ctx.Err() // The caller gave up
+		}
+	}
+}
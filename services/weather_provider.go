@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"time"
+)
+
+// DefaultWeatherProvider is the weather provider used when no configuration is given.
+// DefaultWeatherProvider é o provedor de clima usado quando nenhuma configuração é fornecida.
+const DefaultWeatherProvider = "weatherapi"
+
+// WeatherProviderNames lists the built-in weather providers.
+// WeatherProviderNames lista os provedores de clima embutidos.
+var WeatherProviderNames = []string{"weatherapi", "openmeteo", "openweathermap"}
+
+// WeatherProvider is a source of weather data, answering in the provider-neutral model.
+// WeatherProvider é uma fonte de dados de clima, respondendo no modelo neutro em relação ao provedor.
+type WeatherProvider interface {
+	Name() string                                                                                         // Unique name used by the configuration.
+	CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error)          // Fetch the current conditions.
+	Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error)            // Fetch a forecast of the given number of days.
+	History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) // Fetch the observed weather from start to end.
+}
+
+// WeatherProviderConfig holds the credentials and endpoints of the built-in weather providers.
+// Empty base URLs fall back to the public endpoints.
+// WeatherProviderConfig contém as credenciais e os endpoints dos provedores de clima embutidos.
+// URLs base vazias usam os endpoints públicos.
+type WeatherProviderConfig struct {
+	WeatherAPIKey         string // weatherapi.com API key
+	WeatherAPIBaseURL     string // weatherapi.com base URL
+	OpenMeteoBaseURL      string // Open-Meteo forecast API base URL
+	OpenMeteoArchiveURL   string // Open-Meteo historical weather API base URL
+	OpenMeteoGeocodingURL string // Open-Meteo geocoding API base URL
+	OpenWeatherMapKey     string // OpenWeatherMap API key
+	OpenWeatherMapBaseURL string // OpenWeatherMap base URL
+}
+
+// NewWeatherProvider creates the built-in weather provider with the given name.
+// Cria o provedor de clima embutido com o nome informado.
+func NewWeatherProvider(name string, client APIClient, config WeatherProviderConfig) (WeatherProvider, error) {
+	switch name {
+	case "weatherapi":
+		return NewWeatherAPIProvider(client, orDefault(config.WeatherAPIBaseURL, DefaultWeatherAPIBaseURL), config.WeatherAPIKey), nil
+	case "openmeteo":
+		return NewOpenMeteoProvider(client,
+			orDefault(config.OpenMeteoBaseURL, DefaultOpenMeteoBaseURL),
+			orDefault(config.OpenMeteoArchiveURL, DefaultOpenMeteoArchiveURL),
+			orDefault(config.OpenMeteoGeocodingURL, DefaultOpenMeteoGeocodingURL),
+		), nil
+	case "openweathermap":
+		return NewOpenWeatherMapProvider(client, orDefault(config.OpenWeatherMapBaseURL, DefaultOpenWeatherMapBaseURL), config.OpenWeatherMapKey), nil
+	default:
+		return nil, fmt.Errorf("unknown weather provider %q", name) // Reject typos instead of silently falling back
+	}
+}
+
+// orDefault returns value, or fallback when value is empty.
+// Retorna value, ou fallback quando value está vazio.
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// errNoWeatherQuery is returned when a location has neither coordinates nor a city to query.
+// errNoWeatherQuery é retornado quando uma localização não tem coordenadas nem cidade para consultar.
+var errNoWeatherQuery = errors.New("location has no city or coordinates")
+
+// upstreamStatusKind classifies a failed upstream HTTP status into one of the ErrUpstream* kinds.
+// Classifica um status HTTP de falha da origem em um dos tipos ErrUpstream*.
+func upstreamStatusKind(status int) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrUpstreamAuth
+	case status == http.StatusTooManyRequests:
+		return ErrUpstreamQuota
+	case status == http.StatusNotFound:
+		return ErrUpstreamNotFound
+	case status >= http.StatusInternalServerError:
+		return ErrUpstreamUnavailable
+	default:
+		return ErrUpstreamBadRequest
+	}
+}
+
+// compassDirection converts a wind direction in degrees to a 16-point compass direction, e.g. "NNE".
+// Converte uma direção do vento em graus para uma direção da rosa dos ventos de 16 pontos, ex.: "NNE".
+func compassDirection(degree int) string {
+	points := []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+	index := int((float64(((degree%360)+360)%360) + 11.25) / 22.5)
+	return points[index%len(points)]
+}
+
+// localTime converts a Unix timestamp to the location's local time, given its offset from UTC.
+// Converte um timestamp Unix para o horário local da localização, dado seu deslocamento em relação ao UTC.
+func localTime(unix int64, utcOffsetSeconds int) time.Time {
+	return time.Unix(unix, 0).In(time.FixedZone("", utcOffsetSeconds))
+}
+
+// valueAt returns values[i], or the zero value when the provider returned a shorter series.
+// Retorna values[i], ou o valor zero quando o provedor retornou uma série mais curta.
+func valueAt[T any](values []T, i int) T {
+	var zero T
+	if i < 0 || i >= len(values) {
+		return zero
+	}
+	return values[i]
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Strategies for combining several weather providers.
+// Estratégias para combinar vários provedores de clima.
+const (
+	WeatherStrategyFailover  = "failover"  // Ask the providers in order, moving to the next on error or timeout
+	WeatherStrategyRace      = "race"      // Ask every provider at once; the first success wins
+	WeatherStrategyConsensus = "consensus" // Ask every provider and answer the median, flagging outliers
+)
+
+// WeatherStrategyNames lists the available strategies.
+// WeatherStrategyNames lista as estratégias disponíveis.
+var WeatherStrategyNames = []string{WeatherStrategyFailover, WeatherStrategyRace, WeatherStrategyConsensus}
+
+// WeatherStrategyConfig configures how several weather providers are combined.
+// WeatherStrategyConfig configura como vários provedores de clima são combinados.
+type WeatherStrategyConfig struct {
+	AttemptTimeout    time.Duration // Maximum time a single provider is given by failover and consensus, 0 for no limit
+	OutlierThresholdC float64       // Distance from the median temperature beyond which consensus discards a provider
+}
+
+// DefaultWeatherStrategyConfig is used when no strategy configuration is given.
+// DefaultWeatherStrategyConfig é usado quando nenhuma configuração de estratégia é fornecida.
+var DefaultWeatherStrategyConfig = WeatherStrategyConfig{
+	AttemptTimeout:    5 * time.Second,
+	OutlierThresholdC: 3,
+}
+
+// NewWeatherStrategy combines the providers, in priority order, with the named strategy.
+// A single provider needs no strategy and is returned as is.
+// Combina os provedores, em ordem de prioridade, com a estratégia informada.
+// Um único provedor dispensa estratégia e é retornado como está.
+func NewWeatherStrategy(name string, providers []WeatherProvider, config WeatherStrategyConfig) (WeatherProvider, error) {
+	if !slices.Contains(WeatherStrategyNames, name) {
+		return nil, fmt.Errorf("unknown weather strategy %q", name) // Reject typos instead of silently falling back
+	}
+	if len(providers) == 0 {
+		return nil, errors.New("no weather provider configured")
+	}
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	switch name {
+	case WeatherStrategyRace:
+		return &RaceWeatherProvider{Providers: providers}, nil
+	case WeatherStrategyConsensus:
+		return &ConsensusWeatherProvider{Providers: providers, AttemptTimeout: config.AttemptTimeout, OutlierThresholdC: config.OutlierThresholdC}, nil
+	default:
+		return &FailoverWeatherProvider{Providers: providers, AttemptTimeout: config.AttemptTimeout}, nil
+	}
+}
+
+// FailoverWeatherProvider asks its providers in priority order and answers with the first success.
+// FailoverWeatherProvider consulta seus provedores em ordem de prioridade e responde com o primeiro sucesso.
+type FailoverWeatherProvider struct {
+	Providers      []WeatherProvider // Providers in priority order
+	AttemptTimeout time.Duration     // Maximum time given to each provider, 0 for no limit
+}
+
+// Name returns the strategy and its providers, e.g. "failover(weatherapi,openmeteo)".
+// Retorna a estratégia e seus provedores, ex.: "failover(weatherapi,openmeteo)".
+func (p *FailoverWeatherProvider) Name() string {
+	return strategyName(WeatherStrategyFailover, p.Providers)
+}
+
+// CurrentWeather returns the current conditions of the first provider that answers.
+// Retorna as condições atuais do primeiro provedor que responder.
+func (p *FailoverWeatherProvider) CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	return failover(ctx, p.Providers, p.AttemptTimeout, func(ctx context.Context, provider WeatherProvider) (models.CurrentWeather, error) {
+		return provider.CurrentWeather(ctx, location)
+	})
+}
+
+// Forecast returns the forecast of the first provider that answers.
+// Retorna a previsão do primeiro provedor que responder.
+func (p *FailoverWeatherProvider) Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	return failover(ctx, p.Providers, p.AttemptTimeout, func(ctx context.Context, provider WeatherProvider) (models.Forecast, error) {
+		return provider.Forecast(ctx, location, days)
+	})
+}
+
+// History returns the history of the first provider that answers.
+// Retorna o histórico do primeiro provedor que responder.
+func (p *FailoverWeatherProvider) History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	return failover(ctx, p.Providers, p.AttemptTimeout, func(ctx context.Context, provider WeatherProvider) (models.Forecast, error) {
+		return provider.History(ctx, location, start, end)
+	})
+}
+
+// RaceWeatherProvider asks every provider at once and answers with the first success, like
+// the CEP lookup. The slower providers are cancelled.
+// RaceWeatherProvider consulta todos os provedores ao mesmo tempo e responde com o primeiro
+// sucesso, como a busca de CEP. Os provedores mais lentos são cancelados.
+type RaceWeatherProvider struct {
+	Providers []WeatherProvider // Providers raced on each call
+}
+
+// Name returns the strategy and its providers, e.g. "race(weatherapi,openmeteo)".
+// Retorna a estratégia e seus provedores, ex.: "race(weatherapi,openmeteo)".
+func (p *RaceWeatherProvider) Name() string {
+	return strategyName(WeatherStrategyRace, p.Providers)
+}
+
+// CurrentWeather returns the current conditions of the fastest provider.
+// Retorna as condições atuais do provedor mais rápido.
+func (p *RaceWeatherProvider) CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	return race(ctx, p.Providers, func(ctx context.Context, provider WeatherProvider) (models.CurrentWeather, error) {
+		return provider.CurrentWeather(ctx, location)
+	})
+}
+
+// Forecast returns the forecast of the fastest provider.
+// Retorna a previsão do provedor mais rápido.
+func (p *RaceWeatherProvider) Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	return race(ctx, p.Providers, func(ctx context.Context, provider WeatherProvider) (models.Forecast, error) {
+		return provider.Forecast(ctx, location, days)
+	})
+}
+
+// History returns the history of the fastest provider.
+// Retorna o histórico do provedor mais rápido.
+func (p *RaceWeatherProvider) History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	return race(ctx, p.Providers, func(ctx context.Context, provider WeatherProvider) (models.Forecast, error) {
+		return provider.History(ctx, location, start, end)
+	})
+}
+
+// ConsensusWeatherProvider asks every provider and answers with the median temperatures of
+// the ones that agree. A provider further than OutlierThresholdC from the median is flagged
+// as an outlier and left out. When no two providers agree, the one with the highest priority wins.
+// ConsensusWeatherProvider consulta todos os provedores e responde com as temperaturas medianas
+// dos que concordam. Um provedor mais distante que OutlierThresholdC da mediana é marcado como
+// discrepante e deixado de fora. Quando nenhum par concorda, vence o de maior prioridade.
+type ConsensusWeatherProvider struct {
+	Providers         []WeatherProvider // Providers in priority order
+	AttemptTimeout    time.Duration     // Maximum time given to each provider, 0 for no limit
+	OutlierThresholdC float64           // Distance from the median beyond which a provider is an outlier
+}
+
+// Name returns the strategy and its providers, e.g. "consensus(weatherapi,openmeteo)".
+// Retorna a estratégia e seus provedores, ex.: "consensus(weatherapi,openmeteo)".
+func (p *ConsensusWeatherProvider) Name() string {
+	return strategyName(WeatherStrategyConsensus, p.Providers)
+}
+
+// CurrentWeather returns the reading closest to the median, with the median temperatures.
+// Retorna a leitura mais próxima da mediana, com as temperaturas medianas.
+func (p *ConsensusWeatherProvider) CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	readings, names, err := gather(ctx, p.Providers, p.AttemptTimeout, func(ctx context.Context, provider WeatherProvider) (models.CurrentWeather, error) {
+		return provider.CurrentWeather(ctx, location)
+	})
+	if err != nil {
+		return models.CurrentWeather{}, err
+	}
+
+	temperatures := make([]float64, len(readings))
+	for i, reading := range readings {
+		temperatures[i] = reading.TempC
+	}
+	agreeing, outliers := splitOutliers(temperatures, p.OutlierThresholdC)
+
+	weather := readings[closestToMedian(temperatures, agreeing)]
+	weather.TempC = median(pick(agreeing, func(i int) float64 { return readings[i].TempC }))
+	weather.FeelsLikeC = median(pick(agreeing, func(i int) float64 { return readings[i].FeelsLikeC }))
+	weather.Providers = pick(agreeing, func(i int) string { return names[i] })
+	weather.Outliers = pick(outliers, func(i int) string { return names[i] })
+	return weather, nil
+}
+
+// Forecast returns the forecast closest to the median, with the median daily temperatures.
+// Retorna a previsão mais próxima da mediana, com as temperaturas diárias medianas.
+func (p *ConsensusWeatherProvider) Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	return p.forecast(ctx, func(ctx context.Context, provider WeatherProvider) (models.Forecast, error) {
+		return provider.Forecast(ctx, location, days)
+	})
+}
+
+// History returns the history closest to the median, with the median daily temperatures.
+// Retorna o histórico mais próximo da mediana, com as temperaturas diárias medianas.
+func (p *ConsensusWeatherProvider) History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	return p.forecast(ctx, func(ctx context.Context, provider WeatherProvider) (models.Forecast, error) {
+		return provider.History(ctx, location, start, end)
+	})
+}
+
+// forecast compares the providers by the median of their daily average temperatures, then
+// replaces each day of the forecast closest to the median with the medians of the agreeing providers.
+// Compara os provedores pela mediana de suas temperaturas médias diárias e então substitui cada
+// dia da previsão mais próxima da mediana pelas medianas dos provedores que concordam.
+func (p *ConsensusWeatherProvider) forecast(ctx context.Context, call func(context.Context, WeatherProvider) (models.Forecast, error)) (models.Forecast, error) {
+	forecasts, names, err := gather(ctx, p.Providers, p.AttemptTimeout, call)
+	if err != nil {
+		return models.Forecast{}, err
+	}
+
+	typical := make([]float64, len(forecasts))
+	for i, forecast := range forecasts {
+		typical[i] = median(pick(indexes(len(forecast.Days)), func(d int) float64 { return forecast.Days[d].AvgTempC }))
+	}
+	agreeing, outliers := splitOutliers(typical, p.OutlierThresholdC)
+
+	base := forecasts[closestToMedian(typical, agreeing)]
+	result := base
+	result.Days = make([]models.ForecastDay, len(base.Days))
+	for d, day := range base.Days {
+		var minimums, maximums, averages []float64
+		for _, i := range agreeing {
+			for _, other := range forecasts[i].Days {
+				if other.Date == day.Date {
+					minimums = append(minimums, other.MinTempC)
+					maximums = append(maximums, other.MaxTempC)
+					averages = append(averages, other.AvgTempC)
+				}
+			}
+		}
+		day.MinTempC, day.MaxTempC, day.AvgTempC = median(minimums), median(maximums), median(averages)
+		result.Days[d] = day
+	}
+	result.Providers = pick(agreeing, func(i int) string { return names[i] })
+	result.Outliers = pick(outliers, func(i int) string { return names[i] })
+	return result, nil
+}
+
+// failover calls the providers in order until one succeeds, bounding each attempt by timeout.
+// Chama os provedores em ordem até um ter sucesso, limitando cada tentativa por timeout.
+func failover[T any](ctx context.Context, providers []WeatherProvider, timeout time.Duration, call func(context.Context, WeatherProvider) (T, error)) (T, error) {
+	var zero T
+	lookupErr := &WeatherLookupError{}
+	for _, provider := range providers {
+		value, err := attempt(ctx, provider, timeout, call)
+		if err == nil {
+			return value, nil
+		}
+		if ctx.Err() != nil {
+			return zero, ctx.Err() // The caller gave up, there is no point in trying the next one
+		}
+		lookupErr.Errors = append(lookupErr.Errors, ProviderError{Provider: provider.Name(), Err: err})
+	}
+	return zero, lookupErr // Every provider failed
+}
+
+// race calls every provider concurrently and returns the first success, cancelling the others.
+// Chama todos os provedores em paralelo e retorna o primeiro sucesso, cancelando os demais.
+func race[T any](ctx context.Context, providers []WeatherProvider, call func(context.Context, WeatherProvider) (T, error)) (T, error) {
+	var zero T
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel() // Cancel the losers once we return
+
+	// Buffered so providers that lose the race can still deliver their result and exit
+	// Com buffer para que os provedores que perderem a corrida ainda entreguem o resultado e terminem
+	type result struct {
+		provider string
+		value    T
+		err      error
+	}
+	results := make(chan result, len(providers))
+	for _, provider := range providers {
+		go func() {
+			value, err := call(raceCtx, provider)
+			results <- result{provider: provider.Name(), value: value, err: err}
+		}()
+	}
+
+	lookupErr := &WeatherLookupError{}
+	for range providers {
+		select {
+		case res := <-results:
+			if res.err == nil {
+				return res.value, nil // First success wins
+			}
+			lookupErr.Errors = append(lookupErr.Errors, ProviderError{Provider: res.provider, Err: res.err})
+		case <-ctx.Done():
+			return zero, ctx.Err() // The caller gave up
+		}
+	}
+	return zero, lookupErr // Every provider failed
+}
+
+// gather calls every provider concurrently and returns the successes, with the names of the
+// providers that produced them, in priority order. It only fails when no provider succeeded.
+// Chama todos os provedores em paralelo e retorna os sucessos, com os nomes dos provedores que
+// os produziram, em ordem de prioridade. Só falha quando nenhum provedor teve sucesso.
+func gather[T any](ctx context.Context, providers []WeatherProvider, timeout time.Duration, call func(context.Context, WeatherProvider) (T, error)) ([]T, []string, error) {
+	values := make([]T, len(providers))
+	errs := make([]error, len(providers))
+	done := make(chan int, len(providers))
+	for i, provider := range providers {
+		go func() {
+			values[i], errs[i] = attempt(ctx, provider, timeout, call)
+			done <- i
+		}()
+	}
+	for range providers {
+		<-done // Every call is bounded by ctx and timeout
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err // The caller gave up
+	}
+
+	var successes []T
+	var names []string
+	lookupErr := &WeatherLookupError{}
+	for i, provider := range providers {
+		if errs[i] != nil {
+			lookupErr.Errors = append(lookupErr.Errors, ProviderError{Provider: provider.Name(), Err: errs[i]})
+			continue
+		}
+		successes = append(successes, values[i])
+		names = append(names, provider.Name())
+	}
+	if len(successes) == 0 {
+		return nil, nil, lookupErr
+	}
+	return successes, names, nil
+}
+
+// attempt calls a single provider, bounded by timeout when it is positive.
+// Chama um único provedor, limitado por timeout quando positivo.
+func attempt[T any](ctx context.Context, provider WeatherProvider, timeout time.Duration, call func(context.Context, WeatherProvider) (T, error)) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return call(ctx, provider)
+}
+
+// splitOutliers returns the indexes of the values within threshold of their median, and of
+// the others. When none are within threshold, the first value (highest priority) is kept.
+// Retorna os índices dos valores a até threshold da mediana e os dos demais. Quando nenhum
+// está dentro do limite, o primeiro valor (maior prioridade) é mantido.
+func splitOutliers(values []float64, threshold float64) (agreeing, outliers []int) {
+	middle := median(values)
+	for i, value := range values {
+		if math.Abs(value-middle) <= threshold {
+			agreeing = append(agreeing, i)
+		} else {
+			outliers = append(outliers, i)
+		}
+	}
+	if len(agreeing) == 0 {
+		return []int{0}, outliers[1:]
+	}
+	return agreeing, outliers
+}
+
+// closestToMedian returns which of the candidate indexes holds the value closest to the
+// median of the candidates, preferring the highest priority on ties.
+// Retorna qual dos índices candidatos tem o valor mais próximo da mediana dos candidatos,
+// preferindo a maior prioridade em caso de empate.
+func closestToMedian(values []float64, candidates []int) int {
+	middle := median(pick(candidates, func(i int) float64 { return values[i] }))
+	best := candidates[0]
+	for _, i := range candidates[1:] {
+		if math.Abs(values[i]-middle) < math.Abs(values[best]-middle) {
+			best = i
+		}
+	}
+	return best
+}
+
+// median returns the median of the values, or 0 when there are none.
+// Retorna a mediana dos valores, ou 0 quando não há nenhum.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// pick maps each index to a value.
+// Mapeia cada índice para um valor.
+func pick[T any](indexes []int, value func(int) T) []T {
+	values := make([]T, 0, len(indexes))
+	for _, i := range indexes {
+		values = append(values, value(i))
+	}
+	return values
+}
+
+// indexes returns 0, 1, ..., n-1.
+// Retorna 0, 1, ..., n-1.
+func indexes(n int) []int {
+	all := make([]int, n)
+	for i := range all {
+		all[i] = i
+	}
+	return all
+}
+
+// strategyName formats a strategy and its providers, e.g. "race(weatherapi,openmeteo)".
+// Formata uma estratégia e seus provedores, ex.: "race(weatherapi,openmeteo)".
+func strategyName(strategy string, providers []WeatherProvider) string {
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		names = append(names, provider.Name())
+	}
+	return fmt.Sprintf("%s(%s)", strategy, strings.Join(names, ","))
+}
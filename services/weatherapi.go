@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"strconv"
+	"time"
+)
+
+// DefaultWeatherAPIBaseURL is the public weatherapi.com endpoint.
+// DefaultWeatherAPIBaseURL é o endpoint público da weatherapi.com.
+const DefaultWeatherAPIBaseURL = "https://api.weatherapi.com/v1"
+
+// WeatherAPIProvider fetches weather data from weatherapi.com.
+// WeatherAPIProvider busca dados de clima na weatherapi.com.
+type WeatherAPIProvider struct {
+	Client  APIClient // The API client used for making requests.
+	BaseURL string    // Base URL of the API, replaceable in tests
+	APIKey  string    // weatherapi.com API key
+}
+
+// NewWeatherAPIProvider creates and returns a new WeatherAPIProvider.
+// Cria e retorna um novo WeatherAPIProvider.
+func NewWeatherAPIProvider(client APIClient, baseURL, apiKey string) *WeatherAPIProvider {
+	return &WeatherAPIProvider{Client: client, BaseURL: baseURL, APIKey: apiKey}
+}
+
+// Name returns the provider name.
+// Retorna o nome do provedor.
+func (p *WeatherAPIProvider) Name() string {
+	return "weatherapi"
+}
+
+// CurrentWeather retrieves the current weather for a resolved location, by coordinates
+// when they are known and by "city,UF,Brazil" otherwise.
+// Recupera o clima atual para uma localização resolvida, por coordenadas quando conhecidas
+// e por "cidade,UF,Brazil" caso contrário.
+func (p *WeatherAPIProvider) CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	fetchedAt := time.Now()
+	var response models.WeatherResponse
+	if err := p.get(ctx, "current.json", location, nil, &response); err != nil {
+		return models.CurrentWeather{}, err
+	}
+
+	current := response.Current
+	weather := models.CurrentWeather{
+		Providers:  []string{p.Name()},
+		TempC:      current.TempC,
+		FeelsLikeC: current.FeelsLikeC,
+		Condition:  models.WeatherCondition(current.Condition),
+		Humidity:   current.Humidity,
+		Cloud:      current.Cloud,
+		UV:         current.UV,
+		IsDay:      current.IsDay == 1,
+		WindKph:    current.WindKph,
+		GustKph:    current.GustKph,
+		WindDegree: current.WindDegree,
+		WindDir:    current.WindDir,
+		PressureMb: current.PressureMb,
+		PrecipMm:   current.PrecipMm,
+		VisKm:      current.VisKm,
+		FetchedAt:  fetchedAt,
+	}
+	if current.LastUpdatedEpoch != 0 {
+		weather.LastUpdated = time.Unix(current.LastUpdatedEpoch, 0)
+	}
+	return weather, nil
+}
+
+// Forecast retrieves a daily and hourly forecast of the given number of days (up to 14).
+// Recupera uma previsão diária e horária com o número de dias informado (até 14).
+func (p *WeatherAPIProvider) Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	params := url.Values{"days": {strconv.Itoa(days)}}
+	return p.forecast(ctx, "forecast.json", location, params)
+}
+
+// History retrieves the observed weather for each day from start to end, inclusive.
+// Recupera o clima observado em cada dia de start até end, inclusive.
+func (p *WeatherAPIProvider) History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	params := url.Values{"dt": {start.Format(time.DateOnly)}}
+	if end.After(start) {
+		params.Set("end_dt", end.Format(time.DateOnly)) // Only sent for date ranges
+	}
+	return p.forecast(ctx, "history.json", location, params)
+}
+
+// forecast calls a forecast-shaped endpoint and converts its days into the neutral model.
+// Chama um endpoint no formato de previsão e converte seus dias para o modelo neutro.
+func (p *WeatherAPIProvider) forecast(ctx context.Context, endpoint string, location models.Location, params url.Values) (models.Forecast, error) {
+	fetchedAt := time.Now()
+	var response models.WeatherForecastResponse
+	if err := p.get(ctx, endpoint, location, params, &response); err != nil {
+		return models.Forecast{}, err
+	}
+
+	forecast := models.Forecast{Providers: []string{p.Name()}, FetchedAt: fetchedAt}
+	for _, forecastDay := range response.Forecast.ForecastDay {
+		day := models.ForecastDay{
+			Date:         forecastDay.Date,
+			MinTempC:     forecastDay.Day.MinTempC,
+			MaxTempC:     forecastDay.Day.MaxTempC,
+			AvgTempC:     forecastDay.Day.AvgTempC,
+			Condition:    models.WeatherCondition(forecastDay.Day.Condition),
+			ChanceOfRain: forecastDay.Day.DailyChanceOfRain,
+			PrecipMm:     forecastDay.Day.TotalPrecipMm,
+		}
+		for _, hour := range forecastDay.Hour {
+			day.Hours = append(day.Hours, models.ForecastHour{
+				Time:         hour.Time,
+				TempC:        hour.TempC,
+				Condition:    models.WeatherCondition(hour.Condition),
+				ChanceOfRain: hour.ChanceOfRain,
+			})
+		}
+		forecast.Days = append(forecast.Days, day)
+	}
+	return forecast, nil
+}
+
+// get calls a weatherapi.com endpoint for a location and decodes a successful response into target.
+// Chama um endpoint da weatherapi.com para uma localização e decodifica uma resposta de sucesso em target.
+func (p *WeatherAPIProvider) get(ctx context.Context, endpoint string, location models.Location, params url.Values, target any) error {
+	query, err := weatherQuery(location)
+	if err != nil {
+		return err // Nothing to query
+	}
+
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("key", p.APIKey) // API key of the account
+	params.Set("q", query)      // Encoded along with the other parameters, fixing spaces on names
+	url := fmt.Sprintf("%s/%s?%s", p.BaseURL, endpoint, params.Encode())
+
+	resp, err := p.Client.Get(ctx, url) // Send GET request to the weather API
+	if err != nil {
+		return err // Return error if the request fails
+	}
+	defer resp.Body.Close() // Close response body when done
+
+	if resp.StatusCode != http.StatusOK {
+		return weatherAPIError(resp) // Never decode an error payload as weather
+	}
+	return json.NewDecoder(resp.Body).Decode(target) // Return error if the response cannot be decoded
+}
+
+// weatherAPIError builds an *UpstreamError from a failed weatherapi.com response, using the
+// error object of the payload when present (see https://www.weatherapi.com/docs/#intro-error-codes).
+// Monta um *UpstreamError a partir de uma resposta de falha da weatherapi.com, usando o objeto
+// de erro do corpo quando presente (veja https://www.weatherapi.com/docs/#intro-error-codes).
+func weatherAPIError(resp *http.Response) *UpstreamError {
+	var payload models.WeatherAPIErrorResponse
+	json.NewDecoder(resp.Body).Decode(&payload) // Best effort: the body may not be JSON
+
+	upstreamErr := &UpstreamError{
+		Provider:   "weatherapi",
+		StatusCode: resp.StatusCode,
+		Code:       payload.Error.Code,
+		Message:    payload.Error.Message,
+	}
+
+	switch {
+	case payload.Error.Code == 1002 || payload.Error.Code == 2006 || payload.Error.Code == 2008 || payload.Error.Code == 2009:
+		upstreamErr.Kind = ErrUpstreamAuth // Key missing, invalid, disabled or without access
+	case payload.Error.Code == 2007 || resp.StatusCode == http.StatusTooManyRequests:
+		upstreamErr.Kind = ErrUpstreamQuota // Monthly quota exceeded or rate limited
+	case payload.Error.Code == 1006:
+		upstreamErr.Kind = ErrUpstreamNotFound // No matching location found
+	case payload.Error.Code == 9999 || resp.StatusCode >= http.StatusInternalServerError:
+		upstreamErr.Kind = ErrUpstreamUnavailable // Internal application error
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		upstreamErr.Kind = ErrUpstreamAuth
+	default:
+		upstreamErr.Kind = ErrUpstreamBadRequest // Missing or invalid parameters
+	}
+	return upstreamErr
+}
+
+// weatherQuery builds the weather API "q" parameter for a location. Coordinates are
+// unambiguous; otherwise the UF and country disambiguate homonymous cities.
+// Monta o parâmetro "q" da API de clima para uma localização. Coordenadas não são
+// ambíguas; caso contrário, a UF e o país diferenciam cidades homônimas.
+func weatherQuery(location models.Location) (string, error) {
+	if location.Latitude != nil && location.Longitude != nil {
+		return fmt.Sprintf("%.4f,%.4f", *location.Latitude, *location.Longitude), nil
+	}
+	if location.City == nil || *location.City == "" {
+		return "", errNoWeatherQuery
+	}
+	if location.Uf == nil || *location.Uf == "" {
+		return *location.City, nil
+	}
+	return fmt.Sprintf("%s,%s,Brazil", *location.City, *location.Uf), nil
+}
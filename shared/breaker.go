@@ -0,0 +1,187 @@
+package shared
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker.Allow while calls are being rejected.
+// ErrCircuitOpen é retornado por CircuitBreaker.Allow enquanto as chamadas estão sendo rejeitadas.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState is the state of a circuit breaker.
+// BreakerState é o estado de um circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Calls flow and failures are counted
+	BreakerOpen                         // Calls are rejected until the cooldown ends
+	BreakerHalfOpen                     // A few probe calls decide whether to close or reopen
+)
+
+// String returns the state name used in logs and on the admin endpoint.
+// Retorna o nome do estado usado em logs e no endpoint administrativo.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerOutcome is how a call allowed by a circuit breaker ended.
+// BreakerOutcome é como terminou uma chamada permitida por um circuit breaker.
+type BreakerOutcome int
+
+const (
+	BreakerSuccess BreakerOutcome = iota // The upstream answered properly
+	BreakerFailure                       // The upstream failed and counts against the failure rate
+	BreakerIgnored                       // The call says nothing about the upstream, e.g. the caller gave up
+)
+
+// BreakerConfig configures a circuit breaker.
+// BreakerConfig configura um circuit breaker.
+type BreakerConfig struct {
+	FailureRate    float64       // Failure ratio, from 0 to 1, that opens the breaker
+	MinRequests    int           // Calls needed in a window before the failure rate is considered
+	Window         time.Duration // Length of the window over which calls are counted
+	Cooldown       time.Duration // How long the breaker stays open before probing the upstream
+	HalfOpenProbes int           // Concurrent probe calls allowed while half-open
+}
+
+// BreakerSnapshot is a point-in-time view of a circuit breaker.
+// BreakerSnapshot é uma visão instantânea de um circuit breaker.
+type BreakerSnapshot struct {
+	State    BreakerState // Current state
+	Requests int          // Calls counted in the current window
+	Failures int          // Failures counted in the current window
+	OpenedAt time.Time    // When the breaker last opened, zero if never
+}
+
+// CircuitBreaker stops calling a failing upstream for a while. It opens once the failure rate
+// over a window reaches the threshold, rejects calls during the cooldown, then lets a few probe
+// calls through: a successful probe closes it and a failed one opens it again.
+// CircuitBreaker deixa de chamar uma origem com falhas por um tempo. Ele abre quando a taxa de
+// falhas em uma janela atinge o limite, rejeita chamadas durante o resfriamento e então deixa
+// passar algumas chamadas de teste: um teste com sucesso o fecha e uma falha o abre novamente.
+type CircuitBreaker struct {
+	Config BreakerConfig    // Breaker configuration
+	Now    func() time.Time // Clock, replaceable in tests
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time // Start of the current counting window
+	requests    int       // Calls counted in the current window
+	failures    int       // Failures counted in the current window
+	openedAt    time.Time // When the breaker last opened
+	probes      int       // Probe calls in flight while half-open
+}
+
+// NewCircuitBreaker creates a closed circuit breaker with the given configuration.
+// Cria um circuit breaker fechado com a configuração informada.
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	if config.HalfOpenProbes < 1 {
+		config.HalfOpenProbes = 1 // At least one probe, or the breaker would never close again
+	}
+	return &CircuitBreaker{Config: config, Now: time.Now}
+}
+
+// Allow asks permission for a call. It returns ErrCircuitOpen while calls are rejected;
+// otherwise the caller must report how the call ended through the returned function.
+// Pede permissão para uma chamada. Retorna ErrCircuitOpen enquanto as chamadas são rejeitadas;
+// caso contrário, quem chama deve informar como a chamada terminou pela função retornada.
+func (b *CircuitBreaker) Allow() (func(BreakerOutcome), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.Now()
+	if b.state == BreakerOpen {
+		if now.Sub(b.openedAt) < b.Config.Cooldown {
+			return nil, ErrCircuitOpen // Still cooling down
+		}
+		b.state = BreakerHalfOpen // Time to probe the upstream again
+		b.probes = 0
+	}
+
+	if b.state == BreakerHalfOpen {
+		if b.probes >= b.Config.HalfOpenProbes {
+			return nil, ErrCircuitOpen // Enough probes in flight already
+		}
+		b.probes++
+		return b.onProbeDone, nil
+	}
+
+	if b.windowStart.IsZero() || now.Sub(b.windowStart) >= b.Config.Window {
+		b.resetWindow(now) // Start a new counting window
+	}
+	return b.onCallDone, nil
+}
+
+// Snapshot returns the current state and counters.
+// Retorna o estado e os contadores atuais.
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == BreakerOpen && b.Now().Sub(b.openedAt) >= b.Config.Cooldown {
+		state = BreakerHalfOpen // The next call will be a probe
+	}
+	return BreakerSnapshot{State: state, Requests: b.requests, Failures: b.failures, OpenedAt: b.openedAt}
+}
+
+// onCallDone records a call made while closed and opens the breaker past the threshold.
+// Registra uma chamada feita com o breaker fechado e o abre ao passar do limite.
+func (b *CircuitBreaker) onCallDone(outcome BreakerOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if outcome == BreakerIgnored || b.state != BreakerClosed {
+		return // Nothing learned, or another call already changed the state
+	}
+	b.requests++
+	if outcome == BreakerFailure {
+		b.failures++
+	}
+	if b.requests >= b.Config.MinRequests && float64(b.failures) >= b.Config.FailureRate*float64(b.requests) {
+		b.open()
+	}
+}
+
+// onProbeDone records a probe call made while half-open, closing or reopening the breaker.
+// Registra uma chamada de teste feita com o breaker meio aberto, fechando-o ou reabrindo-o.
+func (b *CircuitBreaker) onProbeDone(outcome BreakerOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probes--
+	if b.state != BreakerHalfOpen {
+		return // Another probe already decided
+	}
+	switch outcome {
+	case BreakerSuccess:
+		b.state = BreakerClosed
+		b.resetWindow(b.Now())
+	case BreakerFailure:
+		b.open()
+	}
+}
+
+// open moves the breaker to the open state. Callers must hold the lock.
+// Move o breaker para o estado aberto. Quem chama deve manter o lock.
+func (b *CircuitBreaker) open() {
+	b.state = BreakerOpen
+	b.openedAt = b.Now()
+}
+
+// resetWindow starts a new counting window. Callers must hold the lock.
+// Inicia uma nova janela de contagem. Quem chama deve manter o lock.
+func (b *CircuitBreaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
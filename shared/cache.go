@@ -0,0 +1,90 @@
+package shared
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRUCache is a size-bounded, concurrency-safe least-recently-used cache.
+// LRUCache é um cache LRU (menos recentemente usado) com tamanho limitado e seguro para concorrência.
+type LRUCache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int                 // Maximum number of entries kept
+	order    *list.List          // Entries from most to least recently used
+	items    map[K]*list.Element // Index of the list elements by key
+}
+
+// lruEntry is the value stored in each list element.
+// lruEntry é o valor armazenado em cada elemento da lista.
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// NewLRUCache creates an LRUCache holding at most capacity entries.
+// Cria um LRUCache que armazena no máximo capacity entradas.
+func NewLRUCache[K comparable, V any](capacity int) *LRUCache[K, V] {
+	if capacity < 1 {
+		capacity = 1 // A cache must hold at least one entry
+	}
+	return &LRUCache[K, V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+	}
+}
+
+// Get returns the value stored for key and marks it as recently used.
+// Retorna o valor armazenado para a chave e o marca como usado recentemente.
+func (c *LRUCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry[K, V]).value, true
+}
+
+// Add stores value for key, evicting the least recently used entry when full.
+// Armazena o valor para a chave, removendo a entrada menos usada quando cheio.
+func (c *LRUCache[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		element.Value.(*lruEntry[K, V]).value = value // Replace the existing entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+// Remove deletes the entry for key, if present.
+// Remove a entrada da chave, se existir.
+func (c *LRUCache[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.order.Remove(element)
+		delete(c.items, key)
+	}
+}
+
+// Len returns the number of cached entries.
+// Retorna o número de entradas no cache.
+func (c *LRUCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
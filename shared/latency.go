@@ -0,0 +1,60 @@
+package shared
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// LatencyTracker keeps the most recent latencies of an operation to estimate its percentiles.
+// LatencyTracker guarda as latências mais recentes de uma operação para estimar seus percentis.
+type LatencyTracker struct {
+	MinSamples int // Samples needed before Percentile reports an estimate
+
+	mu      sync.Mutex
+	samples []time.Duration // Ring buffer of the latest latencies
+	next    int             // Position of the next sample in the ring buffer
+	full    bool            // Whether the ring buffer has wrapped around
+}
+
+// NewLatencyTracker creates a tracker that keeps the last size latencies and needs at least
+// minSamples of them before estimating a percentile.
+// Cria um rastreador que guarda as últimas size latências e precisa de ao menos minSamples
+// delas antes de estimar um percentil.
+func NewLatencyTracker(size, minSamples int) *LatencyTracker {
+	return &LatencyTracker{MinSamples: minSamples, samples: make([]time.Duration, size)}
+}
+
+// Observe records a latency, replacing the oldest one when the tracker is full.
+// Registra uma latência, substituindo a mais antiga quando o rastreador está cheio.
+func (t *LatencyTracker) Observe(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.samples[t.next] = latency
+	t.next = (t.next + 1) % len(t.samples)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// Percentile returns the latency below which p percent (0 to 100) of the samples fall, or
+// false while there are fewer than MinSamples samples.
+// Retorna a latência abaixo da qual ficam p por cento (de 0 a 100) das amostras, ou false
+// enquanto houver menos de MinSamples amostras.
+func (t *LatencyTracker) Percentile(p float64) (time.Duration, bool) {
+	t.mu.Lock()
+	count := t.next
+	if t.full {
+		count = len(t.samples)
+	}
+	sorted := slices.Clone(t.samples[:count])
+	t.mu.Unlock()
+
+	if count == 0 || count < t.MinSamples {
+		return 0, false // Not enough samples for a meaningful estimate
+	}
+	slices.Sort(sorted)
+	rank := int(p / 100 * float64(count-1)) // Nearest rank, rounded down
+	return sorted[min(max(rank, 0), count-1)], true
+}
@@ -0,0 +1,155 @@
+package shared
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// LoggingConfig configures the structured logger.
+// LoggingConfig configura o logger estruturado.
+type LoggingConfig struct {
+	Level     slog.Level // Lowest level written
+	Format    string     // "json" for Cloud Logging, "text" for reading in a terminal
+	ProjectID string     // Google Cloud project, links log entries to their trace when set
+}
+
+// NewLogger creates a logger whose entries carry the request ID and trace of their context.
+// In JSON, the level and message use the "severity" and "message" fields Cloud Logging reads.
+// Cria um logger cujas entradas carregam o ID da requisição e o trace do seu contexto. Em JSON,
+// o nível e a mensagem usam os campos "severity" e "message" que o Cloud Logging lê.
+func NewLogger(w io.Writer, config LoggingConfig) *slog.Logger {
+	var handler slog.Handler
+	if config.Format == "text" {
+		handler = slog.NewTextHandler(w, &slog.HandlerOptions{Level: config.Level})
+	} else {
+		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: config.Level, ReplaceAttr: cloudLoggingAttr})
+	}
+	return slog.New(&contextHandler{Handler: handler, projectID: config.ProjectID})
+}
+
+// ParseLogLevel parses "debug", "info", "warn" or "error", case-insensitively.
+// Interpreta "debug", "info", "warn" ou "error", sem diferenciar maiúsculas.
+func ParseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(value))
+	return level, err
+}
+
+// cloudLoggingAttr renames the built-in attributes to the Cloud Logging structured fields.
+// Renomeia os atributos embutidos para os campos estruturados do Cloud Logging.
+func cloudLoggingAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+	switch attr.Key {
+	case slog.LevelKey:
+		return slog.String("severity", severity(attr.Value.Any().(slog.Level)))
+	case slog.MessageKey:
+		attr.Key = "message"
+	}
+	return attr
+}
+
+// severity maps a slog level to the matching Cloud Logging severity.
+// Mapeia um nível do slog para a severidade correspondente do Cloud Logging.
+func severity(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// contextHandler adds the request ID and the trace of the context to every entry.
+// contextHandler adiciona o ID da requisição e o trace do contexto a cada entrada.
+type contextHandler struct {
+	slog.Handler
+	projectID string
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+
+	// Let Cloud Logging group the entries of a request under its trace
+	// Permite que o Cloud Logging agrupe as entradas de uma requisição sob o seu trace
+	if h.projectID != "" {
+		if span := SpanFromContext(ctx); span != nil {
+			record.AddAttrs(
+				slog.String("logging.googleapis.com/trace", "projects/"+h.projectID+"/traces/"+span.SpanContext.TraceID.String()),
+				slog.String("logging.googleapis.com/spanId", span.SpanContext.SpanID.String()),
+			)
+		} else if trace := cloudTraceFromContext(ctx); trace != "" {
+			record.AddAttrs(slog.String("logging.googleapis.com/trace", "projects/"+h.projectID+"/traces/"+trace))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), projectID: h.projectID}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), projectID: h.projectID}
+}
+
+// requestIDKey and cloudTraceKey are the context keys of the request ID and of the trace ID
+// received in X-Cloud-Trace-Context.
+// requestIDKey e cloudTraceKey são as chaves de contexto do ID da requisição e do ID do trace
+// recebido em X-Cloud-Trace-Context.
+type requestIDKey struct{}
+type cloudTraceKey struct{}
+
+// ContextWithRequestID returns a context carrying the request ID.
+// Retorna um contexto que carrega o ID da requisição.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID of ctx, or "".
+// Retorna o ID da requisição de ctx, ou "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithCloudTrace returns a context carrying the trace ID of an X-Cloud-Trace-Context
+// header ("TRACE_ID/SPAN_ID;o=1"), used to link the log entries when no span is recorded.
+// Retorna um contexto que carrega o ID do trace de um cabeçalho X-Cloud-Trace-Context
+// ("TRACE_ID/SPAN_ID;o=1"), usado para ligar as entradas de log quando nenhum span é registrado.
+func ContextWithCloudTrace(ctx context.Context, header string) context.Context {
+	trace, _, _ := strings.Cut(header, "/")
+	if len(trace) != 32 {
+		return ctx // Not a trace ID
+	}
+	if _, err := hex.DecodeString(trace); err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, cloudTraceKey{}, strings.ToLower(trace))
+}
+
+// cloudTraceFromContext returns the trace ID received in X-Cloud-Trace-Context, or "".
+// Retorna o ID do trace recebido em X-Cloud-Trace-Context, ou "".
+func cloudTraceFromContext(ctx context.Context) string {
+	trace, _ := ctx.Value(cloudTraceKey{}).(string)
+	return trace
+}
+
+// NewRequestID returns a random request ID.
+// Retorna um ID de requisição aleatório.
+func NewRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,371 @@
+package shared
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLatencyBuckets are the histogram bucket bounds, in seconds, used for request latencies.
+// DefaultLatencyBuckets são os limites dos buckets, em segundos, usados para latências de requisições.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// MetricsRegistry holds the application metrics and writes them in the Prometheus text
+// exposition format.
+// MetricsRegistry contém as métricas da aplicação e as escreve no formato de texto de
+// exposição do Prometheus.
+type MetricsRegistry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// metric is a metric family that can write itself in the exposition format.
+// metric é uma família de métricas que sabe se escrever no formato de exposição.
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// NewMetricsRegistry creates an empty registry.
+// Cria um registro vazio.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{}
+}
+
+// NewCounter registers a counter with the given label names.
+// Registra um contador com os nomes de rótulos informados.
+func (r *MetricsRegistry) NewCounter(name, help string, labels ...string) *Counter {
+	counter := &Counter{family: newFamily(name, help, "counter", labels)}
+	r.register(counter)
+	return counter
+}
+
+// NewGauge registers a gauge with the given label names.
+// Registra um medidor com os nomes de rótulos informados.
+func (r *MetricsRegistry) NewGauge(name, help string, labels ...string) *Gauge {
+	gauge := &Gauge{family: newFamily(name, help, "gauge", labels)}
+	r.register(gauge)
+	return gauge
+}
+
+// NewGaugeFunc registers a gauge without labels whose value is read from fn on every scrape.
+// Registra um medidor sem rótulos cujo valor é lido de fn a cada coleta.
+func (r *MetricsRegistry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&gaugeFunc{family: newFamily(name, help, "gauge", nil), fn: fn})
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds and label names.
+// Registra um histograma com os limites superiores de bucket e os nomes de rótulos informados.
+func (r *MetricsRegistry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	histogram := &Histogram{family: newFamily(name, help, "histogram", labels), buckets: sortedCopy(buckets)}
+	r.register(histogram)
+	return histogram
+}
+
+// WriteText writes every metric, sorted by name, in the Prometheus text exposition format.
+// Escreve todas as métricas, ordenadas por nome, no formato de texto de exposição do Prometheus.
+func (r *MetricsRegistry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := make([]metric, len(r.metrics))
+	copy(metrics, r.metrics)
+	r.mu.Unlock()
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name() < metrics[j].name() })
+	buffered := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(buffered)
+	}
+	return buffered.Flush()
+}
+
+// register adds a metric, refusing duplicated names like the Prometheus client does.
+// Adiciona uma métrica, recusando nomes duplicados como o cliente do Prometheus faz.
+func (r *MetricsRegistry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.metrics {
+		if existing.name() == m.name() {
+			panic(fmt.Sprintf("metric %q registered twice", m.name()))
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// family holds what every metric type shares: its name, help text, type and labeled series.
+// family contém o que todo tipo de métrica compartilha: nome, ajuda, tipo e séries rotuladas.
+type family struct {
+	metricName string
+	help       string
+	kind       string   // "counter", "gauge" or "histogram"
+	labels     []string // Label names, in order
+}
+
+func newFamily(name, help, kind string, labels []string) family {
+	return family{metricName: name, help: help, kind: kind, labels: labels}
+}
+
+func (f *family) name() string {
+	return f.metricName
+}
+
+// key identifies a series by its label values, checking that all of them were given.
+// Identifica uma série pelos valores dos rótulos, verificando que todos foram informados.
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %q expects %d label values, got %d", f.metricName, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// writeHeader writes the HELP and TYPE lines.
+// Escreve as linhas HELP e TYPE.
+func (f *family) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.metricName, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.metricName, f.kind)
+}
+
+// writeSample writes one sample line, with an optional extra label such as the bucket bound.
+// Escreve uma linha de amostra, com um rótulo extra opcional como o limite do bucket.
+func (f *family) writeSample(w *bufio.Writer, suffix string, values []string, extraName, extraValue string, value float64) {
+	w.WriteString(f.metricName + suffix)
+	if len(values) > 0 || extraName != "" {
+		pairs := make([]string, 0, len(values)+1)
+		for i, value := range values {
+			pairs = append(pairs, f.labels[i]+`="`+escapeLabel(value)+`"`)
+		}
+		if extraName != "" {
+			pairs = append(pairs, extraName+`="`+extraValue+`"`)
+		}
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+// Counter is a metric that only goes up, such as a number of requests.
+// Counter é uma métrica que só aumenta, como um número de requisições.
+type Counter struct {
+	family
+	mu     sync.Mutex
+	series map[string]*valueSeries
+}
+
+// valueSeries is a labeled series holding a single value.
+// valueSeries é uma série rotulada que contém um único valor.
+type valueSeries struct {
+	values []string
+	value  float64
+}
+
+// Inc adds one to the series with the given label values.
+// Soma um à série com os valores de rótulos informados.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds delta, which must not be negative, to the series with the given label values.
+// Soma delta, que não pode ser negativo, à série com os valores de rótulos informados.
+func (c *Counter) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("counter %q cannot decrease", c.metricName))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addToSeries(&c.series, c.key(values), values, delta)
+}
+
+// Value returns the current value of the series with the given label values.
+// Retorna o valor atual da série com os valores de rótulos informados.
+func (c *Counter) Value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if series, ok := c.series[c.key(values)]; ok {
+		return series.value
+	}
+	return 0
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeValueSeries(w, &c.family, c.series)
+}
+
+// Gauge is a metric that goes up and down, such as the number of requests in flight.
+// Gauge é uma métrica que sobe e desce, como o número de requisições em andamento.
+type Gauge struct {
+	family
+	mu     sync.Mutex
+	series map[string]*valueSeries
+}
+
+// Add adds delta, possibly negative, to the series with the given label values.
+// Soma delta, possivelmente negativo, à série com os valores de rótulos informados.
+func (g *Gauge) Add(delta float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	addToSeries(&g.series, g.key(values), values, delta)
+}
+
+// Value returns the current value of the series with the given label values.
+// Retorna o valor atual da série com os valores de rótulos informados.
+func (g *Gauge) Value(values ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if series, ok := g.series[g.key(values)]; ok {
+		return series.value
+	}
+	return 0
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	writeValueSeries(w, &g.family, g.series)
+}
+
+// gaugeFunc is a gauge whose value is read when the metrics are written.
+// gaugeFunc é um medidor cujo valor é lido quando as métricas são escritas.
+type gaugeFunc struct {
+	family
+	fn func() float64
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	g.writeSample(w, "", nil, "", "", g.fn())
+}
+
+// Histogram counts observations, such as latencies, in cumulative buckets.
+// Histogram conta observações, como latências, em buckets cumulativos.
+type Histogram struct {
+	family
+	buckets []float64 // Upper bounds, sorted, without +Inf
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+// histogramSeries is a labeled series of a histogram.
+// histogramSeries é uma série rotulada de um histograma.
+type histogramSeries struct {
+	values []string
+	counts []uint64 // Observations per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+// Observe records a value in the series with the given label values.
+// Registra um valor na série com os valores de rótulos informados.
+func (h *Histogram) Observe(value float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := h.key(values)
+	series, ok := h.series[key]
+	if !ok {
+		if h.series == nil {
+			h.series = make(map[string]*histogramSeries)
+		}
+		series = &histogramSeries{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = series
+	}
+	series.counts[sort.SearchFloat64s(h.buckets, value)]++ // First bucket whose bound is >= value
+	series.sum += value
+	series.count++
+}
+
+// Count returns how many values were observed in the series with the given label values.
+// Retorna quantos valores foram observados na série com os valores de rótulos informados.
+func (h *Histogram) Count(values ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if series, ok := h.series[h.key(values)]; ok {
+		return series.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += series.counts[i]
+			h.writeSample(w, "_bucket", series.values, "le", formatFloat(bound), float64(cumulative))
+		}
+		h.writeSample(w, "_bucket", series.values, "le", "+Inf", float64(series.count))
+		h.writeSample(w, "_sum", series.values, "", "", series.sum)
+		h.writeSample(w, "_count", series.values, "", "", float64(series.count))
+	}
+}
+
+// addToSeries adds delta to the series at key, creating it when needed.
+// Soma delta à série em key, criando-a quando necessário.
+func addToSeries(series *map[string]*valueSeries, key string, values []string, delta float64) {
+	if *series == nil {
+		*series = make(map[string]*valueSeries)
+	}
+	s, ok := (*series)[key]
+	if !ok {
+		s = &valueSeries{values: append([]string(nil), values...)} // The caller may reuse its slice
+		(*series)[key] = s
+	}
+	s.value += delta
+}
+
+// writeValueSeries writes a counter or gauge family with its series sorted by label values.
+// Escreve uma família de contador ou medidor com as séries ordenadas pelos valores dos rótulos.
+func writeValueSeries(w *bufio.Writer, f *family, series map[string]*valueSeries) {
+	f.writeHeader(w)
+	for _, key := range sortedKeys(series) {
+		f.writeSample(w, "", series[key].values, "", "", series[key].value)
+	}
+}
+
+// sortedKeys returns the keys of m in order, so the output is stable between scrapes.
+// Retorna as chaves de m em ordem, para que a saída seja estável entre coletas.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedCopy returns a sorted copy of the bucket bounds.
+// Retorna uma cópia ordenada dos limites dos buckets.
+func sortedCopy(buckets []float64) []float64 {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// escapeLabel escapes a label value as the exposition format requires.
+// Escapa um valor de rótulo como o formato de exposição exige.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatFloat formats a sample value as the exposition format expects.
+// Formata um valor de amostra como o formato de exposição espera.
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,234 @@
+package shared
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRedisTimeout bounds each Redis round trip whose context has no earlier deadline.
+// DefaultRedisTimeout limita cada ida e volta ao Redis cujo contexto não tem prazo anterior.
+const DefaultRedisTimeout = time.Second
+
+// redisMaxIdle is how many idle connections are kept for reuse.
+// redisMaxIdle é quantas conexões ociosas são mantidas para reuso.
+const redisMaxIdle = 8
+
+// RedisError is an error reply sent by the Redis server, e.g. "WRONGTYPE ...".
+// RedisError é uma resposta de erro enviada pelo servidor Redis, por exemplo "WRONGTYPE ...".
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// RedisClient is a minimal Redis client speaking RESP2 over TCP, enough for the counters the
+// instances share. Replies are decoded as string (simple and bulk strings), int64, nil (null
+// replies) or []any (arrays).
+// RedisClient é um cliente Redis mínimo que fala RESP2 sobre TCP, o suficiente para os
+// contadores que as instâncias compartilham. As respostas são decodificadas como string
+// (strings simples e bulk), int64, nil (respostas nulas) ou []any (arrays).
+type RedisClient struct {
+	Addr     string        // host:port of the server
+	Password string        // Sent with AUTH on each new connection, empty for none
+	Timeout  time.Duration // Deadline of a round trip whose context has none
+
+	mu     sync.Mutex
+	idle   []*redisConn // Connections ready for reuse
+	closed bool         // Set by Close, after which connections are not kept
+}
+
+// redisConn is a connection and the reader of its replies.
+// redisConn é uma conexão e o leitor das suas respostas.
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedisClient creates a client for the server at addr. Connections are opened on demand.
+// Cria um cliente para o servidor em addr. As conexões são abertas sob demanda.
+func NewRedisClient(addr, password string) *RedisClient {
+	return &RedisClient{Addr: addr, Password: password, Timeout: DefaultRedisTimeout}
+}
+
+// Do sends one command and returns its reply.
+// Envia um comando e retorna a sua resposta.
+func (c *RedisClient) Do(ctx context.Context, args ...string) (any, error) {
+	replies, err := c.Pipeline(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// Pipeline sends the commands in a single round trip and returns their replies in order. An
+// error reply to any of them is returned as a RedisError, after every reply has been read.
+// Envia os comandos em uma única ida e volta e retorna as suas respostas em ordem. Uma
+// resposta de erro a qualquer um deles é retornada como RedisError, depois de todas as
+// respostas serem lidas.
+func (c *RedisClient) Pipeline(ctx context.Context, commands ...[]string) ([]any, error) {
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := c.roundTrip(ctx, conn, commands)
+	var redisErr RedisError
+	if err != nil && !errors.As(err, &redisErr) {
+		conn.Close() // The stream may be out of sync, never reuse it
+		return nil, err
+	}
+	c.release(conn)
+	return replies, err
+}
+
+// Close closes the idle connections. Connections in use are closed when released.
+// Fecha as conexões ociosas. As conexões em uso são fechadas quando liberadas.
+func (c *RedisClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for _, conn := range c.idle {
+		conn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+// conn returns an idle connection or dials a new one, authenticating it.
+// Retorna uma conexão ociosa ou abre uma nova, autenticando-a.
+func (c *RedisClient) conn(ctx context.Context) (*redisConn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+
+	dialer := net.Dialer{Timeout: c.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+	if c.Password != "" {
+		if _, err := c.roundTrip(ctx, conn, [][]string{{"AUTH", c.Password}}); err != nil {
+			conn.Close()
+			return nil, err // Never echo the password: the reply does not carry it
+		}
+	}
+	return conn, nil
+}
+
+// release keeps the connection for reuse, unless there are enough idle ones.
+// Guarda a conexão para reuso, a menos que já existam conexões ociosas suficientes.
+func (c *RedisClient) release(conn *redisConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= redisMaxIdle {
+		conn.Close()
+		return
+	}
+	c.idle = append(c.idle, conn)
+}
+
+// roundTrip writes the commands and reads one reply per command, within the deadline of ctx
+// or Timeout, whichever comes first. Canceling ctx interrupts the round trip.
+// Escreve os comandos e lê uma resposta por comando, dentro do prazo de ctx ou de Timeout, o
+// que vier primeiro. Cancelar ctx interrompe a ida e volta.
+func (c *RedisClient) roundTrip(ctx context.Context, conn *redisConn, commands [][]string) ([]any, error) {
+	deadline := time.Now().Add(c.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Unix(1, 0)) }) // Unblocks the I/O
+	defer stop()
+
+	writer := bufio.NewWriter(conn)
+	for _, args := range commands {
+		fmt.Fprintf(writer, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	replies := make([]any, len(commands))
+	var replyErr error
+	for i := range replies {
+		reply, err := readRedisReply(conn.reader)
+		if err != nil {
+			return nil, contextError(ctx, err)
+		}
+		if redisErr, ok := reply.(RedisError); ok && replyErr == nil {
+			replyErr = redisErr
+		}
+		replies[i] = reply
+	}
+	return replies, replyErr
+}
+
+// contextError prefers the error of ctx, which explains an interrupted round trip.
+// Prefere o erro de ctx, que explica uma ida e volta interrompida.
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// readRedisReply decodes one RESP2 reply. Error replies are returned as a RedisError value.
+// Decodifica uma resposta RESP2. Respostas de erro são retornadas como um valor RedisError.
+func readRedisReply(reader *bufio.Reader) (any, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return RedisError(body), nil
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(body)
+		if err != nil || size < 0 {
+			return nil, err // Size -1 is a null reply
+		}
+		data := make([]byte, size+2) // With the trailing \r\n
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		size, err := strconv.Atoi(body)
+		if err != nil || size < 0 {
+			return nil, err // Size -1 is a null reply
+		}
+		items := make([]any, size)
+		for i := range items {
+			if items[i], err = readRedisReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
@@ -0,0 +1,75 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ServerConfig configures the HTTP server timeouts and its shutdown.
+// ServerConfig configura os timeouts do servidor HTTP e o seu desligamento.
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration // Maximum time to read the request headers
+	ReadTimeout       time.Duration // Maximum time to read the whole request
+	WriteTimeout      time.Duration // Maximum time from the end of the headers to the end of the response
+	IdleTimeout       time.Duration // How long an idle keep-alive connection is kept open
+	ShutdownTimeout   time.Duration // How long in-flight requests may take to finish on shutdown
+}
+
+// DefaultServerConfig is used when no server configuration is given. The write timeout leaves
+// room for a CEP lookup followed by a weather call with retries, and the shutdown timeout fits
+// in the 10 seconds Cloud Run waits between SIGTERM and SIGKILL.
+// DefaultServerConfig é usado quando nenhuma configuração de servidor é fornecida. O timeout de
+// escrita deixa espaço para uma busca de CEP seguida de uma chamada de clima com novas tentativas,
+// e o timeout de desligamento cabe nos 10 segundos que o Cloud Run espera entre SIGTERM e SIGKILL.
+var DefaultServerConfig = ServerConfig{
+	ReadHeaderTimeout: 5 * time.Second,
+	ReadTimeout:       10 * time.Second,
+	WriteTimeout:      30 * time.Second,
+	IdleTimeout:       2 * time.Minute,
+	ShutdownTimeout:   8 * time.Second,
+}
+
+// NewServer creates an HTTP server for handler with the given timeouts.
+// Cria um servidor HTTP para handler com os timeouts informados.
+func NewServer(handler http.Handler, config ServerConfig) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+}
+
+// Serve runs server on listener until ctx is done, then stops accepting connections and waits
+// up to shutdownTimeout for the in-flight requests to finish. Connections still busy after
+// that are closed and context.DeadlineExceeded is returned.
+// Executa server em listener até ctx terminar, então para de aceitar conexões e espera até
+// shutdownTimeout para que as requisições em andamento terminem. Conexões ainda ocupadas depois
+// disso são fechadas e context.DeadlineExceeded é retornado.
+func Serve(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err // The server failed before being asked to stop
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close() // Give up on the requests that did not finish in time
+		return err
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package shared
+
+import (
+	"context"
+	"sync"
+)
+
+// SingleFlight coalesces concurrent calls that share a key into a single execution.
+// SingleFlight agrupa chamadas concorrentes com a mesma chave em uma única execução.
+type SingleFlight[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flightCall[V] // Calls in progress indexed by key
+}
+
+// flightCall is an in-progress call and the callers waiting for it.
+// flightCall é uma chamada em andamento e quem está esperando por ela.
+type flightCall[V any] struct {
+	done    chan struct{}      // Closed when the call finishes
+	value   V                  // Result, valid after done is closed
+	err     error              // Error, valid after done is closed
+	waiters int                // Callers still waiting for the result
+	cancel  context.CancelFunc // Cancels the call once every waiter has given up
+}
+
+// Do runs fn once for all concurrent callers using the same key and hands every one of them
+// the same value and error. The returned bool reports whether the result was shared with an
+// earlier caller. fn receives a context detached from each individual caller (keeping the first
+// caller's values and deadline) that is only cancelled when every waiter has given up.
+// Executa fn uma única vez para todos os chamadores concorrentes com a mesma chave e entrega a
+// todos o mesmo valor e erro. O bool retornado informa se o resultado foi compartilhado com um
+// chamador anterior. fn recebe um contexto desvinculado de cada chamador (mantendo os valores e
+// o prazo do primeiro) que só é cancelado quando todos desistem de esperar.
+func (g *SingleFlight[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, error, bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*flightCall[V])
+	}
+
+	call, shared := g.calls[key]
+	if !shared {
+		call = g.start(ctx, key, fn) // First caller starts the execution
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err, shared
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel() // Nobody is waiting anymore, so stop the execution
+			if g.calls[key] == call {
+				delete(g.calls, key) // Let the next caller start a fresh execution
+			}
+		}
+		g.mu.Unlock()
+
+		var zero V
+		return zero, ctx.Err(), shared
+	}
+}
+
+// start launches fn in background for key. Callers must hold the lock.
+// Inicia fn em segundo plano para a chave. Quem chama deve manter o lock.
+func (g *SingleFlight[K, V]) start(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) *flightCall[V] {
+	// Detach from the caller but never outlive its deadline
+	// Desvincula do chamador, mas nunca ultrapassa o seu prazo
+	detached := context.WithoutCancel(ctx)
+	var callCtx context.Context
+	var cancel context.CancelFunc
+	if deadline, ok := ctx.Deadline(); ok {
+		callCtx, cancel = context.WithDeadline(detached, deadline)
+	} else {
+		callCtx, cancel = context.WithCancel(detached)
+	}
+
+	call := &flightCall[V]{done: make(chan struct{}), cancel: cancel}
+	g.calls[key] = call
+
+	go func() {
+		call.value, call.err = fn(callCtx)
+
+		g.mu.Lock()
+		if g.calls[key] == call {
+			delete(g.calls, key) // Later callers start a new execution
+		}
+		g.mu.Unlock()
+
+		cancel()
+		close(call.done)
+	}()
+	return call
+}
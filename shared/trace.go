@@ -0,0 +1,302 @@
+package shared
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace, shared by every span of a request across services.
+// TraceID identifica um trace, compartilhado por todos os spans de uma requisição entre serviços.
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace.
+// SpanID identifica um span dentro de um trace.
+type SpanID [8]byte
+
+// String returns the trace ID as lowercase hex.
+// Retorna o ID do trace em hexadecimal minúsculo.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// String returns the span ID as lowercase hex.
+// Retorna o ID do span em hexadecimal minúsculo.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext is the part of a span propagated to other services in the traceparent header.
+// SpanContext é a parte de um span propagada para outros serviços no cabeçalho traceparent.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool // Whether the trace is recorded
+}
+
+// IsValid reports whether both IDs are set, as W3C Trace Context requires.
+// Informa se os dois IDs estão definidos, como o W3C Trace Context exige.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent formats the span context as a W3C traceparent header value.
+// Formata o contexto do span como um valor de cabeçalho traceparent do W3C.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header value, rejecting malformed ones.
+// Interpreta um valor de cabeçalho traceparent do W3C, rejeitando os malformados.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false // Unknown layout; later versions may only append fields
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// SpanKind tells whether a span serves a request, makes one or is internal work.
+// SpanKind indica se um span atende uma requisição, faz uma ou é um trabalho interno.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota // Work inside the service
+	SpanKindServer                   // An incoming request
+	SpanKindClient                   // An outgoing request
+)
+
+// Span is a timed operation of a trace. A nil *Span is valid and records nothing, which is
+// what StartSpan returns when tracing is disabled.
+// Span é uma operação cronometrada de um trace. Um *Span nil é válido e não registra nada,
+// que é o que StartSpan retorna quando o tracing está desabilitado.
+type Span struct {
+	Name        string
+	Kind        SpanKind
+	SpanContext SpanContext
+	Parent      SpanID    // Zero for the root span of the trace
+	Start       time.Time // When the operation started
+	tracer      *Tracer
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes map[string]any
+	err        string // Error description, empty when the operation succeeded
+	ended      bool
+}
+
+// SetAttribute records a key/value pair describing the operation.
+// Registra um par chave/valor que descreve a operação.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]any)
+	}
+	s.attributes[key] = value
+}
+
+// SetError marks the operation as failed with err; a nil err is ignored.
+// Marca a operação como falha com err; um err nil é ignorado.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End finishes the span and hands it to the exporter when the trace is sampled. Later calls
+// do nothing.
+// Finaliza o span e o entrega ao exportador quando o trace é amostrado. Chamadas seguintes
+// não fazem nada.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = s.tracer.now()
+	s.mu.Unlock()
+
+	if s.SpanContext.Sampled {
+		s.tracer.Exporter.Export(s.snapshot())
+	}
+}
+
+// snapshot copies the finished span for the exporter.
+// Copia o span finalizado para o exportador.
+func (s *Span) snapshot() SpanData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attributes := make(map[string]any, len(s.attributes))
+	for key, value := range s.attributes {
+		attributes[key] = value
+	}
+	return SpanData{
+		Name:        s.Name,
+		Kind:        s.Kind,
+		SpanContext: s.SpanContext,
+		Parent:      s.Parent,
+		Start:       s.Start,
+		End:         s.end,
+		Attributes:  attributes,
+		Error:       s.err,
+	}
+}
+
+// SpanData is a finished span, as handed to the exporters.
+// SpanData é um span finalizado, como entregue aos exportadores.
+type SpanData struct {
+	Name        string
+	Kind        SpanKind
+	SpanContext SpanContext
+	Parent      SpanID
+	Start       time.Time
+	End         time.Time
+	Attributes  map[string]any
+	Error       string // Empty when the operation succeeded
+}
+
+// SpanExporter sends finished spans somewhere, such as stdout or a collector.
+// SpanExporter envia os spans finalizados para algum lugar, como o stdout ou um coletor.
+type SpanExporter interface {
+	Export(span SpanData)
+}
+
+// Tracer starts spans and hands the finished ones to its exporter. A nil *Tracer disables
+// tracing: it starts no spans, so nothing is recorded anywhere.
+// Tracer inicia spans e entrega os finalizados ao seu exportador. Um *Tracer nil desabilita o
+// tracing: ele não inicia spans, então nada é registrado em lugar nenhum.
+type Tracer struct {
+	Exporter    SpanExporter     // Where finished spans go
+	SampleRatio float64          // Fraction of the new traces recorded; incoming ones keep their decision
+	Now         func() time.Time // Clock, replaceable in tests
+}
+
+// NewTracer creates a tracer that records sampleRatio of the new traces into exporter.
+// Cria um tracer que registra sampleRatio dos novos traces em exporter.
+func NewTracer(exporter SpanExporter, sampleRatio float64) *Tracer {
+	return &Tracer{Exporter: exporter, SampleRatio: sampleRatio, Now: time.Now}
+}
+
+// Start starts a span that is a child of the span or remote parent in ctx, or the root of a
+// new trace, and returns a context carrying it.
+// Inicia um span filho do span ou do pai remoto em ctx, ou a raiz de um novo trace, e retorna
+// um contexto que o carrega.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{Name: name, Kind: kind, Start: t.now(), tracer: t}
+	if parent, ok := parentSpanContext(ctx); ok {
+		span.SpanContext = SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+		span.Parent = parent.SpanID
+	} else {
+		fillRandom(span.SpanContext.TraceID[:])
+		span.SpanContext.Sampled = rand.Float64() < t.SampleRatio
+	}
+	fillRandom(span.SpanContext.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// now reads the tracer clock.
+// Lê o relógio do tracer.
+func (t *Tracer) now() time.Time {
+	if t.Now == nil {
+		return time.Now()
+	}
+	return t.Now()
+}
+
+// spanKey and remoteSpanKey are the context keys of the current span and of the parent
+// received from another service.
+// spanKey e remoteSpanKey são as chaves de contexto do span atual e do pai recebido de outro
+// serviço.
+type spanKey struct{}
+type remoteSpanKey struct{}
+
+// StartSpan starts a child of the span in ctx with the same tracer. Without a span in ctx it
+// returns ctx and a nil span, so code deep in the services can trace without a tracer of its own.
+// Inicia um filho do span em ctx com o mesmo tracer. Sem span em ctx, retorna ctx e um span nil,
+// para que o código dentro dos serviços possa rastrear sem ter um tracer próprio.
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, kind)
+}
+
+// SpanFromContext returns the current span of ctx, or nil.
+// Retorna o span atual de ctx, ou nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent returns a context whose next span continues the trace of another
+// service, as received in a traceparent header.
+// Retorna um contexto cujo próximo span continua o trace de outro serviço, como recebido em
+// um cabeçalho traceparent.
+func ContextWithRemoteParent(ctx context.Context, parent SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanKey{}, parent)
+}
+
+// parentSpanContext returns the span context the next span descends from, if any.
+// Retorna o contexto de span do qual o próximo span descende, se houver.
+func parentSpanContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext, true
+	}
+	parent, ok := ctx.Value(remoteSpanKey{}).(SpanContext)
+	return parent, ok && parent.IsValid()
+}
+
+// fillRandom fills an ID with random bytes, never all zeros since that ID is invalid.
+// Preenche um ID com bytes aleatórios, nunca todos zero, já que esse ID é inválido.
+func fillRandom(id []byte) {
+	for {
+		for i := range id {
+			id[i] = byte(rand.Uint32())
+		}
+		for _, b := range id {
+			if b != 0 {
+				return
+			}
+		}
+	}
+}
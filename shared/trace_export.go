@@ -0,0 +1,299 @@
+package shared
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WriterExporter writes each finished span as one JSON line, e.g. to stdout.
+// WriterExporter escreve cada span finalizado como uma linha JSON, por exemplo no stdout.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter creates an exporter that writes to w.
+// Cria um exportador que escreve em w.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// writtenSpan is the JSON line written by WriterExporter.
+// writtenSpan é a linha JSON escrita pelo WriterExporter.
+type writtenSpan struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_span_id,omitempty"`
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"`
+	Start      time.Time      `json:"start"`
+	DurationMs float64        `json:"duration_ms"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Export writes the span as a JSON line.
+// Escreve o span como uma linha JSON.
+func (e *WriterExporter) Export(span SpanData) {
+	line := writtenSpan{
+		TraceID:    span.SpanContext.TraceID.String(),
+		SpanID:     span.SpanContext.SpanID.String(),
+		Name:       span.Name,
+		Kind:       [...]string{"internal", "server", "client"}[span.Kind],
+		Start:      span.Start,
+		DurationMs: float64(span.End.Sub(span.Start).Microseconds()) / 1000,
+		Attributes: span.Attributes,
+		Error:      span.Error,
+	}
+	if span.Parent != (SpanID{}) {
+		line.ParentID = span.Parent.String()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	json.NewEncoder(e.w).Encode(line) // Encode appends the newline
+}
+
+// OTLPExporter sends spans in batches to an OpenTelemetry collector using OTLP over HTTP with
+// JSON encoding. Spans are queued and sent in background, so requests never wait for the
+// collector; when the queue is full, new spans are dropped.
+// OTLPExporter envia spans em lotes para um coletor OpenTelemetry usando OTLP sobre HTTP com
+// codificação JSON. Os spans são enfileirados e enviados em segundo plano, para que as
+// requisições nunca esperem pelo coletor; quando a fila está cheia, novos spans são descartados.
+type OTLPExporter struct {
+	Endpoint    string       // Collector base URL; spans are posted to Endpoint + "/v1/traces"
+	ServiceName string       // service.name resource attribute
+	Client      *http.Client // Client used to reach the collector
+
+	mu     sync.RWMutex
+	closed bool // Set by Close, after which spans are dropped
+	queue  chan SpanData
+	done   chan struct{}
+}
+
+const (
+	otlpQueueSize     = 2048            // Spans waiting to be sent
+	otlpBatchSize     = 256             // Spans sent per request
+	otlpFlushInterval = 5 * time.Second // Longest time a span waits in the queue
+)
+
+// NewOTLPExporter creates an exporter for the collector at endpoint and starts sending spans.
+// Close must be called to send the spans still queued.
+// Cria um exportador para o coletor em endpoint e começa a enviar spans. Close deve ser
+// chamado para enviar os spans ainda enfileirados.
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	e := &OTLPExporter{
+		Endpoint:    strings.TrimSuffix(endpoint, "/"),
+		ServiceName: serviceName,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		queue:       make(chan SpanData, otlpQueueSize),
+		done:        make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export queues the span, dropping it when the queue is full.
+// Enfileira o span, descartando-o quando a fila está cheia.
+func (e *OTLPExporter) Export(span SpanData) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return
+	}
+	select {
+	case e.queue <- span:
+	default: // Never make a request wait for the collector
+	}
+}
+
+// Close sends the queued spans and stops the exporter. Spans exported afterwards are lost.
+// Envia os spans enfileirados e para o exportador. Spans exportados depois disso são perdidos.
+func (e *OTLPExporter) Close() error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.queue)
+	}
+	e.mu.Unlock()
+	<-e.done
+	return nil
+}
+
+// run batches the queued spans until the queue is closed.
+// Agrupa os spans enfileirados em lotes até a fila ser fechada.
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, otlpBatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			if err := e.send(batch); err != nil {
+				slog.Warn("Error exporting spans", "spans", len(batch), "error", err)
+			}
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case span, ok := <-e.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if batch = append(batch, span); len(batch) == otlpBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send posts a batch of spans to the collector.
+// Envia um lote de spans para o coletor.
+func (e *OTLPExporter) send(batch []SpanData) error {
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.Client.Timeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := e.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body) // Drain so the connection can be reused
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("collector answered %d", response.StatusCode)
+	}
+	return nil
+}
+
+// OTLP JSON payload, following the ExportTraceServiceRequest message.
+// Corpo OTLP em JSON, seguindo a mensagem ExportTraceServiceRequest.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 is a string in the JSON mapping
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 0 unset, 1 ok, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+// request converts a batch of spans into the OTLP payload.
+// Converte um lote de spans no corpo OTLP.
+func (e *OTLPExporter) request(batch []SpanData) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		converted := otlpSpan{
+			TraceID:           span.SpanContext.TraceID.String(),
+			SpanID:            span.SpanContext.SpanID.String(),
+			Name:              span.Name,
+			Kind:              int(span.Kind) + 1, // OTLP numbers kinds from 1, internal first
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+		}
+		if span.Parent != (SpanID{}) {
+			converted.ParentSpanID = span.Parent.String()
+		}
+		if span.Error != "" {
+			converted.Status = otlpStatus{Code: 2, Message: span.Error}
+		}
+		spans = append(spans, converted)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(map[string]any{"service.name": e.ServiceName})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: e.ServiceName}, Spans: spans}},
+	}}}
+}
+
+// otlpAttributes converts span attributes, sorted by key, into OTLP key/value pairs.
+// Converte os atributos do span, ordenados por chave, em pares chave/valor do OTLP.
+func otlpAttributes(attributes map[string]any) []otlpAttribute {
+	converted := make([]otlpAttribute, 0, len(attributes))
+	for key, value := range attributes {
+		var v otlpValue
+		switch typed := value.(type) {
+		case bool:
+			v.BoolValue = &typed
+		case int:
+			s := strconv.Itoa(typed)
+			v.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(typed, 10)
+			v.IntValue = &s
+		case float64:
+			v.DoubleValue = &typed
+		default:
+			s := fmt.Sprint(typed)
+			v.StringValue = &s
+		}
+		converted = append(converted, otlpAttribute{Key: key, Value: v})
+	}
+	sort.Slice(converted, func(i, j int) bool { return converted[i].Key < converted[j].Key })
+	return converted
+}
@@ -20,6 +20,10 @@ func (tc *TemperatureConverter) CelsiusToKelvin(c float64) float64 {
 	return c + 273 // Formula to convert Celsius to Kelvin
 }
 
+// DefaultCepPattern is the format a CEP must match: eight digits, without the dash.
+// DefaultCepPattern é o formato que um CEP deve seguir: oito dígitos, sem o hífen.
+const DefaultCepPattern = `^\d{8}$`
+
 // CepValidator validates if a CEP is in the correct format.
 // CepValidator valida se um CEP está no formato correto.
 type CepValidator struct {
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"post-graduation-exercise-cloud-run-weather-api/handlers"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+)
+
+// newTestKeyStore cria um repositório com uma chave de cliente limitada e uma de administrador
+func newTestKeyStore(now *time.Time) *services.APIKeyStore {
+	store := services.NewAPIKeyStore([]services.APIKey{
+		{Client: "mobile", Key: "mobile-key", DailyQuota: 2, MonthlyQuota: 3},
+		{Client: "ops", Key: "ops-key", Admin: true},
+	})
+	store.Now = func() time.Time { return *now }
+	return store
+}
+
+// serveWithKey chama handler com a chave no cabeçalho X-API-Key, quando informada
+func serveWithKey(handler http.HandlerFunc, target, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+// errorCode decodifica o código do corpo de erro
+func errorCode(t *testing.T, rr *httptest.ResponseRecorder) string {
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return response.Code
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	now := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	handler := handlers.RequireAPIKey(newTestKeyStore(&now))(func(w http.ResponseWriter, r *http.Request) {})
+
+	// Sem chave ou com uma chave desconhecida a resposta é 401
+	rr := serveWithKey(handler, "/weather?cep=01001000", "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "api_key_missing", errorCode(t, rr))
+	rr = serveWithKey(handler, "/weather?cep=01001000", "stolen-key")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "api_key_invalid", errorCode(t, rr))
+
+	// A chave também pode vir na query, e as requisições restantes são informadas
+	rr = serveWithKey(handler, "/weather?cep=01001000&api_key=mobile-key", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("X-Quota-Remaining"))
+	rr = serveWithKey(handler, "/weather?cep=01001000", "mobile-key")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "0", rr.Header().Get("X-Quota-Remaining"))
+
+	// A cota diária esgotada responde 429 até a meia-noite UTC
+	rr = serveWithKey(handler, "/weather?cep=01001000", "mobile-key")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "quota_exceeded", errorCode(t, rr))
+	assert.Equal(t, "3600", rr.Header().Get("Retry-After"))
+
+	// No dia seguinte a cota diária volta, e também a mensal, pois o mês virou
+	now = now.Add(2 * time.Hour)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, serveWithKey(handler, "/weather?cep=01001000", "mobile-key").Code)
+	}
+}
+
+func TestRequireAPIKeyMonthlyQuota(t *testing.T) {
+	now := time.Date(2024, 2, 10, 12, 0, 0, 0, time.UTC)
+	store := newTestKeyStore(&now)
+	handler := handlers.RequireAPIKey(store)(func(w http.ResponseWriter, r *http.Request) {})
+
+	// Dois dias de uso esgotam a cota mensal de 3 requisições
+	for day := 0; day < 2; day++ {
+		for i := 0; i < 2; i++ {
+			serveWithKey(handler, "/weather", "mobile-key")
+		}
+		now = now.AddDate(0, 0, 1)
+	}
+	rr := serveWithKey(handler, "/weather", "mobile-key")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "monthly quota of 3 requests exceeded", response.Error)
+
+	// Requisições rejeitadas não são contadas
+	usage, err := store.Usage(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "mobile", usage[0].Client)
+	assert.Equal(t, 3, usage[0].MonthlyUsed)
+	assert.Equal(t, 0, usage[0].DailyUsed)
+}
+
+func TestRequireAdminKey(t *testing.T) {
+	now := time.Now()
+	store := newTestKeyStore(&now)
+	admin := &handlers.AdminHandler{Keys: store}
+	handler := handlers.RequireAdminKey(store)(admin.UsageHandlerFunc())
+
+	// Só a chave de administrador acessa /admin
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(handler, "/admin/usage", "").Code)
+	rr := serveWithKey(handler, "/admin/usage", "mobile-key")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "admin_key_required", errorCode(t, rr))
+
+	store.Consume(context.Background(), "mobile-key")
+	rr = serveWithKey(handler, "/admin/usage", "ops-key")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// O uso é listado por cliente, e as requisições de administrador não contam
+	var response models.UsageResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []models.KeyUsageResponse{
+		{Client: "mobile", DailyUsed: 1, DailyQuota: 2, MonthlyUsed: 1, MonthlyQuota: 3},
+		{Client: "ops", Admin: true},
+	}, response.Keys)
+}
+
+func TestAuthWithoutKeys(t *testing.T) {
+	// Sem chaves configuradas as rotas de clima continuam públicas
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	assert.Equal(t, http.StatusOK, serveWithKey(handlers.RequireAPIKey(nil)(ok), "/weather", "").Code)
+
+	// Mas as de administração falham fechadas, mesmo com uma chave qualquer
+	for _, key := range []string{"", "ops-key"} {
+		rr := serveWithKey(handlers.RequireAdminKey(nil)(ok), "/admin/usage", key)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Equal(t, "admin_key_required", errorCode(t, rr))
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"post-graduation-exercise-cloud-run-weather-api/handlers"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerStates(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	breaker := shared.NewCircuitBreaker(shared.BreakerConfig{FailureRate: 0.5, MinRequests: 4, Window: time.Minute, Cooldown: 30 * time.Second})
+	breaker.Now = clock.Now
+
+	// Abaixo do mínimo de chamadas o breaker continua fechado, e chamadas ignoradas não contam
+	for _, outcome := range []shared.BreakerOutcome{shared.BreakerFailure, shared.BreakerSuccess, shared.BreakerFailure, shared.BreakerIgnored} {
+		done, err := breaker.Allow()
+		assert.NoError(t, err)
+		done(outcome)
+	}
+	assert.Equal(t, shared.BreakerClosed, breaker.Snapshot().State)
+	assert.Equal(t, 3, breaker.Snapshot().Requests)
+
+	// A quarta chamada atinge o mínimo com 50% de falhas e abre o breaker
+	done, _ := breaker.Allow()
+	done(shared.BreakerSuccess)
+	assert.Equal(t, shared.BreakerOpen, breaker.Snapshot().State)
+	_, err := breaker.Allow()
+	assert.ErrorIs(t, err, shared.ErrCircuitOpen)
+
+	// Após o resfriamento, uma única chamada de teste passa; a falha reabre o breaker
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, shared.BreakerHalfOpen, breaker.Snapshot().State)
+	probe, err := breaker.Allow()
+	assert.NoError(t, err)
+	_, err = breaker.Allow()
+	assert.ErrorIs(t, err, shared.ErrCircuitOpen)
+	probe(shared.BreakerFailure)
+	assert.Equal(t, shared.BreakerOpen, breaker.Snapshot().State)
+
+	// Um teste com sucesso fecha o breaker e zera a janela
+	clock.Advance(30 * time.Second)
+	probe, _ = breaker.Allow()
+	probe(shared.BreakerSuccess)
+	snapshot := breaker.Snapshot()
+	assert.Equal(t, shared.BreakerClosed, snapshot.State)
+	assert.Zero(t, snapshot.Requests)
+	assert.Equal(t, clock.Now().Add(-30*time.Second), snapshot.OpenedAt)
+}
+
+func TestBreakerClientSkipsFailingHost(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := services.NewBreakerClient(services.NewAPIClient(server.Client()),
+		shared.BreakerConfig{FailureRate: 0.5, MinRequests: 2, Window: time.Minute, Cooldown: time.Minute})
+
+	// Uma chamada cancelada por quem chama não conta como falha do host
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.Get(cancelled, server.URL)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	for range 2 {
+		resp, err := client.Get(context.Background(), server.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// Com o breaker aberto a chamada falha na hora, sem chegar ao host
+	_, err = client.Get(context.Background(), server.URL)
+	var openErr *services.CircuitOpenError
+	assert.True(t, errors.As(err, &openErr))
+	assert.ErrorIs(t, err, services.ErrUpstreamUnavailable)
+	assert.Equal(t, int32(2), calls.Load())
+
+	snapshot := client.Snapshot()[openErr.Host]
+	assert.Equal(t, shared.BreakerOpen, snapshot.State)
+	assert.Equal(t, 2, snapshot.Failures)
+}
+
+func TestBreakersHandler(t *testing.T) {
+	server := newFakeUpstream(t, map[string]string{"/ok": `{}`}, nil)
+	client := services.NewBreakerClient(services.NewAPIClient(server.Client()), services.DefaultBreakerConfig)
+	resp, err := client.Get(context.Background(), server.URL+"/ok")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	rr := httptest.NewRecorder()
+	handlers.NewAdminHandler(client).BreakersHandlerFunc().ServeHTTP(rr, httptest.NewRequest("GET", "/admin/breakers", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{"breakers": [{"host": %q, "state": "closed", "requests": 1, "failures": 0}]}`, server.Listener.Addr().String()), rr.Body.String())
+
+	// Com os breakers desabilitados a lista fica vazia
+	rr = httptest.NewRecorder()
+	handlers.NewAdminHandler(nil).BreakersHandlerFunc().ServeHTTP(rr, httptest.NewRequest("GET", "/admin/breakers", nil))
+	assert.JSONEq(t, `{"breakers": []}`, rr.Body.String())
+}
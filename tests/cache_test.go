@@ -0,0 +1,242 @@
+package tests
+
+import (
+	"context"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeClock permite avançar o tempo nos testes de cache
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	cache := shared.NewLRUCache[string, int](2)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+
+	// "a" passa a ser o mais recente, então "b" é removido
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	cache.Add("c", 3)
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, cache.Len())
+
+	cache.Remove("a")
+	assert.Equal(t, 1, cache.Len())
+}
+
+func newTestLocationCache(next services.LocationService, clock *fakeClock) *services.CachedLocationService {
+	cached := services.NewCachedLocationService(next, services.LocationCacheConfig{
+		TTL:         time.Hour,
+		StaleTTL:    time.Hour,
+		NegativeTTL: time.Minute,
+		Size:        10,
+	})
+	cached.Now = clock.Now
+	return cached
+}
+
+func TestCachedLocationServiceHit(t *testing.T) {
+	cep := "12345678"
+	city := "São Paulo"
+	location := models.Location{Cep: &cep, City: &city, Localidade: &city}
+
+	mockLocationService := new(MockLocationService)
+	mockLocationService.On("GetLocationFromCEP", cep).Return(location, nil).Once()
+	cached := newTestLocationCache(mockLocationService, &fakeClock{now: time.Now()})
+
+	// A segunda chamada deve vir do cache
+	for i := 0; i < 2; i++ {
+		resultado, err := cached.GetLocationFromCEP(context.Background(), cep)
+		assert.NoError(t, err)
+		assert.Equal(t, location, resultado)
+	}
+	mockLocationService.AssertNumberOfCalls(t, "GetLocationFromCEP", 1)
+}
+
+func TestCachedLocationServiceStaleWhileRevalidate(t *testing.T) {
+	cep := "12345678"
+	oldCity := "Sampa"
+	newCity := "São Paulo"
+	oldLocation := models.Location{Cep: &cep, City: &oldCity, Localidade: &oldCity}
+	newLocation := models.Location{Cep: &cep, City: &newCity, Localidade: &newCity}
+
+	clock := &fakeClock{now: time.Now()}
+	mockLocationService := new(MockLocationService)
+	mockLocationService.On("GetLocationFromCEP", cep).Return(oldLocation, nil).Once()
+	mockLocationService.On("GetLocationFromCEP", cep).Return(newLocation, nil).Once()
+	cached := newTestLocationCache(mockLocationService, clock)
+
+	_, err := cached.GetLocationFromCEP(context.Background(), cep)
+	assert.NoError(t, err)
+
+	// Depois do TTL, a entrada antiga é servida enquanto a atualização roda em segundo plano
+	clock.Advance(90 * time.Minute)
+	resultado, err := cached.GetLocationFromCEP(context.Background(), cep)
+	assert.NoError(t, err)
+	assert.Equal(t, oldLocation, resultado)
+
+	assert.Eventually(t, func() bool {
+		resultado, _ := cached.GetLocationFromCEP(context.Background(), cep)
+		return *resultado.City == newCity
+	}, time.Second, 10*time.Millisecond)
+	mockLocationService.AssertNumberOfCalls(t, "GetLocationFromCEP", 2)
+}
+
+// refreshBlockingLocationService responde a primeira busca e bloqueia as seguintes até o cancelamento
+type refreshBlockingLocationService struct {
+	location  models.Location
+	calls     atomic.Int32
+	cancelled chan struct{}
+}
+
+func (s *refreshBlockingLocationService) GetLocationFromCEP(ctx context.Context, cep string) (models.Location, error) {
+	if s.calls.Add(1) == 1 {
+		return s.location, nil
+	}
+	<-ctx.Done()
+	close(s.cancelled)
+	return models.Location{}, ctx.Err()
+}
+
+func TestCachedLocationServiceCloseCancelsRefresh(t *testing.T) {
+	cep := "12345678"
+	city := "São Paulo"
+	clock := &fakeClock{now: time.Now()}
+	next := &refreshBlockingLocationService{location: models.Location{Cep: &cep, City: &city}, cancelled: make(chan struct{})}
+	cached := newTestLocationCache(next, clock)
+
+	_, err := cached.GetLocationFromCEP(context.Background(), cep)
+	assert.NoError(t, err)
+
+	// A entrada expirada dispara uma atualização que fica presa na origem
+	clock.Advance(90 * time.Minute)
+	_, err = cached.GetLocationFromCEP(context.Background(), cep)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return next.calls.Load() == 2 }, time.Second, 10*time.Millisecond)
+
+	// Close cancela a atualização e só retorna depois que ela termina
+	assert.NoError(t, cached.Close())
+	select {
+	case <-next.cancelled:
+	default:
+		t.Fatal("refresh still running after Close")
+	}
+
+	// Depois de fechado, a entrada expirada é servida sem novas atualizações
+	resultado, err := cached.GetLocationFromCEP(context.Background(), cep)
+	assert.NoError(t, err)
+	assert.Equal(t, city, *resultado.City)
+	assert.Equal(t, int32(2), next.calls.Load())
+}
+
+func TestCachedLocationServiceNegativeCaching(t *testing.T) {
+	cep := "99999999"
+	notFound := &services.LocationLookupError{Errors: []services.ProviderError{
+		{Provider: "viacep", Err: services.ErrCEPNotFound},
+	}}
+
+	clock := &fakeClock{now: time.Now()}
+	mockLocationService := new(MockLocationService)
+	mockLocationService.On("GetLocationFromCEP", cep).Return(models.Location{}, notFound)
+	cached := newTestLocationCache(mockLocationService, clock)
+
+	// CEP inexistente é lembrado durante o NegativeTTL
+	for i := 0; i < 2; i++ {
+		_, err := cached.GetLocationFromCEP(context.Background(), cep)
+		assert.ErrorIs(t, err, services.ErrCEPNotFound)
+	}
+	mockLocationService.AssertNumberOfCalls(t, "GetLocationFromCEP", 1)
+
+	// Após o NegativeTTL, os provedores são consultados novamente
+	clock.Advance(2 * time.Minute)
+	_, err := cached.GetLocationFromCEP(context.Background(), cep)
+	assert.Error(t, err)
+	mockLocationService.AssertNumberOfCalls(t, "GetLocationFromCEP", 2)
+}
+
+func newWeatherObservation(tempC float64, fetchedAt, lastUpdated time.Time) models.CurrentWeather {
+	return models.CurrentWeather{TempC: tempC, FetchedAt: fetchedAt, LastUpdated: lastUpdated}
+}
+
+func TestCachedWeatherServiceNormalizedKey(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	city, uf := "São Paulo", "SP"
+	otherSpelling, otherUf := "  são   paulo ", "sp"
+
+	mockWeatherService := new(MockWeatherService)
+	mockWeatherService.On("GetCurrentWeather", mock.Anything).
+		Return(newWeatherObservation(22, clock.Now(), clock.Now()), nil).Once()
+	cached := services.NewCachedWeatherService(mockWeatherService, services.DefaultWeatherCacheConfig)
+	cached.Now = clock.Now
+
+	// Grafias diferentes da mesma cidade compartilham a entrada
+	weather, err := cached.GetCurrentWeather(context.Background(), models.Location{City: &city, Uf: &uf})
+	assert.NoError(t, err)
+	assert.Equal(t, 22.0, weather.TempC)
+	weather, err = cached.GetCurrentWeather(context.Background(), models.Location{City: &otherSpelling, Uf: &otherUf})
+	assert.NoError(t, err)
+	assert.Equal(t, 22.0, weather.TempC)
+	mockWeatherService.AssertNumberOfCalls(t, "GetCurrentWeather", 1)
+}
+
+func TestCachedWeatherServiceRespectsLastUpdated(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	city := "São Paulo"
+	location := models.Location{City: &city}
+
+	// A observação já tem 8 minutos quando é buscada: com TTL de 10 minutos ela expira em 2
+	mockWeatherService := new(MockWeatherService)
+	mockWeatherService.On("GetCurrentWeather", mock.Anything).
+		Return(newWeatherObservation(22, clock.Now(), clock.Now().Add(-8*time.Minute)), nil).Once()
+	mockWeatherService.On("GetCurrentWeather", mock.Anything).
+		Return(newWeatherObservation(25, clock.Now().Add(3*time.Minute), clock.Now().Add(2*time.Minute)), nil).Once()
+	cached := services.NewCachedWeatherService(mockWeatherService, services.WeatherCacheConfig{
+		TTL:    10 * time.Minute,
+		MinTTL: time.Minute,
+		Size:   10,
+	})
+	cached.Now = clock.Now
+
+	_, err := cached.GetCurrentWeather(context.Background(), location)
+	assert.NoError(t, err)
+
+	clock.Advance(90 * time.Second)
+	weather, err := cached.GetCurrentWeather(context.Background(), location)
+	assert.NoError(t, err)
+	assert.Equal(t, 22.0, weather.TempC)
+
+	clock.Advance(90 * time.Second)
+	weather, err = cached.GetCurrentWeather(context.Background(), location)
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0, weather.TempC)
+	mockWeatherService.AssertNumberOfCalls(t, "GetCurrentWeather", 2)
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCoalescedLocationServiceSharesLookup(t *testing.T) {
+	cep := "12345678"
+	city := "São Paulo"
+	location := models.Location{Cep: &cep, City: &city, Localidade: &city}
+
+	// A busca fica bloqueada até todas as requisições chegarem
+	release := make(chan time.Time)
+	mockLocationService := new(MockLocationService)
+	mockLocationService.On("GetLocationFromCEP", cep).Return(location, nil).WaitUntil(release)
+	coalesced := services.NewCoalescedLocationService(mockLocationService)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resultado, err := coalesced.GetLocationFromCEP(context.Background(), cep)
+			assert.NoError(t, err)
+			assert.Equal(t, location, resultado)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	mockLocationService.AssertNumberOfCalls(t, "GetLocationFromCEP", 1)
+}
+
+func TestCoalescedWeatherServiceSharesError(t *testing.T) {
+	city := "São Paulo"
+
+	release := make(chan time.Time)
+	mockWeatherService := new(MockWeatherService)
+	mockWeatherService.On("GetCurrentWeather", mock.Anything).
+		Return(models.CurrentWeather{}, fmt.Errorf("upstream down")).WaitUntil(release)
+	coalesced := services.NewCoalescedWeatherService(mockWeatherService)
+
+	// Todas as requisições recebem o mesmo erro
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := coalesced.GetCurrentWeather(context.Background(), models.Location{City: &city})
+			assert.EqualError(t, err, "upstream down")
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	mockWeatherService.AssertNumberOfCalls(t, "GetCurrentWeather", 1)
+}
+
+func TestSingleFlightWaiterCancellation(t *testing.T) {
+	var group shared.SingleFlight[string, int]
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	// A chamada compartilhada só termina quando cancelada
+	fn := func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+
+	go group.Do(first, "key", fn)
+	<-started
+	done := make(chan error)
+	go func() {
+		_, err, shared := group.Do(second, "key", fn)
+		assert.True(t, shared)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// Um chamador desistir não cancela a chamada dos demais
+	cancelFirst()
+	select {
+	case <-cancelled:
+		t.Fatal("shared call cancelled while a waiter remained")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Quando o último desiste, a chamada é cancelada
+	cancelSecond()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("shared call was not cancelled")
+	}
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"post-graduation-exercise-cloud-run-weather-api/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfigFile grava um arquivo de configuração temporário e retorna o caminho
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfigLoadFileAndEnv(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+port: "9090"
+weather:
+  providers: [openmeteo, weatherapi]
+  strategy: race
+  weatherapi_key: from-file
+  base_urls:
+    openmeteo: http://localhost:8081/v1
+  cache:
+    ttl: 5m
+retry:
+  max_attempts: 2
+`)
+	// O ambiente tem precedência sobre o arquivo
+	t.Setenv("WEATHER_API_KEY", "from-env")
+	t.Setenv("RETRY_BUDGET", "3s")
+
+	cfg, err := config.Load(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "9090", cfg.Port)
+	assert.Equal(t, []string{"openmeteo", "weatherapi"}, cfg.Weather.Providers)
+	assert.Equal(t, "race", cfg.Weather.Strategy)
+	assert.Equal(t, "from-env", cfg.Weather.WeatherAPIKey)
+	assert.Equal(t, "http://localhost:8081/v1", cfg.Weather.BaseURLs.OpenMeteo)
+	assert.Equal(t, 5*time.Minute, cfg.Weather.Cache.TTL)
+	assert.Equal(t, 2, cfg.Retry.MaxAttempts)
+	assert.Equal(t, 3*time.Second, cfg.Retry.Budget)
+
+	// O que não foi configurado mantém o padrão
+	assert.Equal(t, config.Default().CEP, cfg.CEP)
+	assert.Equal(t, config.Default().Weather.BaseURLs.WeatherAPI, cfg.Weather.BaseURLs.WeatherAPI)
+}
+
+func TestConfigLoadJSONFile(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"weather": {"providers": ["openmeteo"], "hedge": {"percentile": 95, "max_delay": "2s"}}}`)
+	t.Setenv("CONFIG_FILE", path)
+
+	// Sem caminho explícito, CONFIG_FILE é usado
+	cfg, err := config.Load("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"openmeteo"}, cfg.Weather.Providers)
+	assert.Equal(t, 95.0, cfg.Weather.Hedge.Percentile)
+	assert.Equal(t, 2*time.Second, cfg.Weather.Hedge.MaxDelay)
+}
+
+func TestConfigValidation(t *testing.T) {
+	// Chaves desconhecidas no arquivo são rejeitadas
+	_, err := config.Load(writeConfigFile(t, "typo.yaml", "weather:\n  stratgy: race\n"))
+	assert.ErrorContains(t, err, "stratgy")
+
+	// Todos os problemas são informados de uma vez
+	t.Setenv("PORT", "http")
+	t.Setenv("SERVER_SHUTDOWN_TIMEOUT", "0s")
+	t.Setenv("WEATHER_PROVIDER", "weatherapi,accuweather")
+	t.Setenv("WEATHER_API_KEY", "")
+	t.Setenv("OPEN_METEO_BASE_URL", "localhost:8081")
+	t.Setenv("VIACEP_BASE_URL", "ftp://mirror.local/ws")
+	t.Setenv("CEP_PATTERN", "^(\\d{8}$")
+	t.Setenv("BREAKER_FAILURE_RATE", "2")
+	t.Setenv("TRACING_EXPORTER", "jaeger")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("AUTH_REDIS_ADDR", "redis.internal")
+	_, err = config.Load("")
+
+	for _, message := range []string{
+		`port: "http" is not a valid TCP port`,
+		"server.shutdown_timeout: must be positive",
+		`unknown weather provider "accuweather"`,
+		"weatherapi_key: required",
+		`weather.base_urls.openmeteo: "localhost:8081" is not an http(s) URL`,
+		`cep.base_urls.viacep: "ftp://mirror.local/ws" is not an http(s) URL`,
+		"cep.pattern",
+		"breaker.failure_rate",
+		`tracing.exporter: unknown exporter "jaeger"`,
+		`logging.level: unknown level "verbose"`,
+		`logging.format: unknown format "xml"`,
+		`auth.redis_addr: "redis.internal" is not a host:port address`,
+	} {
+		assert.ErrorContains(t, err, message)
+	}
+
+	// Valores que não podem ser interpretados apontam a variável
+	t.Setenv("RETRY_MAX_ATTEMPTS", "three")
+	_, err = config.Load("")
+	assert.ErrorContains(t, err, "invalid RETRY_MAX_ATTEMPTS")
+}
+
+func TestConfigLoadAPIKeys(t *testing.T) {
+	keysFile := writeConfigFile(t, "keys.yaml", `
+- client: mobile
+  key: mobile-key
+  daily_quota: 100
+- client: ops
+  key: ops-key
+  admin: true
+`)
+	path := writeConfigFile(t, "config.yaml", "auth:\n  keys_file: "+keysFile+"\n  daily_quota: 1000\n")
+	t.Setenv("WEATHER_API_KEY", "weather-key")
+	t.Setenv("AUTH_API_KEYS", "partner:partner-key")
+	t.Setenv("AUTH_MONTHLY_QUOTA", "20000")
+
+	cfg, err := config.Load(path)
+
+	// As chaves do ambiente e do arquivo de chaves se somam
+	assert.NoError(t, err)
+	assert.Equal(t, []config.APIKeyConfig{
+		{Client: "partner", Key: "partner-key"},
+		{Client: "mobile", Key: "mobile-key", DailyQuota: 100},
+		{Client: "ops", Key: "ops-key", Admin: true},
+	}, cfg.Auth.Keys)
+	assert.Equal(t, 1000, cfg.Auth.DailyQuota)
+	assert.Equal(t, 20000, cfg.Auth.MonthlyQuota)
+
+	// Chaves repetidas ou sem cliente são rejeitadas, e a chave nunca aparece no erro
+	t.Setenv("AUTH_API_KEYS", "mobile:mobile-key,:orphan-key")
+	_, err = config.Load(path)
+	assert.ErrorContains(t, err, "auth.keys[2].key: duplicated")
+	assert.ErrorContains(t, err, "auth.keys[1].client: required")
+	t.Setenv("AUTH_API_KEYS", "no-separator-secret")
+	_, err = config.Load(path)
+	assert.ErrorContains(t, err, "invalid AUTH_API_KEYS")
+	assert.NotContains(t, err.Error(), "no-separator-secret")
+}
+
+func TestConfigAdminDisabledByDefault(t *testing.T) {
+	// Os endpoints de administração expõem os hosts de origem, então só sobem quando habilitados
+	assert.False(t, config.Default().Admin.Enabled)
+
+	t.Setenv("WEATHER_API_KEY", "weather-key")
+	t.Setenv("ADMIN_ENABLED", "true")
+
+	// Habilitados, eles exigem uma chave de administrador, nunca ficando públicos
+	t.Setenv("AUTH_API_KEYS", "mobile:mobile-key")
+	_, err := config.Load("")
+	assert.ErrorContains(t, err, "admin.enabled: requires an auth key with admin: true")
+
+	keysFile := writeConfigFile(t, "keys.yaml", "- client: ops\n  key: ops-key\n  admin: true\n")
+	t.Setenv("AUTH_KEYS_FILE", keysFile)
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	assert.True(t, cfg.Admin.Enabled)
+}
+
+func TestConfigPrintRedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Weather.WeatherAPIKey = "super-secret"
+	cfg.Weather.OpenWeatherMapKey = "another-secret"
+	cfg.Auth.Keys = []config.APIKeyConfig{{Client: "mobile", Key: "mobile-secret"}}
+	cfg.Auth.RedisPassword = "redis-secret"
+
+	var output strings.Builder
+	assert.NoError(t, cfg.Print(&output))
+
+	assert.NotContains(t, output.String(), "secret")
+	assert.Contains(t, output.String(), "weatherapi_key: '[REDACTED]'")
+	assert.Contains(t, output.String(), "lookup_timeout: 10s")
+	assert.Contains(t, output.String(), "client: mobile")
+	assert.Equal(t, "super-secret", cfg.Weather.WeatherAPIKey) // O original não é alterado
+	assert.Equal(t, "mobile-secret", cfg.Auth.Keys[0].Key)
+}
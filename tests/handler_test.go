@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -22,18 +23,16 @@ func TestWeatherHandlerSuccess(t *testing.T) {
 	// Mock API client and services
 	apiKey := os.Getenv("WEATHER_API_KEY")
 	cep := "12345678"
-	chBrasilAPI := make(chan models.Location)
-	chViaCEP := make(chan models.Location)
 	mockApiClient := new(MockApiClient)
-	weatherService := services.NewWeatherService(mockApiClient)
-	locationService := services.NewLocationService(weatherService)
-	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{}, chBrasilAPI, chViaCEP)
+	weatherService := newWeatherAPIService(mockApiClient)
+	locationService := services.NewLocationService(services.NewDefaultCEPProviderRegistry(mockApiClient, services.CEPProviderConfig{}))
+	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{})
 
 	// mock CEP Responses
-	mockApiClient.On("Get", fmt.Sprintf("https://brasilapi.com.br/api/cep/v1/%s", cep)).
+	mockApiClient.On("Get", fmt.Sprintf("https://brasilapi.com.br/api/cep/v2/%s", cep)).
 		Return(&http.Response{
 			StatusCode: 200,
-			Body:       io.NopCloser(bytes.NewReader([]byte(`{"cep": "12345678","state": "SP","city": "São Paulo","neighborhood": "SP","street": "Rua XV de Novembro","service": "ViaCEP"}`))),
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"cep": "12345678","state": "SP","city": "SP","neighborhood": "Centro","street": "Rua XV de Novembro","service": "ViaCEP"}`))),
 		}, nil)
 	mockApiClient.On("Get", fmt.Sprintf("http://viacep.com.br/ws/%s/json", cep)).
 		Return(&http.Response{
@@ -41,7 +40,7 @@ func TestWeatherHandlerSuccess(t *testing.T) {
 			Body:       io.NopCloser(bytes.NewReader([]byte(`{"cep": "12345678","logradouro": "Rua XV de Novembro","complemento": "Apto 101","unidade": "Unidade 2","bairro": "Centro","localidade": "SP","uf": "SP","estado": "São Paulo","regiao": "Sudeste","ibge": "3550308","gia": "1004","ddd": "11","siafi": "1234"}`))),
 		}, nil)
 	// Mock Weather API response
-	mockApiClient.On("Get", fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s", apiKey, "SP")).
+	mockApiClient.On("Get", fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s", apiKey, "SP%2CSP%2CBrazil")).
 		Return(&http.Response{
 			StatusCode: 200,
 			Body:       io.NopCloser(bytes.NewReader([]byte(`{"current": {"temp_c":22.0}}`))),
@@ -66,16 +65,17 @@ func TestWeatherHandlerSuccess(t *testing.T) {
 	// Assert response body
 	expectedResponse := `{"temp_C":22,"temp_F":71.6,"temp_K":295}`
 	assert.JSONEq(t, expectedResponse, rr.Body.String())
+
+	// Assert cache age header
+	assert.Equal(t, "0", rr.Header().Get("Age"))
 }
 
 func TestWeatherHandlerInvalidCepValidator(t *testing.T) {
 	cep := "123456"
-	chBrasilAPI := make(chan models.Location)
-	chViaCEP := make(chan models.Location)
 	mockApiClient := new(MockApiClient)
-	weatherService := services.NewWeatherService(mockApiClient)
-	locationService := services.NewLocationService(weatherService)
-	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{}, chBrasilAPI, chViaCEP)
+	weatherService := newWeatherAPIService(mockApiClient)
+	locationService := services.NewLocationService(services.NewDefaultCEPProviderRegistry(mockApiClient, services.CEPProviderConfig{}))
+	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{})
 
 	// Create a mock HTTP request
 	req, err := http.NewRequest("GET", fmt.Sprintf("/weather?cep=%s", cep), nil)
@@ -100,15 +100,13 @@ func TestWeatherHandlerInvalidCepValidator(t *testing.T) {
 
 func TestWeatherHandlerCepNotFound(t *testing.T) {
 	cep := "12345678"
-	chBrasilAPI := make(chan models.Location)
-	chViaCEP := make(chan models.Location)
 	mockApiClient := new(MockApiClient)
-	weatherService := services.NewWeatherService(mockApiClient)
-	locationService := services.NewLocationService(weatherService)
-	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{}, chBrasilAPI, chViaCEP)
+	weatherService := newWeatherAPIService(mockApiClient)
+	locationService := services.NewLocationService(services.NewDefaultCEPProviderRegistry(mockApiClient, services.CEPProviderConfig{}))
+	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{})
 
 	// mock CEP Responses
-	mockApiClient.On("Get", fmt.Sprintf("https://brasilapi.com.br/api/cep/v1/%s", cep)).
+	mockApiClient.On("Get", fmt.Sprintf("https://brasilapi.com.br/api/cep/v2/%s", cep)).
 		Return(&http.Response{
 			StatusCode: 404,
 			Body:       io.NopCloser(bytes.NewReader([]byte(``))),
@@ -142,18 +140,16 @@ func TestWeatherHandlerCepNotFound(t *testing.T) {
 
 func TestWeatherHandlerInternalServerError(t *testing.T) {
 	cep := "12345678"
-	chBrasilAPI := make(chan models.Location)
-	chViaCEP := make(chan models.Location)
 	mockApiClient := new(MockApiClient)
 	weatherService := new(MockWeatherService)
-	locationService := services.NewLocationService(weatherService)
-	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{}, chBrasilAPI, chViaCEP)
+	locationService := services.NewLocationService(services.NewDefaultCEPProviderRegistry(mockApiClient, services.CEPProviderConfig{}))
+	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{})
 
 	// mock CEP Responses
-	mockApiClient.On("Get", fmt.Sprintf("https://brasilapi.com.br/api/cep/v1/%s", cep)).
+	mockApiClient.On("Get", fmt.Sprintf("https://brasilapi.com.br/api/cep/v2/%s", cep)).
 		Return(&http.Response{
 			StatusCode: 200,
-			Body:       io.NopCloser(bytes.NewReader([]byte(`{"cep": "12345678","state": "SP","city": "São Paulo","neighborhood": "SP","street": "Rua XV de Novembro","service": "ViaCEP"}`))),
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"cep": "12345678","state": "SP","city": "SP","neighborhood": "Centro","street": "Rua XV de Novembro","service": "ViaCEP"}`))),
 		}, nil)
 	mockApiClient.On("Get", fmt.Sprintf("http://viacep.com.br/ws/%s/json", cep)).
 		Return(&http.Response{
@@ -161,8 +157,7 @@ func TestWeatherHandlerInternalServerError(t *testing.T) {
 			Body:       io.NopCloser(bytes.NewReader([]byte(`{"cep": "12345678","logradouro": "Rua XV de Novembro","complemento": "Apto 101","unidade": "Unidade 2","bairro": "Centro","localidade": "SP","uf": "SP","estado": "São Paulo","regiao": "Sudeste","ibge": "3550308","gia": "1004","ddd": "11","siafi": "1234"}`))),
 		}, nil)
 
-	weatherService.On("GetTemperature", mock.Anything).Return(0.0, fmt.Errorf("Error Getting Temperature")).Once()
-	weatherService.On("GetClient", mock.Anything).Return(mockApiClient)
+	weatherService.On("GetCurrentWeather", mock.Anything).Return(models.CurrentWeather{}, fmt.Errorf("Error Getting Temperature")).Once()
 
 	// Create a mock HTTP request
 	req, err := http.NewRequest("GET", fmt.Sprintf("/weather?cep=%s", cep), nil)
@@ -184,3 +179,269 @@ func TestWeatherHandlerInternalServerError(t *testing.T) {
 	expectedResponse := `{"error": "failed to get temperature"}`
 	assert.JSONEq(t, expectedResponse, rr.Body.String())
 }
+
+func TestWeatherHandlerUpstreamErrors(t *testing.T) {
+	cep := "12345678"
+	apiKey := os.Getenv("WEATHER_API_KEY")
+	cases := []struct {
+		name             string
+		statusCode       int
+		body             string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{"invalid key", 401, `{"error":{"code":2006,"message":"API key is invalid."}}`, http.StatusInternalServerError, `{"error":"weather provider authentication failed","code":"weather_provider_auth_failed"}`},
+		{"quota exceeded", 403, `{"error":{"code":2007,"message":"API key has exceeded calls per month quota."}}`, http.StatusServiceUnavailable, `{"error":"weather provider quota exceeded","code":"weather_quota_exceeded"}`},
+		{"location not found", 400, `{"error":{"code":1006,"message":"No matching location found."}}`, http.StatusNotFound, `{"error":"can not find weather for zipcode","code":"weather_location_not_found"}`},
+		{"server error", 502, `<html>Bad Gateway</html>`, http.StatusBadGateway, `{"error":"weather provider unavailable","code":"weather_provider_unavailable"}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApiClient := new(MockApiClient)
+			weatherService := newWeatherAPIService(mockApiClient)
+			locationService := services.NewLocationService(services.NewDefaultCEPProviderRegistry(mockApiClient, services.CEPProviderConfig{}))
+			handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{})
+
+			// mock CEP Responses
+			mockApiClient.On("Get", fmt.Sprintf("https://brasilapi.com.br/api/cep/v2/%s", cep)).
+				Return(&http.Response{
+					StatusCode: 404,
+					Body:       io.NopCloser(bytes.NewReader([]byte(``))),
+				}, nil)
+			mockApiClient.On("Get", fmt.Sprintf("http://viacep.com.br/ws/%s/json", cep)).
+				Return(&http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"cep": "12345678","localidade": "SP","uf": "SP"}`))),
+				}, nil)
+			// Mock Weather API error
+			mockApiClient.On("Get", fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s", apiKey, "SP%2CSP%2CBrazil")).
+				Return(&http.Response{
+					StatusCode: tc.statusCode,
+					Body:       io.NopCloser(bytes.NewReader([]byte(tc.body))),
+				}, nil)
+
+			req, err := http.NewRequest("GET", fmt.Sprintf("/weather?cep=%s", cep), nil)
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			handler.WeatherHandlerFunc().ServeHTTP(rr, req)
+
+			// Assert status code and response body
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.JSONEq(t, tc.expectedResponse, rr.Body.String())
+		})
+	}
+}
+
+func TestConditionsHandlerSuccess(t *testing.T) {
+	cep := "01001000"
+	city, uf, neighborhood := "São Paulo", "SP", "Sé"
+	latitude, longitude := -23.5505, -46.6333
+	location := models.Location{Cep: &cep, City: &city, Uf: &uf, Localidade: &neighborhood, Latitude: &latitude, Longitude: &longitude}
+
+	weather := models.CurrentWeather{
+		TempC:       25,
+		FeelsLikeC:  27,
+		Condition:   models.WeatherCondition{Text: "Partly cloudy", Icon: "//cdn.weatherapi.com/weather/64x64/day/116.png", Code: 1003},
+		Humidity:    60,
+		Cloud:       25,
+		UV:          7,
+		IsDay:       true,
+		WindKph:     18,
+		GustKph:     36,
+		WindDegree:  120,
+		WindDir:     "ESE",
+		PressureMb:  1015,
+		VisKm:       10,
+		LastUpdated: time.Unix(1700000000, 0),
+		Providers:   []string{"weatherapi", "openmeteo"},
+		Outliers:    []string{"openweathermap"},
+	}
+
+	locationService := new(MockLocationService)
+	locationService.On("GetLocationFromCEP", cep).Return(location, nil)
+	weatherService := new(MockWeatherService)
+	weatherService.On("GetCurrentWeather", location).Return(weather, nil)
+	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{})
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("/v1/conditions?cep=%s", cep), nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ConditionsHandlerFunc().ServeHTTP(rr, req)
+
+	// Assert status code and response body
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expectedResponse := `{
+		"address": {"cep": "01001000", "city": "São Paulo", "uf": "SP", "neighborhood": "Sé", "latitude": -23.5505, "longitude": -46.6333},
+		"temperature": {"temp_C": 25, "temp_F": 77, "temp_K": 298},
+		"feels_like": {"temp_C": 27, "temp_F": 80.6, "temp_K": 300},
+		"condition": {"text": "Partly cloudy", "icon": "//cdn.weatherapi.com/weather/64x64/day/116.png", "code": 1003},
+		"humidity_percent": 60,
+		"cloud_percent": 25,
+		"uv_index": 7,
+		"is_day": true,
+		"wind": {"speed_kph": 18, "speed_mph": 11.18, "speed_ms": 5, "gust_kph": 36, "gust_mph": 22.37, "gust_ms": 10, "degree": 120, "direction": "ESE"},
+		"pressure": {"mb": 1015, "in": 29.97},
+		"precipitation": {"mm": 0, "in": 0},
+		"visibility": {"km": 10, "miles": 6.21},
+		"last_updated": "2023-11-14T22:13:20Z",
+		"providers": ["weatherapi", "openmeteo"],
+		"outliers": ["openweathermap"]
+	}`
+	assert.JSONEq(t, expectedResponse, rr.Body.String())
+	assert.Equal(t, "weatherapi, openmeteo", rr.Header().Get("X-Weather-Providers"))
+}
+
+func TestForecastHandlerSuccess(t *testing.T) {
+	cep := "01001000"
+	city, uf := "São Paulo", "SP"
+	location := models.Location{Cep: &cep, City: &city, Uf: &uf, Localidade: &city}
+	apiKey := os.Getenv("WEATHER_API_KEY")
+
+	locationService := new(MockLocationService)
+	locationService.On("GetLocationFromCEP", cep).Return(location, nil)
+	mockApiClient := new(MockApiClient)
+	weatherService := newWeatherAPIService(mockApiClient)
+	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{})
+
+	// Mock Weather API forecast response
+	mockApiClient.On("Get", fmt.Sprintf("https://api.weatherapi.com/v1/forecast.json?days=2&key=%s&q=%s", apiKey, "S%C3%A3o+Paulo%2CSP%2CBrazil")).
+		Return(&http.Response{
+			StatusCode: 200,
+			Body: io.NopCloser(bytes.NewReader([]byte(`{"forecast": {"forecastday": [
+				{"date": "2024-01-01", "day": {"maxtemp_c": 30, "mintemp_c": 20, "avgtemp_c": 25, "daily_chance_of_rain": 80, "totalprecip_mm": 12.5, "condition": {"text": "Patchy rain nearby", "icon": "rain.png", "code": 1063}},
+				 "hour": [{"time": "2024-01-01 00:00", "temp_c": 22, "chance_of_rain": 10, "condition": {"text": "Clear", "icon": "clear.png", "code": 1000}}]},
+				{"date": "2024-01-02", "day": {"maxtemp_c": 28, "mintemp_c": 18, "avgtemp_c": 23, "condition": {"text": "Sunny", "icon": "sun.png", "code": 1000}}, "hour": []}
+			]}}`))),
+		}, nil)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("/v1/forecast?cep=%s&days=2", cep), nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ForecastHandlerFunc().ServeHTTP(rr, req)
+
+	// Assert status code and response body
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expectedResponse := `{
+		"address": {"cep": "01001000", "city": "São Paulo", "uf": "SP"},
+		"days": [
+			{"date": "2024-01-01",
+			 "min": {"temp_C": 20, "temp_F": 68, "temp_K": 293},
+			 "max": {"temp_C": 30, "temp_F": 86, "temp_K": 303},
+			 "avg": {"temp_C": 25, "temp_F": 77, "temp_K": 298},
+			 "condition": {"text": "Patchy rain nearby", "icon": "rain.png", "code": 1063},
+			 "chance_of_rain_percent": 80, "total_precipitation_mm": 12.5,
+			 "hours": [{"time": "2024-01-01 00:00", "temperature": {"temp_C": 22, "temp_F": 71.6, "temp_K": 295}, "condition": {"text": "Clear", "icon": "clear.png", "code": 1000}, "chance_of_rain_percent": 10}]},
+			{"date": "2024-01-02",
+			 "min": {"temp_C": 18, "temp_F": 64.4, "temp_K": 291},
+			 "max": {"temp_C": 28, "temp_F": 82.4, "temp_K": 301},
+			 "avg": {"temp_C": 23, "temp_F": 73.4, "temp_K": 296},
+			 "condition": {"text": "Sunny", "icon": "sun.png", "code": 1000},
+			 "chance_of_rain_percent": 0, "total_precipitation_mm": 0,
+			 "hours": []}
+		],
+		"providers": ["weatherapi"]
+	}`
+	assert.JSONEq(t, expectedResponse, rr.Body.String())
+	assert.Equal(t, "weatherapi", rr.Header().Get("X-Weather-Providers"))
+}
+
+func TestForecastHandlerInvalidDays(t *testing.T) {
+	handler := handlers.NewWeatherHandler(new(MockLocationService), new(MockWeatherService), &shared.TemperatureConverter{})
+
+	for _, days := range []string{"0", "15", "abc"} {
+		req, err := http.NewRequest("GET", fmt.Sprintf("/v1/forecast?cep=01001000&days=%s", days), nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handler.ForecastHandlerFunc().ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.JSONEq(t, `{"error":"invalid days"}`, rr.Body.String())
+	}
+}
+
+func TestHistoryHandlerRange(t *testing.T) {
+	cep := "01001000"
+	city, uf := "São Paulo", "SP"
+	location := models.Location{Cep: &cep, City: &city, Uf: &uf, Localidade: &city}
+	apiKey := os.Getenv("WEATHER_API_KEY")
+
+	locationService := new(MockLocationService)
+	locationService.On("GetLocationFromCEP", cep).Return(location, nil)
+	mockApiClient := new(MockApiClient)
+	weatherService := newWeatherAPIService(mockApiClient)
+	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{})
+
+	// Mock Weather API history response
+	mockApiClient.On("Get", fmt.Sprintf("https://api.weatherapi.com/v1/history.json?dt=2024-01-01&end_dt=2024-01-02&key=%s&q=%s", apiKey, "S%C3%A3o+Paulo%2CSP%2CBrazil")).
+		Return(&http.Response{
+			StatusCode: 200,
+			Body: io.NopCloser(bytes.NewReader([]byte(`{"forecast": {"forecastday": [
+				{"date": "2024-01-01", "day": {"maxtemp_c": 30, "mintemp_c": 20, "avgtemp_c": 25, "totalprecip_mm": 3, "condition": {"text": "Sunny", "icon": "sun.png", "code": 1000}}, "hour": []},
+				{"date": "2024-01-02", "day": {"maxtemp_c": 28, "mintemp_c": 18, "avgtemp_c": 22, "condition": {"text": "Sunny", "icon": "sun.png", "code": 1000}}, "hour": []}
+			]}}`))),
+		}, nil)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("/v1/history?cep=%s&start=2024-01-01&end=2024-01-02", cep), nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.HistoryHandlerFunc().ServeHTTP(rr, req)
+
+	// Assert status code and response body
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expectedResponse := `{
+		"address": {"cep": "01001000", "city": "São Paulo", "uf": "SP"},
+		"days": [
+			{"date": "2024-01-01", "temp_C": 25, "temp_F": 77, "temp_K": 298,
+			 "min": {"temp_C": 20, "temp_F": 68, "temp_K": 293},
+			 "max": {"temp_C": 30, "temp_F": 86, "temp_K": 303},
+			 "condition": {"text": "Sunny", "icon": "sun.png", "code": 1000},
+			 "total_precipitation_mm": 3, "hours": []},
+			{"date": "2024-01-02", "temp_C": 22, "temp_F": 71.6, "temp_K": 295,
+			 "min": {"temp_C": 18, "temp_F": 64.4, "temp_K": 291},
+			 "max": {"temp_C": 28, "temp_F": 82.4, "temp_K": 301},
+			 "condition": {"text": "Sunny", "icon": "sun.png", "code": 1000},
+			 "total_precipitation_mm": 0, "hours": []}
+		],
+		"providers": ["weatherapi"]
+	}`
+	assert.JSONEq(t, expectedResponse, rr.Body.String())
+	assert.Equal(t, "weatherapi", rr.Header().Get("X-Weather-Providers"))
+}
+
+func TestHistoryHandlerInvalidDates(t *testing.T) {
+	handler := handlers.NewWeatherHandler(new(MockLocationService), new(MockWeatherService), &shared.TemperatureConverter{})
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+
+	cases := map[string]string{
+		"":                                "invalid date",
+		"date=01-01-2024":                 "invalid date",
+		"date=" + tomorrow:                "invalid date",
+		"start=2024-01-10&end=2024-01-01": "invalid date range",
+		"start=2024-01-01&end=2024-03-01": "invalid date range",
+	}
+	for query, message := range cases {
+		req, err := http.NewRequest("GET", "/v1/history?cep=01001000&"+query, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handler.HistoryHandlerFunc().ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, query)
+		assert.JSONEq(t, fmt.Sprintf(`{"error":%q}`, message), rr.Body.String(), query)
+	}
+}
+
+func TestHistoryHandlerFutureDateInBrasiliaTime(t *testing.T) {
+	handler := handlers.NewWeatherHandler(new(MockLocationService), new(MockWeatherService), &shared.TemperatureConverter{})
+
+	// 22:30 de 10/03 em Brasília já é 11/03 em UTC, mas 11/03 ainda é amanhã para o usuário
+	handler.Now = func() time.Time { return time.Date(2024, 3, 11, 1, 30, 0, 0, time.UTC) }
+
+	req, err := http.NewRequest("GET", "/v1/history?cep=01001000&date=2024-03-11", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.HistoryHandlerFunc().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.JSONEq(t, `{"error":"invalid date"}`, rr.Body.String())
+}
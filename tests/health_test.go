@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"post-graduation-exercise-cloud-run-weather-api/handlers"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+)
+
+// countingHealthCheck cria uma verificação que conta as execuções e retorna o erro de *result
+func countingHealthCheck(name string, critical bool, calls *atomic.Int32, result *error) services.HealthCheck {
+	return services.HealthCheck{
+		Name:     name,
+		Critical: critical,
+		Check: func(ctx context.Context) error {
+			calls.Add(1)
+			return *result
+		},
+	}
+}
+
+func TestHealthMonitorCachesResults(t *testing.T) {
+	var calls atomic.Int32
+	var result error
+	clock := &fakeClock{now: time.Now()}
+	monitor := services.NewHealthMonitor([]services.HealthCheck{
+		countingHealthCheck("weather:weatherapi", true, &calls, &result),
+	}, services.HealthConfig{CacheTTL: 30 * time.Second, Timeout: time.Second})
+	monitor.Now = clock.Now
+
+	_, ready := monitor.Status(context.Background())
+	assert.True(t, ready)
+
+	// Dentro do CacheTTL o resultado anterior é reutilizado
+	result = errors.New("connection refused")
+	_, ready = monitor.Status(context.Background())
+	assert.True(t, ready)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Depois do CacheTTL a dependência é verificada novamente
+	clock.Advance(time.Minute)
+	statuses, ready := monitor.Status(context.Background())
+	assert.False(t, ready)
+	assert.EqualError(t, statuses[0].Err, "connection refused")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHealthMonitorCheckTimeout(t *testing.T) {
+	monitor := services.NewHealthMonitor([]services.HealthCheck{{
+		Name:     "cep:blocking",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}}, services.HealthConfig{Timeout: 50 * time.Millisecond})
+
+	statuses, ready := monitor.Status(context.Background())
+
+	assert.False(t, ready)
+	assert.ErrorIs(t, statuses[0].Err, context.DeadlineExceeded)
+}
+
+func TestReadinessHandler(t *testing.T) {
+	var configCalls, cepCalls atomic.Int32
+	var configResult error
+	cepResult := errors.New("viacep unavailable")
+	monitor := services.NewHealthMonitor([]services.HealthCheck{
+		countingHealthCheck("config", true, &configCalls, &configResult),
+		countingHealthCheck("cep:viacep", false, &cepCalls, &cepResult),
+	}, services.HealthConfig{Timeout: time.Second})
+	handler := handlers.NewHealthHandler(monitor)
+
+	// Uma dependência não crítica falhando não tira a instância do ar
+	rr := httptest.NewRecorder()
+	handler.ReadinessHandlerFunc().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var response models.HealthResponse
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "ok", response.Status)
+	assert.Len(t, response.Dependencies, 2)
+	assert.Equal(t, "ok", response.Dependencies[0].Status)
+	assert.Equal(t, "failing", response.Dependencies[1].Status)
+	assert.Equal(t, "unavailable", response.Dependencies[1].Error)
+
+	// Uma dependência crítica falhando responde 503
+	configResult = errors.New("weather.weatherapi_key: required")
+	rr = httptest.NewRecorder()
+	handler.ReadinessHandlerFunc().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"status":"unavailable"`)
+
+	// A vivacidade não depende das dependências
+	rr = httptest.NewRecorder()
+	handler.LivenessHandlerFunc().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func TestReadinessHidesUpstreamErrors(t *testing.T) {
+	timeout := &url.Error{Op: "Get", URL: "https://api.weatherapi.com/v1/current.json?key=SECRET&q=-23.5,-46.6", Err: context.DeadlineExceeded}
+	quota := &services.UpstreamError{Provider: "openweathermap", Kind: services.ErrUpstreamQuota}
+	monitor := services.NewHealthMonitor([]services.HealthCheck{
+		{Name: "weather:weatherapi", Check: func(ctx context.Context) error { return timeout }},
+		{Name: "weather:openweathermap", Check: func(ctx context.Context) error { return quota }},
+		{Name: "cep:viacep", Check: func(ctx context.Context) error { return services.ErrCEPNotFound }},
+	}, services.HealthConfig{Timeout: time.Second})
+
+	rr := httptest.NewRecorder()
+	handlers.NewHealthHandler(monitor).ReadinessHandlerFunc().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	// O endpoint é público: só o motivo classificado aparece, nunca a URL com a chave
+	var response models.HealthResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "timeout", response.Dependencies[0].Error)
+	assert.Equal(t, "quota", response.Dependencies[1].Error)
+	assert.Equal(t, "not_found", response.Dependencies[2].Error)
+	assert.NotContains(t, rr.Body.String(), "SECRET")
+}
+
+func TestCEPProviderHealthCheck(t *testing.T) {
+	upstream := newFakeUpstream(t, map[string]string{
+		"/ws/" + services.HealthProbeCEP + "/json": `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP"}`,
+	}, nil)
+	check := services.NewCEPProviderHealthCheck(services.NewViaCEPProvider(services.NewAPIClient(upstream.Client()), upstream.URL+"/ws"))
+
+	assert.Equal(t, "cep:viacep", check.Name)
+	assert.Equal(t, services.HealthGroupCEP, check.Group)
+	assert.NoError(t, check.Check(context.Background()))
+}
+
+func TestWeatherHealthCheckIsOnlyReported(t *testing.T) {
+	weatherService := new(MockWeatherService)
+	weatherService.On("GetCurrentWeather", mock.Anything).Return(models.CurrentWeather{}, &services.UpstreamError{Provider: "weatherapi", Kind: services.ErrUpstreamQuota})
+	check := services.NewWeatherHealthCheck(weatherService)
+	monitor := services.NewHealthMonitor([]services.HealthCheck{check}, services.HealthConfig{Timeout: time.Second})
+
+	// Uma cota esgotada da API de clima atinge todas as instâncias, então não as tira do ar
+	statuses, ready := monitor.Status(context.Background())
+	assert.True(t, ready)
+	assert.Equal(t, "weather", statuses[0].Name)
+	assert.False(t, statuses[0].Critical)
+	assert.ErrorIs(t, statuses[0].Err, services.ErrUpstreamQuota)
+}
+
+func TestHealthMonitorGroupFailsOnlyWhenAllFail(t *testing.T) {
+	var viacepCalls, brasilapiCalls atomic.Int32
+	var viacepResult, brasilapiResult error
+	viacep := countingHealthCheck("cep:viacep", true, &viacepCalls, &viacepResult)
+	brasilapi := countingHealthCheck("cep:brasilapi", true, &brasilapiCalls, &brasilapiResult)
+	viacep.Group, brasilapi.Group = services.HealthGroupCEP, services.HealthGroupCEP
+	monitor := services.NewHealthMonitor([]services.HealthCheck{viacep, brasilapi}, services.HealthConfig{Timeout: time.Second})
+
+	// Um provedor de CEP fora do ar é coberto pelo outro
+	viacepResult = errors.New("viacep unavailable")
+	_, ready := monitor.Status(context.Background())
+	assert.True(t, ready)
+
+	// Com todos os provedores de CEP fora do ar a instância não está pronta
+	brasilapiResult = errors.New("brasilapi unavailable")
+	monitor = services.NewHealthMonitor([]services.HealthCheck{viacep, brasilapi}, services.HealthConfig{Timeout: time.Second})
+	_, ready = monitor.Status(context.Background())
+	assert.False(t, ready)
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"post-graduation-exercise-cloud-run-weather-api/handlers"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+)
+
+func TestWeatherEndToEndAgainstFakeUpstreams(t *testing.T) {
+	// Todas as origens respondem a partir de um único servidor local
+	requests := make(chan *http.Request, 10)
+	upstream := newFakeUpstream(t, map[string]string{
+		"/brasilapi/01001000":      `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Sé", "street": "Praça da Sé"}`,
+		"/viacep/01001000/json":    `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP", "bairro": "Sé", "logradouro": "Praça da Sé"}`,
+		"/weatherapi/current.json": `{"current": {"temp_c": 25.0}}`,
+	}, requests)
+
+	apiClient := services.NewAPIClient(upstream.Client())
+	registry := services.NewDefaultCEPProviderRegistry(apiClient, services.CEPProviderConfig{
+		BrasilAPIBaseURL: upstream.URL + "/brasilapi",
+		ViaCEPBaseURL:    upstream.URL + "/viacep",
+	})
+	weatherService := services.NewWeatherService(services.NewWeatherAPIProvider(apiClient, upstream.URL+"/weatherapi", "test-key"))
+	handler := handlers.NewWeatherHandler(services.NewLocationService(registry), weatherService, &shared.TemperatureConverter{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/weather", handler.WeatherHandlerFunc())
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/weather?cep=01001000")
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	var body map[string]float64
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Equal(t, map[string]float64{"temp_C": 25, "temp_F": 77, "temp_K": 298}, body)
+
+	// Nenhuma chamada saiu do servidor local e a chave foi repassada
+	for len(requests) > 0 {
+		request := <-requests
+		assert.Contains(t, []string{"/brasilapi/01001000", "/viacep/01001000/json", "/weatherapi/current.json"}, request.URL.Path)
+		if request.URL.Path == "/weatherapi/current.json" {
+			assert.Equal(t, "test-key", request.URL.Query().Get("key"))
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"post-graduation-exercise-cloud-run-weather-api/handlers"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+)
+
+// captureLogs troca o logger padrão por um que escreve em JSON no buffer retornado
+func captureLogs(t *testing.T, config shared.LoggingConfig) *bytes.Buffer {
+	var buffer bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(shared.NewLogger(&buffer, config))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buffer
+}
+
+// logEntries decodifica as linhas JSON escritas no buffer
+func logEntries(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLoggerWritesCloudLoggingFields(t *testing.T) {
+	buffer := captureLogs(t, shared.LoggingConfig{Level: slog.LevelInfo, ProjectID: "my-project"})
+
+	tracer := shared.NewTracer(&recordingExporter{}, 1)
+	ctx, span := tracer.Start(shared.ContextWithRequestID(context.Background(), "abc-123"), "request", shared.SpanKindServer)
+	slog.WarnContext(ctx, "Upstream call failed", "provider", "viacep")
+	slog.DebugContext(ctx, "Ignored below the configured level")
+	span.End()
+
+	entries := logEntries(t, buffer)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "WARNING", entries[0]["severity"])
+	assert.Equal(t, "Upstream call failed", entries[0]["message"])
+	assert.Equal(t, "abc-123", entries[0]["request_id"])
+	assert.Equal(t, "viacep", entries[0]["provider"])
+	assert.Equal(t, "projects/my-project/traces/"+span.SpanContext.TraceID.String(), entries[0]["logging.googleapis.com/trace"])
+	assert.Equal(t, span.SpanContext.SpanID.String(), entries[0]["logging.googleapis.com/spanId"])
+}
+
+func TestWithRequestID(t *testing.T) {
+	captureLogs(t, shared.LoggingConfig{})
+	cloudTrace := "105445aa7843bc8bf206b12000100000/1;o=1"
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string // ID esperado, vazio quando deve ser gerado
+	}{
+		{"ID do cliente", map[string]string{"X-Request-ID": "client-42", "X-Cloud-Trace-Context": cloudTrace}, "client-42"},
+		{"trace do Cloud Run", map[string]string{"X-Cloud-Trace-Context": cloudTrace}, "105445aa7843bc8bf206b12000100000"},
+		{"ID inválido", map[string]string{"X-Request-ID": "bad id\ninjected"}, ""},
+		{"sem cabeçalhos", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := handlers.WithRequestID(func(w http.ResponseWriter, r *http.Request) {
+				seen = shared.RequestIDFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/weather?cep=01001000", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			// O ID da requisição é o mesmo devolvido no cabeçalho de resposta
+			assert.NotEmpty(t, seen)
+			assert.Equal(t, seen, rr.Header().Get("X-Request-ID"))
+			if tt.want != "" {
+				assert.Equal(t, tt.want, seen)
+			} else {
+				assert.Len(t, seen, 32)
+			}
+		})
+	}
+}
+
+func TestUpstreamFailuresAreLogged(t *testing.T) {
+	buffer := captureLogs(t, shared.LoggingConfig{Level: slog.LevelInfo})
+
+	cep := "01001000"
+	city := "São Paulo"
+	location := models.Location{Cep: &cep, City: &city, Localidade: &city}
+	failing := &MockCEPProvider{ProviderName: "viacep"}
+	failing.On("FetchLocation", cep).Return(models.Location{}, fmt.Errorf("connection reset"))
+	working := &MockCEPProvider{ProviderName: "brasilapi", Delay: 50 * time.Millisecond}
+	working.On("FetchLocation", cep).Return(location, nil)
+	registry := services.NewCEPProviderRegistry()
+	assert.NoError(t, registry.Register(failing))
+	assert.NoError(t, registry.Register(working))
+
+	// A falha de um provedor é registrada com o motivo, mesmo quando outro responde
+	ctx := shared.ContextWithRequestID(context.Background(), "req-1")
+	service := &services.LocationServiceImpl{Registry: registry}
+	_, err := service.GetLocationFromCEP(ctx, cep)
+	assert.NoError(t, err)
+
+	entries := logEntries(t, buffer)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "WARNING", entries[0]["severity"])
+	assert.Equal(t, "viacep", entries[0]["provider"])
+	assert.Equal(t, "cep", entries[0]["operation"])
+	assert.Contains(t, entries[0]["error"], "connection reset")
+	assert.Equal(t, "req-1", entries[0]["request_id"])
+}
+
+func TestUpstreamFailureLogsHideAPIKey(t *testing.T) {
+	buffer := captureLogs(t, shared.LoggingConfig{Level: slog.LevelDebug})
+
+	// Uma origem desligada faz a chamada com a chave na query falhar no transporte
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	upstream.Close()
+	provider := services.NewWeatherAPIProvider(services.NewAPIClient(upstream.Client()), upstream.URL+"/v1", "SECRET")
+	weatherService := &services.WeatherServiceImpl{Provider: services.NewInstrumentedWeatherProvider(provider, nil)}
+
+	cep := "01001000"
+	city := "São Paulo"
+	locationService := new(MockLocationService)
+	locationService.On("GetLocationFromCEP", cep).Return(models.Location{Cep: &cep, City: &city, Localidade: &city}, nil)
+	handler := handlers.NewWeatherHandler(locationService, weatherService, &shared.TemperatureConverter{})
+
+	rr := httptest.NewRecorder()
+	handler.WeatherHandlerFunc().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather?cep="+cep, nil))
+
+	// A falha é registrada pelo provedor e pelo handler, com o caminho mas sem a chave
+	entries := logEntries(t, buffer)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "weatherapi", entries[0]["provider"])
+	assert.Contains(t, entries[0]["error"], "/v1/current.json")
+	assert.Equal(t, "Weather lookup failed", entries[1]["message"])
+	assert.NotContains(t, buffer.String(), "SECRET")
+	assert.NotContains(t, rr.Body.String(), "SECRET")
+}
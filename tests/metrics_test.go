@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"post-graduation-exercise-cloud-run-weather-api/handlers"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+)
+
+func TestMetricsRegistryTextFormat(t *testing.T) {
+	registry := shared.NewMetricsRegistry()
+	counter := registry.NewCounter("requests_total", "Requests served.", "route", "status")
+	histogram := registry.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	registry.NewGaugeFunc("answer", "The answer.", func() float64 { return 42 })
+
+	counter.Inc("/weather", "200")
+	counter.Add(2, "/weather", "200")
+	counter.Inc(`/a"b`, "404") // Aspas nos valores são escapadas
+	histogram.Observe(0.05, "/weather")
+	histogram.Observe(0.5, "/weather")
+	histogram.Observe(3, "/weather")
+
+	var output strings.Builder
+	assert.NoError(t, registry.WriteText(&output))
+
+	// Métricas ordenadas por nome e buckets cumulativos
+	expected := `# HELP answer The answer.
+# TYPE answer gauge
+answer 42
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/weather",le="0.1"} 1
+latency_seconds_bucket{route="/weather",le="1"} 2
+latency_seconds_bucket{route="/weather",le="+Inf"} 3
+latency_seconds_sum{route="/weather"} 3.55
+latency_seconds_count{route="/weather"} 3
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/a\"b",status="404"} 1
+requests_total{route="/weather",status="200"} 3
+`
+	assert.Equal(t, expected, output.String())
+}
+
+func TestMetricsHandlerInstrument(t *testing.T) {
+	metricsHandler := handlers.NewMetricsHandler(shared.NewMetricsRegistry())
+	notFound := metricsHandler.Instrument("/weather", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "can not find zipcode", http.StatusNotFound)
+	})
+	notFound.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/weather?cep=99999999", nil))
+
+	rr := httptest.NewRecorder()
+	metricsHandler.MetricsHandlerFunc().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// A rota registrada é usada, não o caminho com a query string
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `http_requests_total{route="/weather",method="GET",status="404"} 1`)
+	assert.Contains(t, rr.Body.String(), `http_request_duration_seconds_count{route="/weather"} 1`)
+	assert.Contains(t, rr.Body.String(), `http_requests_in_flight{route="/weather"} 0`)
+	assert.Contains(t, rr.Body.String(), "# TYPE go_goroutines gauge")
+}
+
+func TestMetricsHandlerBoundsMethodLabel(t *testing.T) {
+	metricsHandler := handlers.NewMetricsHandler(shared.NewMetricsRegistry())
+	handler := metricsHandler.Instrument("/weather", func(w http.ResponseWriter, r *http.Request) {})
+	for _, method := range []string{"GET", "X1", "X2", "DELETE", "PROPFIND"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/weather", nil))
+	}
+
+	rr := httptest.NewRecorder()
+	metricsHandler.MetricsHandlerFunc().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Métodos inventados pelo cliente caem todos em uma única série extra
+	assert.Equal(t, 2, strings.Count(rr.Body.String(), `http_requests_total{route="/weather"`))
+	assert.Contains(t, rr.Body.String(), `http_requests_total{route="/weather",method="GET",status="200"} 1`)
+	assert.Contains(t, rr.Body.String(), `http_requests_total{route="/weather",method="other",status="200"} 4`)
+	assert.NotContains(t, rr.Body.String(), "X1")
+}
+
+func TestMetricsRequireAdminKey(t *testing.T) {
+	now := time.Now()
+	store := newTestKeyStore(&now)
+	metricsHandler := handlers.NewMetricsHandler(shared.NewMetricsRegistry())
+	router := handlers.NewRouter().Use(handlers.Recover, handlers.RequireAdminKey(store))
+	router.Handle("/metrics", metricsHandler.MetricsHandlerFunc())
+
+	// As métricas expõem os provedores e suas falhas, então só a chave de administrador as lê
+	for key, status := range map[string]int{"": http.StatusUnauthorized, "mobile-key": http.StatusForbidden} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("X-API-Key", key)
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, status, rr.Code, key)
+	}
+
+	// O Prometheus pode enviar a chave como parâmetro da coleta
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics?api_key=ops-key", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "# TYPE go_goroutines gauge")
+
+	// Sem chaves configuradas, as métricas ficam fechadas
+	rr = httptest.NewRecorder()
+	noKeys := handlers.NewRouter().Use(handlers.RequireAdminKey(nil))
+	noKeys.Handle("/metrics", metricsHandler.MetricsHandlerFunc())
+	noKeys.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestLocationServiceMetrics(t *testing.T) {
+	cep := "12345678"
+	city := "São Paulo"
+	location := models.Location{Cep: &cep, City: &city, Localidade: &city}
+
+	// O provedor que falha e o vencedor da disputa são registrados
+	failing := &MockCEPProvider{ProviderName: "failing"}
+	failing.On("FetchLocation", cep).Return(models.Location{}, fmt.Errorf("connection reset"))
+	slow := &MockCEPProvider{ProviderName: "slow", Delay: 50 * time.Millisecond}
+	slow.On("FetchLocation", cep).Return(location, nil)
+	registry := services.NewCEPProviderRegistry()
+	assert.NoError(t, registry.Register(failing))
+	assert.NoError(t, registry.Register(slow))
+
+	metrics := services.NewMetrics(shared.NewMetricsRegistry())
+	locationService := &services.LocationServiceImpl{Registry: registry, Metrics: metrics}
+	cached := services.NewCachedLocationService(locationService, services.DefaultLocationCacheConfig)
+	cached.Metrics = metrics
+
+	for i := 0; i < 2; i++ {
+		_, err := cached.GetLocationFromCEP(context.Background(), cep)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 1.0, metrics.CEPRaceWins.Value("slow"))
+	assert.Equal(t, 1.0, metrics.UpstreamErrors.Value("failing", "cep"))
+	assert.Equal(t, uint64(1), metrics.UpstreamDuration.Count("slow", "cep", "success"))
+	assert.Equal(t, 1.0, metrics.CacheLookups.Value("location", "miss"))
+	assert.Equal(t, 1.0, metrics.CacheLookups.Value("location", "hit"))
+}
+
+func TestInstrumentedWeatherProviderIgnoresCancelledCalls(t *testing.T) {
+	metrics := services.NewMetrics(shared.NewMetricsRegistry())
+	provider := services.NewInstrumentedWeatherProvider(newMockWeatherProvider("slow", time.Second, 20, nil), metrics)
+
+	// Uma chamada cancelada por quem chamou, como a perdedora de um hedge, não conta como erro
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := provider.CurrentWeather(ctx, models.Location{})
+
+	assert.Error(t, err)
+	assert.Equal(t, "slow", provider.Name())
+	assert.Equal(t, 0.0, metrics.UpstreamErrors.Value("slow", "current"))
+	assert.Equal(t, uint64(1), metrics.UpstreamDuration.Count("slow", "current", "cancelled"))
+}
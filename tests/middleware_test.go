@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"post-graduation-exercise-cloud-run-weather-api/handlers"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+)
+
+// tagging retorna um middleware que registra seu nome em calls antes de chamar o próximo
+func tagging(name string, calls *[]string) handlers.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next(w, r)
+		}
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	handler := handlers.Chain(tagging("first", &calls), tagging("second", &calls))(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// O primeiro middleware é o mais externo
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestRouterChains(t *testing.T) {
+	var calls, routes []string
+	router := handlers.NewRouter().Use(tagging("common", &calls))
+	api := router.UseRoute(func(route string, next http.HandlerFunc) http.HandlerFunc {
+		routes = append(routes, route)
+		return next
+	})
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	api.Handle("GET /weather", ok)
+	router.Handle("/metrics", ok)
+
+	// As rotas da API recebem os middlewares de rota, as demais só os comuns
+	assert.Equal(t, []string{"GET /weather"}, routes)
+	for _, path := range []string{"/weather", "/metrics"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+	assert.Equal(t, []string{"common", "common"}, calls)
+}
+
+func TestRecoverReturnsJSONError(t *testing.T) {
+	buffer := captureLogs(t, shared.LoggingConfig{Level: slog.LevelInfo})
+	handler := handlers.Recover(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map")
+	})
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/weather", nil))
+
+	var response models.ErrorResponse
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "internal_error", response.Code)
+
+	// O panic é registrado com a pilha de chamadas
+	entries := logEntries(t, buffer)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "ERROR", entries[0]["severity"])
+	assert.Equal(t, "nil map", entries[0]["panic"])
+	assert.Contains(t, entries[0]["stack"], "TestRecoverReturnsJSONError")
+}
+
+func TestRecoverAbortsStartedResponse(t *testing.T) {
+	captureLogs(t, shared.LoggingConfig{})
+	handler := handlers.Recover(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("failed midway")
+	})
+
+	// Com a resposta já iniciada, só resta abortar a conexão
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/weather", nil))
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	buffer := captureLogs(t, shared.LoggingConfig{Level: slog.LevelInfo})
+	router := handlers.NewRouter().Use(handlers.WithRequestID, handlers.AccessLog)
+	router.Handle("/weather", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/weather?cep=01001000", nil)
+	req.Header.Set("X-Request-ID", "req-7")
+	req.Header.Set("User-Agent", "curl/8.0")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logEntries(t, buffer)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "INFO", entries[0]["severity"])
+	assert.Equal(t, "/weather", entries[0]["route"])
+	assert.Equal(t, "req-7", entries[0]["request_id"])
+	httpRequest := entries[0]["httpRequest"].(map[string]any)
+	assert.Equal(t, "GET", httpRequest["requestMethod"])
+	assert.Equal(t, "/weather", httpRequest["requestUrl"])
+	assert.Equal(t, 404.0, httpRequest["status"])
+	assert.Equal(t, "9", httpRequest["responseSize"])
+	assert.Equal(t, "curl/8.0", httpRequest["userAgent"])
+	assert.Regexp(t, `^\d+\.\d{6}s$`, httpRequest["latency"])
+}
+
+func TestTimingHeader(t *testing.T) {
+	handler := handlers.Timing(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	})
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/weather", nil))
+	assert.Regexp(t, `^app;dur=\d+\.\d{3}$`, rr.Header().Get("Server-Timing"))
+}
+
+func TestGzip(t *testing.T) {
+	body := strings.Repeat(`{"temp_C":28.5}`, 100)
+	handler := handlers.Gzip(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		compressed     bool
+	}{
+		{"aceita gzip", "br, gzip", true},
+		{"gzip com peso", "gzip;q=0.8, identity", true},
+		{"gzip recusado", "gzip;q=0", false},
+		{"sem Accept-Encoding", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+			if !tt.compressed {
+				assert.Empty(t, rr.Header().Get("Content-Encoding"))
+				assert.Equal(t, body, rr.Body.String())
+				return
+			}
+			assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+			assert.Less(t, rr.Body.Len(), len(body))
+			reader, err := gzip.NewReader(rr.Body)
+			assert.NoError(t, err)
+			decompressed, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, body, string(decompressed))
+		})
+	}
+}
+
+func TestGzipSkipsResponsesWithoutBody(t *testing.T) {
+	handler := handlers.Gzip(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/weather", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Zero(t, rr.Body.Len())
+}
@@ -1,9 +1,12 @@
 package tests
 
 import (
+	"context"
 	"net/http"
+	"os"
 	"post-graduation-exercise-cloud-run-weather-api/models"
 	"post-graduation-exercise-cloud-run-weather-api/services"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -13,14 +16,24 @@ type MockWeatherService struct {
 	mock.Mock
 }
 
-func (m *MockWeatherService) GetTemperature(city string) (float64, error) {
-	args := m.Called(city)
-	return args.Get(0).(float64), args.Error(1)
+func (m *MockWeatherService) GetCurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	args := m.Called(location)
+	return args.Get(0).(models.CurrentWeather), args.Error(1)
 }
 
-func (m *MockWeatherService) GetClient() services.APIClient {
-	args := m.Called()
-	return args.Get(0).(services.APIClient)
+func (m *MockWeatherService) GetForecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	args := m.Called(location, days)
+	return args.Get(0).(models.Forecast), args.Error(1)
+}
+
+func (m *MockWeatherService) GetHistory(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	args := m.Called(location, start, end)
+	return args.Get(0).(models.Forecast), args.Error(1)
+}
+
+// newWeatherAPIService cria um WeatherService sobre a weatherapi.com usando o cliente informado
+func newWeatherAPIService(client services.APIClient) services.WeatherService {
+	return services.NewWeatherService(services.NewWeatherAPIProvider(client, services.DefaultWeatherAPIBaseURL, os.Getenv("WEATHER_API_KEY")))
 }
 
 // MockApiClient implementando o método Get
@@ -29,7 +42,7 @@ type MockApiClient struct {
 }
 
 // Mock do método Get com switch para URL
-func (m *MockApiClient) Get(url string) (*http.Response, error) {
+func (m *MockApiClient) Get(ctx context.Context, url string) (*http.Response, error) {
 	args := m.Called(url)
 	return args.Get(0).(*http.Response), args.Error(1)
 }
@@ -40,7 +53,74 @@ type MockLocationService struct {
 }
 
 // Simula a obtenção de localização a partir do CEP
-func (m *MockLocationService) GetLocationFromCEP(cep string, chBrasilAPI, chViaCEP chan models.Location) (models.Location, error) {
-	args := m.Called(cep, chBrasilAPI, chViaCEP)
+func (m *MockLocationService) GetLocationFromCEP(ctx context.Context, cep string) (models.Location, error) {
+	args := m.Called(cep)
 	return args.Get(0).(models.Location), args.Error(1)
 }
+
+// MockCEPProvider simula um provedor de CEP com atraso configurável
+type MockCEPProvider struct {
+	mock.Mock
+	ProviderName string        // Nome do provedor no registro
+	Delay        time.Duration // Atraso antes de responder
+}
+
+func (m *MockCEPProvider) Name() string {
+	return m.ProviderName
+}
+
+// Simula a busca do CEP respeitando o atraso configurado e o cancelamento
+func (m *MockCEPProvider) FetchLocation(ctx context.Context, cep string) (models.Location, error) {
+	select {
+	case <-time.After(m.Delay):
+	case <-ctx.Done():
+		return models.Location{}, ctx.Err()
+	}
+	args := m.Called(cep)
+	return args.Get(0).(models.Location), args.Error(1)
+}
+
+// MockWeatherProvider simula um provedor de clima com atraso configurável
+type MockWeatherProvider struct {
+	mock.Mock
+	ProviderName string        // Nome do provedor
+	Delay        time.Duration // Atraso antes de responder
+}
+
+func (m *MockWeatherProvider) Name() string {
+	return m.ProviderName
+}
+
+// wait aguarda o atraso configurado respeitando o cancelamento
+func (m *MockWeatherProvider) wait(ctx context.Context) error {
+	select {
+	case <-time.After(m.Delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *MockWeatherProvider) CurrentWeather(ctx context.Context, location models.Location) (models.CurrentWeather, error) {
+	if err := m.wait(ctx); err != nil {
+		return models.CurrentWeather{}, err
+	}
+	args := m.Called(location)
+	return args.Get(0).(models.CurrentWeather), args.Error(1)
+}
+
+func (m *MockWeatherProvider) Forecast(ctx context.Context, location models.Location, days int) (models.Forecast, error) {
+	if err := m.wait(ctx); err != nil {
+		return models.Forecast{}, err
+	}
+	args := m.Called(location, days)
+	return args.Get(0).(models.Forecast), args.Error(1)
+}
+
+func (m *MockWeatherProvider) History(ctx context.Context, location models.Location, start, end time.Time) (models.Forecast, error) {
+	if err := m.wait(ctx); err != nil {
+		return models.Forecast{}, err
+	}
+	args := m.Called(location, start, end)
+	return args.Get(0).(models.Forecast), args.Error(1)
+}
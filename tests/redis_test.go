@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"post-graduation-exercise-cloud-run-weather-api/handlers"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+)
+
+// fakeRedis é um servidor Redis em memória que entende os comandos usados pelos contadores
+type fakeRedis struct {
+	password string
+	mu       sync.Mutex
+	values   map[string]int
+	expires  map[string]int64
+	commands []string // Nomes dos comandos recebidos
+}
+
+// newFakeRedis inicia o servidor e retorna o seu endereço
+func newFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	server := &fakeRedis{password: password, values: map[string]int{}, expires: map[string]int64{}}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server, listener.Addr().String()
+}
+
+// serve responde aos comandos de uma conexão até ela ser fechada
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, args[0])
+		switch {
+		case args[0] == "AUTH" && args[1] == f.password:
+			authenticated = true
+			io.WriteString(conn, "+OK\r\n")
+		case args[0] == "AUTH":
+			io.WriteString(conn, "-WRONGPASS invalid username-password pair\r\n")
+		case !authenticated:
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+		case args[0] == "INCRBY":
+			delta, _ := strconv.Atoi(args[2])
+			f.values[args[1]] += delta
+			fmt.Fprintf(conn, ":%d\r\n", f.values[args[1]])
+		case args[0] == "EXPIREAT":
+			f.expires[args[1]], _ = strconv.ParseInt(args[2], 10, 64)
+			io.WriteString(conn, ":1\r\n")
+		case args[0] == "MGET":
+			fmt.Fprintf(conn, "*%d\r\n", len(args)-1)
+			for _, key := range args[1:] {
+				if value, ok := f.values[key]; ok {
+					text := strconv.Itoa(value)
+					fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(text), text)
+				} else {
+					io.WriteString(conn, "$-1\r\n")
+				}
+			}
+		default:
+			io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+		f.mu.Unlock()
+	}
+}
+
+// readCommand lê um comando RESP enviado como array de strings bulk
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil { // Tamanho da string
+			return nil, err
+		}
+		value, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(value, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisClient(t *testing.T) {
+	server, addr := newFakeRedis(t, "secret")
+	client := shared.NewRedisClient(addr, "secret")
+	defer client.Close()
+
+	// A conexão é autenticada e reaproveitada entre os comandos
+	reply, err := client.Do(context.Background(), "INCRBY", "counter", "5")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), reply)
+	replies, err := client.Pipeline(context.Background(), []string{"INCRBY", "counter", "-2"}, []string{"MGET", "counter", "missing"})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{int64(3), []any{"3", nil}}, replies)
+	assert.Equal(t, []string{"AUTH", "INCRBY", "INCRBY", "MGET"}, server.commands)
+
+	// Respostas de erro viram RedisError, e a senha errada nunca aparece no erro
+	_, err = client.Do(context.Background(), "FLUSHALL")
+	assert.EqualError(t, err, "redis: ERR unknown command")
+	_, err = shared.NewRedisClient(addr, "wrong-password").Do(context.Background(), "MGET", "counter")
+	assert.ErrorContains(t, err, "WRONGPASS")
+	assert.NotContains(t, err.Error(), "wrong-password")
+}
+
+func TestRedisUsageCounterSharesQuotasAmongInstances(t *testing.T) {
+	server, addr := newFakeRedis(t, "")
+	now := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+
+	// Duas instâncias com o mesmo Redis contam as requisições da mesma chave juntas
+	instances := make([]http.HandlerFunc, 2)
+	stores := make([]*services.APIKeyStore, 2)
+	for i := range instances {
+		stores[i] = newTestKeyStore(&now)
+		client := shared.NewRedisClient(addr, "")
+		t.Cleanup(func() { client.Close() })
+		stores[i].Counter = services.NewRedisUsageCounter(client)
+		instances[i] = handlers.RequireAPIKey(stores[i])(func(w http.ResponseWriter, r *http.Request) {})
+	}
+	assert.Equal(t, http.StatusOK, serveWithKey(instances[0], "/weather", "mobile-key").Code)
+	assert.Equal(t, "0", serveWithKey(instances[1], "/weather", "mobile-key").Header().Get("X-Quota-Remaining"))
+	rr := serveWithKey(instances[0], "/weather", "mobile-key")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "3600", rr.Header().Get("Retry-After"))
+
+	// A requisição rejeitada não fica contada, e o uso visto por qualquer instância é o total
+	usage, err := stores[1].Usage(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, usage[0].DailyUsed)
+	assert.Equal(t, 2, usage[0].MonthlyUsed)
+
+	// Os contadores ficam sob o hash da chave, nunca sob o segredo, e expiram depois do período
+	for key, expiresAt := range server.expires {
+		assert.NotContains(t, key, "mobile-key")
+		assert.Greater(t, expiresAt, now.Unix())
+	}
+	assert.Len(t, server.expires, 2)
+}
+
+func TestRequireAPIKeyWithUsageUnavailable(t *testing.T) {
+	captureLogs(t, shared.LoggingConfig{})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	listener.Close() // Um Redis fora do ar
+
+	now := time.Now()
+	store := newTestKeyStore(&now)
+	store.Counter = services.NewRedisUsageCounter(shared.NewRedisClient(listener.Addr().String(), ""))
+
+	// Sem como contar a requisição, ela é rejeitada em vez de atendida sem cota
+	rr := serveWithKey(handlers.RequireAPIKey(store)(func(w http.ResponseWriter, r *http.Request) {}), "/weather", "mobile-key")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "usage_unavailable", errorCode(t, rr))
+
+	// Uma chave inválida continua sendo 401, pois não depende dos contadores
+	rr = serveWithKey(handlers.RequireAPIKey(store)(func(w http.ResponseWriter, r *http.Request) {}), "/weather", "stolen-key")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	admin := &handlers.AdminHandler{Keys: store}
+	rr = serveWithKey(handlers.RequireAdminKey(store)(admin.UsageHandlerFunc()), "/admin/usage", "ops-key")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
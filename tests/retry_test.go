@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSequenceUpstream responde cada chamada com o próximo status da lista, repetindo o último
+func newSequenceUpstream(t *testing.T, statuses []int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		status := statuses[min(call, len(statuses))-1]
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+var testRetryConfig = services.RetryConfig{
+	MaxAttempts:    3,
+	AttemptTimeout: time.Second,
+	BaseDelay:      time.Millisecond,
+	MaxDelay:       10 * time.Millisecond,
+	Budget:         5 * time.Second,
+}
+
+func TestRetryClientRetriesTransientStatuses(t *testing.T) {
+	server, calls := newSequenceUpstream(t, []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, nil)
+	client := services.NewRetryClient(services.NewAPIClient(server.Client()), testRetryConfig)
+
+	resp, err := client.Get(context.Background(), server.URL)
+
+	// O corpo continua legível depois que a chamada retorna
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", string(body))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetryClientGivesUpAfterMaxAttempts(t *testing.T) {
+	server, calls := newSequenceUpstream(t, []int{http.StatusGatewayTimeout}, nil)
+	client := services.NewRetryClient(services.NewAPIClient(server.Client()), testRetryConfig)
+
+	// A última resposta é entregue a quem chama
+	resp, err := client.Get(context.Background(), server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Erros do cliente não são tentados de novo
+	server, calls = newSequenceUpstream(t, []int{http.StatusNotFound}, nil)
+	resp, err = client.Get(context.Background(), server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryClientHonorsRetryAfterWithinDeadline(t *testing.T) {
+	server, calls := newSequenceUpstream(t, []int{http.StatusTooManyRequests, http.StatusOK}, http.Header{"Retry-After": {"1"}})
+	client := services.NewRetryClient(services.NewAPIClient(server.Client()), testRetryConfig)
+
+	// Esperar o Retry-After estouraria o prazo de quem chama: o 429 volta na hora
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	resp, err := client.Get(ctx, server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Com tempo suficiente, espera o Retry-After e tenta de novo
+	server, calls = newSequenceUpstream(t, []int{http.StatusTooManyRequests, http.StatusOK}, http.Header{"Retry-After": {"1"}})
+	start = time.Now()
+	resp, err = client.Get(context.Background(), server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetryClientAttemptTimeout(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-r.Context().Done() // A primeira tentativa trava até ser abandonada
+			return
+		}
+		io.WriteString(w, "OK")
+	}))
+	defer server.Close()
+	config := testRetryConfig
+	config.AttemptTimeout = 50 * time.Millisecond
+	client := services.NewRetryClient(services.NewAPIClient(server.Client()), config)
+
+	resp, err := client.Get(context.Background(), server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
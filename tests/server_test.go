@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+)
+
+// startTestServer executa shared.Serve com um handler que demora delay e retorna o endereço,
+// um canal que indica quando a requisição começou e o resultado de Serve
+func startTestServer(t *testing.T, ctx context.Context, delay, shutdownTimeout time.Duration) (string, <-chan struct{}, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	started := make(chan struct{}, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-time.After(delay):
+			io.WriteString(w, "done")
+		case <-r.Context().Done():
+		}
+	})
+
+	served := make(chan error, 1)
+	go func() {
+		served <- shared.Serve(ctx, shared.NewServer(handler, shared.DefaultServerConfig), listener, shutdownTimeout)
+	}()
+	return "http://" + listener.Addr().String(), started, served
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	url, started, served := startTestServer(t, ctx, 200*time.Millisecond, time.Second)
+
+	responses := make(chan string, 1)
+	go func() {
+		response, err := http.Get(url)
+		if !assert.NoError(t, err) {
+			responses <- ""
+			return
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		responses <- string(body)
+	}()
+
+	// O sinal chega com a requisição em andamento, que ainda assim é concluída
+	<-started
+	cancel()
+	assert.Equal(t, "done", <-responses)
+	assert.NoError(t, <-served)
+
+	// Novas conexões são recusadas
+	_, err := http.Get(url)
+	assert.Error(t, err)
+}
+
+func TestServeShutdownDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	url, started, served := startTestServer(t, ctx, time.Minute, 100*time.Millisecond)
+
+	go http.Get(url)
+	<-started
+	cancel()
+
+	// A requisição não termina a tempo e as conexões são fechadas
+	select {
+	case err := <-served:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return after the shutdown timeout")
+	}
+}
@@ -2,10 +2,12 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"post-graduation-exercise-cloud-run-weather-api/models"
 	"post-graduation-exercise-cloud-run-weather-api/services"
 	"testing"
@@ -16,12 +18,6 @@ import (
 )
 
 func TestRaceFetch(t *testing.T) {
-	mockLocationService := new(MockLocationService)
-
-	// Canais
-	chBrasilAPI := make(chan models.Location)
-	chViaCEP := make(chan models.Location)
-
 	cep := "12345678"
 	localidadeBrasilAPI := "São Paulo"
 	ufBrasilAPI := "SP"
@@ -29,6 +25,7 @@ func TestRaceFetch(t *testing.T) {
 		Cep:        &cep,
 		Localidade: &localidadeBrasilAPI,
 		Uf:         &ufBrasilAPI,
+		City:       &localidadeBrasilAPI,
 	}
 
 	localidadeViaCEP := "Rio de Janeiro"
@@ -37,35 +34,173 @@ func TestRaceFetch(t *testing.T) {
 		Cep:        &cep,
 		Localidade: &localidadeViaCEP,
 		Uf:         &ufViaCEP,
+		City:       &localidadeViaCEP,
 	}
 
-	// Expect behavior on MockLocationService
-	mockLocationService.On("GetLocationFromCEP", cep, chBrasilAPI, chViaCEP).Return(locationBrasilAPI, nil)
-
-	// Testando a resposta com chBrasilAPI primeiro
-	go func() {
-		chBrasilAPI <- locationBrasilAPI
-	}()
+	// Provedor rápido e provedor lento
+	fast := &MockCEPProvider{ProviderName: "fast"}
+	fast.On("FetchLocation", cep).Return(locationBrasilAPI, nil)
+	slow := &MockCEPProvider{ProviderName: "slow", Delay: 500 * time.Millisecond}
+	slow.On("FetchLocation", cep).Return(locationViaCEP, nil)
 
-	// Testando a resposta com chViaCEP primeiro
-	go func() {
-		time.Sleep(1 * time.Second)
-		chViaCEP <- locationViaCEP
-	}()
+	registry := services.NewCEPProviderRegistry()
+	assert.NoError(t, registry.Register(slow))
+	assert.NoError(t, registry.Register(fast))
+	locationService := services.NewLocationService(registry)
 
-	// Esperado o resultado
-	resultado, err := mockLocationService.GetLocationFromCEP(cep, chBrasilAPI, chViaCEP)
+	// Esperado o resultado do provedor mais rápido
+	resultado, err := locationService.GetLocationFromCEP(context.Background(), cep)
 	assert.NoError(t, err)
 	assert.Equal(t, locationBrasilAPI, resultado)
+}
+
+func TestRaceFirstValidAnswerWins(t *testing.T) {
+	cep := "12345678"
+	city := "São Paulo"
+	location := models.Location{Cep: &cep, Localidade: &city, City: &city}
+
+	// O provedor rápido falha e o lento responde com sucesso
+	failing := &MockCEPProvider{ProviderName: "failing"}
+	failing.On("FetchLocation", cep).Return(models.Location{}, fmt.Errorf("connection reset"))
+	slow := &MockCEPProvider{ProviderName: "slow", Delay: 100 * time.Millisecond}
+	slow.On("FetchLocation", cep).Return(location, nil)
+
+	registry := services.NewCEPProviderRegistry()
+	assert.NoError(t, registry.Register(failing))
+	assert.NoError(t, registry.Register(slow))
+	locationService := services.NewLocationService(registry)
+
+	resultado, err := locationService.GetLocationFromCEP(context.Background(), cep)
+	assert.NoError(t, err)
+	assert.Equal(t, location, resultado)
+}
+
+func TestRaceAllProvidersFail(t *testing.T) {
+	cep := "12345678"
+
+	failing := &MockCEPProvider{ProviderName: "failing"}
+	failing.On("FetchLocation", cep).Return(models.Location{}, fmt.Errorf("connection reset"))
+	unknown := &MockCEPProvider{ProviderName: "unknown"}
+	unknown.On("FetchLocation", cep).Return(models.Location{}, services.ErrCEPNotFound)
+
+	registry := services.NewCEPProviderRegistry()
+	assert.NoError(t, registry.Register(failing))
+	assert.NoError(t, registry.Register(unknown))
+	locationService := services.NewLocationService(registry)
+
+	_, err := locationService.GetLocationFromCEP(context.Background(), cep)
+
+	// Um erro por provedor
+	var lookupErr *services.LocationLookupError
+	assert.ErrorAs(t, err, &lookupErr)
+	assert.Len(t, lookupErr.Errors, 2)
+	assert.False(t, lookupErr.TimedOut)
+	assert.False(t, lookupErr.NotFound())
+	assert.ErrorIs(t, err, services.ErrCEPNotFound)
+	assert.Contains(t, err.Error(), "failing: connection reset")
+}
+
+func TestRaceCancelsLosers(t *testing.T) {
+	cep := "12345678"
+	city := "São Paulo"
+	location := models.Location{Cep: &cep, Localidade: &city, City: &city}
+
+	fast := &MockCEPProvider{ProviderName: "fast"}
+	fast.On("FetchLocation", cep).Return(location, nil)
+	slow := &blockingCEPProvider{cancelled: make(chan struct{})}
+
+	registry := services.NewCEPProviderRegistry()
+	assert.NoError(t, registry.Register(slow))
+	assert.NoError(t, registry.Register(fast))
+	locationService := services.NewLocationService(registry)
+
+	resultado, err := locationService.GetLocationFromCEP(context.Background(), cep)
+	assert.NoError(t, err)
+	assert.Equal(t, location, resultado)
+
+	// O provedor perdedor deve ser cancelado
+	select {
+	case <-slow.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("losing provider was not cancelled")
+	}
+}
+
+func TestGetLocationFromCEPCallerCancelled(t *testing.T) {
+	registry := services.NewCEPProviderRegistry()
+	assert.NoError(t, registry.Register(&blockingCEPProvider{cancelled: make(chan struct{})}))
+	locationService := services.NewLocationService(registry)
+
+	// Simula um cliente que desconecta
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := locationService.GetLocationFromCEP(ctx, "12345678")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// blockingCEPProvider nunca responde e sinaliza quando é cancelado
+type blockingCEPProvider struct {
+	cancelled chan struct{}
+}
+
+func (p *blockingCEPProvider) Name() string {
+	return "blocking"
+}
+
+func (p *blockingCEPProvider) FetchLocation(ctx context.Context, cep string) (models.Location, error) {
+	<-ctx.Done()
+	close(p.cancelled)
+	return models.Location{}, ctx.Err()
+}
 
-	// Verificando chamadas
-	mockLocationService.AssertExpectations(t)
+func TestCEPProviderRegistryConfigure(t *testing.T) {
+	registry := services.NewDefaultCEPProviderRegistry(new(MockApiClient), services.CEPProviderConfig{})
+
+	// Apenas os provedores padrão habilitados
+	assert.Equal(t, []string{"brasilapi", "viacep", "opencep", "awesomeapi"}, registry.Names())
+	assert.Len(t, registry.Providers(), 2)
+
+	// Reordena e habilita outros provedores
+	assert.NoError(t, registry.Configure([]string{"awesomeapi", "viacep"}))
+	enabled := registry.Providers()
+	assert.Equal(t, "awesomeapi", enabled[0].Name())
+	assert.Equal(t, "viacep", enabled[1].Name())
+	assert.Equal(t, []string{"awesomeapi", "viacep", "brasilapi", "opencep"}, registry.Names())
+
+	// Desabilita e reabilita
+	assert.NoError(t, registry.Disable("viacep"))
+	assert.Len(t, registry.Providers(), 1)
+	assert.NoError(t, registry.Enable("opencep"))
+	assert.Len(t, registry.Providers(), 2)
+
+	// Nomes desconhecidos e duplicados são rejeitados
+	assert.Error(t, registry.Configure([]string{"unknown"}))
+	assert.Error(t, registry.Enable("unknown"))
+	assert.Error(t, registry.Register(services.NewViaCEPProvider(new(MockApiClient), services.DefaultViaCEPBaseURL)))
+}
+
+func TestLocalCEPProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ceps.json")
+	err := os.WriteFile(path, []byte(`{"01001-000": {"city": "São Paulo", "uf": "SP", "neighborhood": "Sé"}}`), 0o600)
+	assert.NoError(t, err)
+
+	provider, err := services.NewLocalCEPProvider(path)
+	assert.NoError(t, err)
+
+	location, err := provider.FetchLocation(context.Background(), "01001000")
+	assert.NoError(t, err)
+	assert.Equal(t, "São Paulo", *location.City)
+	assert.Equal(t, "SP", *location.Uf)
+
+	_, err = provider.FetchLocation(context.Background(), "99999999")
+	assert.Error(t, err)
 }
 
 func TestHttpFetchSuccess(t *testing.T) {
 	mockApiClient := new(MockApiClient)
 	apiKey := os.Getenv("WEATHER_API_KEY")
-	weatherService := services.NewWeatherService(mockApiClient)
+	weatherService := newWeatherAPIService(mockApiClient)
 
 	// Mock do retorno do método Get
 	mockApiClient.On("Get", fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%v&q=SP", apiKey)).
@@ -80,23 +215,22 @@ func TestHttpFetchSuccess(t *testing.T) {
 	}, nil).Once()
 
 	// Teste para a URL "SP"
-	response, err := weatherService.GetTemperature("SP")
+	sp := "SP"
+	response, err := weatherService.GetCurrentWeather(context.Background(), models.Location{City: &sp})
 	assert.NoError(t, err)
-	assert.Equal(t, 13.14, response)
+	assert.Equal(t, 13.14, response.TempC)
 
 	// Teste para a URL "other-city" (outro valor)
-	response, err = weatherService.GetTemperature("other-city")
+	otherCity := "other-city"
+	response, err = weatherService.GetCurrentWeather(context.Background(), models.Location{City: &otherCity})
 	assert.NoError(t, err)
-	assert.Equal(t, 13.0, response)
+	assert.Equal(t, 13.0, response.TempC)
 }
 
 func TestHttpFetchNotFound(t *testing.T) {
 	cep := "11111111"
-	chBrasilAPI := make(chan models.Location)
-	chViaCEP := make(chan models.Location)
 	mockApiClient := new(MockApiClient)
-	weatherService := services.NewWeatherService(mockApiClient)
-	locationService := services.NewLocationService(weatherService)
+	locationService := services.NewLocationService(services.NewDefaultCEPProviderRegistry(mockApiClient, services.CEPProviderConfig{}))
 
 	// Mock do retorno do método Get
 	mockApiClient.On("Get", mock.Anything).
@@ -106,8 +240,58 @@ func TestHttpFetchNotFound(t *testing.T) {
 		}, nil)
 
 		// Teste para a URL "SP"
-	response, err := locationService.GetLocationFromCEP(cep, chBrasilAPI, chViaCEP)
+	response, err := locationService.GetLocationFromCEP(context.Background(), cep)
 
 	assert.Equal(t, models.Location{}, response)
 	assert.NotEqual(t, nil, err)
+
+	// Todos os provedores responderam que o CEP não existe
+	var lookupErr *services.LocationLookupError
+	assert.ErrorAs(t, err, &lookupErr)
+	assert.True(t, lookupErr.NotFound())
+}
+
+func TestBrasilAPIProviderCoordinates(t *testing.T) {
+	cep := "01001000"
+	mockApiClient := new(MockApiClient)
+	mockApiClient.On("Get", fmt.Sprintf("https://brasilapi.com.br/api/cep/v2/%s", cep)).
+		Return(&http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"cep":"01001000","state":"SP","city":"São Paulo","neighborhood":"Sé","street":"Praça da Sé","service":"open-cep","location":{"type":"Point","coordinates":{"longitude":"-46.6333","latitude":"-23.5505"}}}`))),
+		}, nil)
+
+	location, err := services.NewBrasilAPIProvider(mockApiClient, services.DefaultBrasilAPIBaseURL).FetchLocation(context.Background(), cep)
+	assert.NoError(t, err)
+	assert.Equal(t, -23.5505, *location.Latitude)
+	assert.Equal(t, -46.6333, *location.Longitude)
+}
+
+func TestHttpFetchByCoordinates(t *testing.T) {
+	mockApiClient := new(MockApiClient)
+	apiKey := os.Getenv("WEATHER_API_KEY")
+	weatherService := newWeatherAPIService(mockApiClient)
+
+	// Coordenadas têm prioridade sobre o nome da cidade
+	mockApiClient.On("Get", fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%v&q=-23.5505%%2C-46.6333", apiKey)).
+		Return(&http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"current": {"temp_c":19.5}}`))),
+		}, nil).Once()
+
+	city, uf := "Bom Jesus", "PI"
+	latitude, longitude := -23.5505, -46.6333
+	response, err := weatherService.GetCurrentWeather(context.Background(), models.Location{City: &city, Uf: &uf, Latitude: &latitude, Longitude: &longitude})
+	assert.NoError(t, err)
+	assert.Equal(t, 19.5, response.TempC)
+
+	// Sem coordenadas, a UF diferencia cidades homônimas
+	mockApiClient.On("Get", fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%v&q=Bom+Jesus%%2CPI%%2CBrazil", apiKey)).
+		Return(&http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"current": {"temp_c":31.0}}`))),
+		}, nil).Once()
+
+	response, err = weatherService.GetCurrentWeather(context.Background(), models.Location{City: &city, Uf: &uf})
+	assert.NoError(t, err)
+	assert.Equal(t, 31.0, response.TempC)
 }
@@ -0,0 +1,226 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"post-graduation-exercise-cloud-run-weather-api/handlers"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+)
+
+// recordingExporter guarda os spans finalizados em memória
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []shared.SpanData
+}
+
+func (e *recordingExporter) Export(span shared.SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// byName retorna os spans com o nome informado
+func (e *recordingExporter) byName(name string) []shared.SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var spans []shared.SpanData
+	for _, span := range e.spans {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestParseTraceparent(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := shared.ParseTraceparent(header)
+	assert.True(t, ok)
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, header, sc.Traceparent())
+
+	// Versões futuras podem acrescentar campos
+	_, ok = shared.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	assert.True(t, ok)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",        // Sem flags
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",     // Trace ID zerado
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",     // Span ID zerado
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",     // Versão inválida
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",     // Hexadecimal inválido
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz", // Campos extras na versão 00
+	} {
+		_, ok := shared.ParseTraceparent(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestTracingWeatherRequest(t *testing.T) {
+	// Origens falsas que guardam o traceparent recebido
+	requests := make(chan *http.Request, 10)
+	upstream := newFakeUpstream(t, map[string]string{
+		"/brasilapi/01001000":      `{"cep": "01001000", "state": "SP", "city": "São Paulo"}`,
+		"/viacep/01001000/json":    `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP"}`,
+		"/weatherapi/current.json": `{"current": {"temp_c": 25.0}}`,
+	}, requests)
+	apiClient := services.NewAPIClient(upstream.Client())
+	registry := services.NewDefaultCEPProviderRegistry(apiClient, services.CEPProviderConfig{
+		BrasilAPIBaseURL: upstream.URL + "/brasilapi",
+		ViaCEPBaseURL:    upstream.URL + "/viacep",
+	})
+	weatherService := services.NewWeatherService(services.NewWeatherAPIProvider(apiClient, upstream.URL+"/weatherapi", "test-key"))
+	handler := handlers.NewWeatherHandler(services.NewLocationService(registry), weatherService, &shared.TemperatureConverter{})
+
+	exporter := &recordingExporter{}
+	traced := handlers.TraceRoute(shared.NewTracer(exporter, 0), "/weather", handler.WeatherHandlerFunc())
+
+	// A requisição chega com o trace de quem chamou, que já foi amostrado
+	incoming := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/weather?cep=01001000", nil)
+	req.Header.Set("traceparent", incoming)
+	rr := httptest.NewRecorder()
+	traced.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// O span de servidor continua o trace recebido e é devolvido na resposta
+	server := exporter.byName("GET /weather")
+	if assert.Len(t, server, 1) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server[0].SpanContext.TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", server[0].Parent.String())
+		assert.Equal(t, 200, server[0].Attributes["http.status_code"])
+		assert.Equal(t, server[0].SpanContext.Traceparent(), rr.Header().Get("Traceparent"))
+	}
+	assert.Len(t, exporter.byName("validate cep"), 1)
+	assert.Len(t, exporter.byName("cep lookup"), 1)
+	assert.Len(t, exporter.byName("weather current"), 1)
+
+	// Cada provedor de CEP tem seu span e apenas um deles vence a disputa
+	assert.Eventually(t, func() bool {
+		return len(exporter.byName("cep brasilapi")) == 1 && len(exporter.byName("cep viacep")) == 1
+	}, time.Second, 10*time.Millisecond)
+	winners := 0
+	for _, span := range append(exporter.byName("cep brasilapi"), exporter.byName("cep viacep")...) {
+		if span.Attributes["cep.outcome"] == "winner" {
+			winners++
+		}
+		assert.Contains(t, []any{"winner", "late", "cancelled"}, span.Attributes["cep.outcome"])
+	}
+	assert.Equal(t, 1, winners)
+
+	// As chamadas às origens levam o traceparent do span de cliente
+	clientSpans := exporter.byName("GET " + upstream.Listener.Addr().String())
+	assert.NotEmpty(t, clientSpans)
+	for len(requests) > 0 {
+		request := <-requests
+		sc, ok := shared.ParseTraceparent(request.Header.Get("traceparent"))
+		assert.True(t, ok)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	}
+}
+
+func TestTracingNotSampled(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := shared.NewTracer(exporter, 0)
+
+	// Um novo trace com SampleRatio 0 ainda propaga o contexto, mas nada é exportado
+	ctx, span := tracer.Start(context.Background(), "root", shared.SpanKindServer)
+	_, child := shared.StartSpan(ctx, "child", shared.SpanKindInternal)
+	child.End()
+	span.End()
+
+	assert.Equal(t, span.SpanContext.TraceID, child.SpanContext.TraceID)
+	assert.Equal(t, span.SpanContext.SpanID, child.Parent)
+	assert.Empty(t, exporter.spans)
+
+	// Sem span no contexto, StartSpan não faz nada
+	_, orphan := shared.StartSpan(context.Background(), "orphan", shared.SpanKindInternal)
+	assert.Nil(t, orphan)
+	orphan.SetAttribute("key", "value")
+	orphan.End()
+}
+
+func TestAPIClientRedactsKeyFromErrors(t *testing.T) {
+	// Uma origem que já foi desligada faz a chamada falhar no transporte
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	upstream.Close()
+
+	exporter := &recordingExporter{}
+	ctx, root := shared.NewTracer(exporter, 1).Start(context.Background(), "GET /weather", shared.SpanKindServer)
+	_, err := services.NewAPIClient(upstream.Client()).Get(ctx, upstream.URL+"/v1/current.json?key=SECRET&q=-23.5,-46.6")
+	root.End()
+
+	// O erro mantém o caminho, mas nem ele nem o span carregam a chave
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "/v1/current.json")
+	assert.NotContains(t, err.Error(), "SECRET")
+	var urlErr *url.Error
+	assert.ErrorAs(t, err, &urlErr)
+
+	client := exporter.byName("GET " + strings.TrimPrefix(upstream.URL, "http://"))
+	assert.Len(t, client, 1)
+	assert.NotEmpty(t, client[0].Error)
+	assert.NotContains(t, client[0].Error, "SECRET")
+}
+
+func TestOTLPExporter(t *testing.T) {
+	// Coletor local que guarda os corpos recebidos
+	bodies := make(chan []byte, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer collector.Close()
+
+	exporter := shared.NewOTLPExporter(collector.URL+"/", "weather-api")
+	tracer := shared.NewTracer(exporter, 1)
+	_, span := tracer.Start(context.Background(), "GET /weather", shared.SpanKindServer)
+	span.SetAttribute("http.status_code", 502)
+	span.SetError(errors.New("upstream unavailable"))
+	span.End()
+
+	// Close envia os spans pendentes
+	assert.NoError(t, exporter.Close())
+
+	var payload struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []map[string]any `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []map[string]any `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	assert.NoError(t, json.Unmarshal(<-bodies, &payload))
+	resource := payload.ResourceSpans[0]
+	assert.Equal(t, map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "weather-api"}}, resource.Resource.Attributes[0])
+
+	exported := resource.ScopeSpans[0].Spans[0]
+	assert.Equal(t, span.SpanContext.TraceID.String(), exported["traceId"])
+	assert.Equal(t, "GET /weather", exported["name"])
+	assert.Equal(t, 2.0, exported["kind"]) // SPAN_KIND_SERVER
+	assert.Equal(t, map[string]any{"code": 2.0, "message": "upstream unavailable"}, exported["status"])
+	assert.Equal(t, []any{map[string]any{"key": "http.status_code", "value": map[string]any{"intValue": "502"}}}, exported["attributes"])
+
+	// Depois de fechado, novos spans são descartados sem erro
+	_, late := tracer.Start(context.Background(), "late", shared.SpanKindServer)
+	late.End()
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"post-graduation-exercise-cloud-run-weather-api/shared"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testHedgeConfig = services.HedgeConfig{Percentile: 95, MinDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+func TestHedgedWeatherProviderSlowPrimary(t *testing.T) {
+	city := "São Paulo"
+	slow := newMockWeatherProvider("weatherapi", 5*time.Second, 20, nil)
+	backup := newMockWeatherProvider("openmeteo", 10*time.Millisecond, 22, nil)
+	provider := services.NewHedgedWeatherProvider(slow, backup, testHedgeConfig)
+	assert.Equal(t, "hedge(weatherapi,openmeteo)", provider.Name())
+
+	start := time.Now()
+	weather, err := provider.CurrentWeather(context.Background(), models.Location{City: &city})
+
+	// A reserva sai após a espera máxima e vence; o primário lento é cancelado
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []string{"openmeteo"}, weather.Providers)
+	slow.AssertNotCalled(t, "CurrentWeather", mock.Anything)
+}
+
+func TestHedgedWeatherProviderFastPrimary(t *testing.T) {
+	city := "São Paulo"
+	fast := newMockWeatherProvider("weatherapi", 0, 20, nil)
+	backup := newMockWeatherProvider("openmeteo", 0, 22, nil)
+	provider := services.NewHedgedWeatherProvider(fast, backup, testHedgeConfig)
+
+	// Um primário rápido nunca é duplicado
+	weather, err := provider.CurrentWeather(context.Background(), models.Location{City: &city})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"weatherapi"}, weather.Providers)
+	backup.AssertNotCalled(t, "CurrentWeather", mock.Anything)
+
+	// Um primário que falha antes da espera devolve o próprio erro, sem reserva
+	failing := newMockWeatherProvider("weatherapi", 0, 0, services.ErrUpstreamQuota)
+	provider = services.NewHedgedWeatherProvider(failing, backup, testHedgeConfig)
+	_, err = provider.CurrentWeather(context.Background(), models.Location{City: &city})
+	assert.ErrorIs(t, err, services.ErrUpstreamQuota)
+	backup.AssertNotCalled(t, "CurrentWeather", mock.Anything)
+}
+
+func TestHedgedWeatherProviderBothFail(t *testing.T) {
+	city := "São Paulo"
+	slow := newMockWeatherProvider("weatherapi", 100*time.Millisecond, 0, services.ErrUpstreamUnavailable)
+	backup := newMockWeatherProvider("openmeteo", 0, 0, errors.New("boom"))
+	provider := services.NewHedgedWeatherProvider(slow, backup, testHedgeConfig)
+
+	_, err := provider.CurrentWeather(context.Background(), models.Location{City: &city})
+
+	var lookupErr *services.WeatherLookupError
+	assert.True(t, errors.As(err, &lookupErr))
+	assert.Len(t, lookupErr.Errors, 2)
+	assert.ErrorIs(t, err, services.ErrUpstreamUnavailable)
+}
+
+func TestLatencyTrackerPercentile(t *testing.T) {
+	tracker := shared.NewLatencyTracker(100, 10)
+
+	// Poucas amostras ainda não dão uma estimativa
+	tracker.Observe(time.Second)
+	_, ok := tracker.Percentile(95)
+	assert.False(t, ok)
+
+	// O buffer circular guarda apenas as 100 últimas: 1ms a 100ms
+	for i := 1; i <= 100; i++ {
+		tracker.Observe(time.Duration(i) * time.Millisecond)
+	}
+	p95, ok := tracker.Percentile(95)
+	assert.True(t, ok)
+	assert.Equal(t, 95*time.Millisecond, p95)
+	p100, _ := tracker.Percentile(100)
+	assert.Equal(t, 100*time.Millisecond, p100)
+}
@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeUpstream sobe um servidor local que responde cada caminho com o corpo informado
+func newFakeUpstream(t *testing.T, responses map[string]string, requests chan<- *http.Request) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests != nil {
+			requests <- r
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWeatherAPIProviderForecast(t *testing.T) {
+	server := newFakeUpstream(t, map[string]string{
+		"/v1/forecast.json": `{"forecast": {"forecastday": [{"date": "2024-01-01", "day": {"maxtemp_c": 30, "mintemp_c": 20, "avgtemp_c": 25, "totalprecip_mm": 1.5, "daily_chance_of_rain": 80, "condition": {"text": "Patchy rain nearby", "icon": "//cdn.weatherapi.com/176.png", "code": 1063}}, "hour": [{"time": "2024-01-01 00:00", "temp_c": 21, "chance_of_rain": 10, "condition": {"text": "Clear", "code": 1000}}]}]}}`,
+	}, nil)
+	provider := services.NewWeatherAPIProvider(services.NewAPIClient(server.Client()), server.URL+"/v1", "test-key")
+
+	city, uf := "São Paulo", "SP"
+	forecast, err := provider.Forecast(context.Background(), models.Location{City: &city, Uf: &uf}, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"weatherapi"}, forecast.Providers)
+	assert.Equal(t, []models.ForecastDay{{
+		Date:         "2024-01-01",
+		MinTempC:     20,
+		MaxTempC:     30,
+		AvgTempC:     25,
+		Condition:    models.WeatherCondition{Text: "Patchy rain nearby", Icon: "//cdn.weatherapi.com/176.png", Code: 1063},
+		ChanceOfRain: 80,
+		PrecipMm:     1.5,
+		Hours:        []models.ForecastHour{{Time: "2024-01-01 00:00", TempC: 21, Condition: models.WeatherCondition{Text: "Clear", Code: 1000}, ChanceOfRain: 10}},
+	}}, forecast.Days)
+}
+
+func TestOpenMeteoProviderCurrentWeatherByCoordinates(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	server := newFakeUpstream(t, map[string]string{
+		"/v1/forecast": `{"utc_offset_seconds": -10800, "current": {"time": 1700000000, "temperature_2m": 24.3, "relative_humidity_2m": 70, "apparent_temperature": 26.1, "is_day": 1, "precipitation": 0.2, "weather_code": 2, "cloud_cover": 40, "pressure_msl": 1013.2, "wind_speed_10m": 12.6, "wind_direction_10m": 135, "wind_gusts_10m": 25.2, "uv_index": 6.5, "visibility": 24140}}`,
+	}, requests)
+	provider := services.NewOpenMeteoProvider(services.NewAPIClient(server.Client()), server.URL+"/v1", server.URL+"/v1", server.URL+"/v1")
+
+	latitude, longitude := -23.5505, -46.6333
+	weather, err := provider.CurrentWeather(context.Background(), models.Location{Latitude: &latitude, Longitude: &longitude})
+
+	// Coordenadas conhecidas dispensam a geocodificação
+	assert.NoError(t, err)
+	request := <-requests
+	assert.Equal(t, "-23.5505", request.URL.Query().Get("latitude"))
+	assert.Equal(t, "-46.6333", request.URL.Query().Get("longitude"))
+	assert.Equal(t, "unixtime", request.URL.Query().Get("timeformat"))
+
+	assert.Equal(t, []string{"openmeteo"}, weather.Providers)
+	assert.Equal(t, 24.3, weather.TempC)
+	assert.Equal(t, 26.1, weather.FeelsLikeC)
+	assert.Equal(t, models.WeatherCondition{Text: "Partly cloudy", Code: 2}, weather.Condition)
+	assert.True(t, weather.IsDay)
+	assert.Equal(t, "SE", weather.WindDir)
+	assert.Equal(t, 24.14, weather.VisKm)
+	assert.Equal(t, time.Unix(1700000000, 0), weather.LastUpdated)
+}
+
+func TestOpenMeteoProviderForecastGeocodesCity(t *testing.T) {
+	// Dois "Bom Jesus": o resultado do estado errado deve ser ignorado
+	requests := make(chan *http.Request, 4)
+	server := newFakeUpstream(t, map[string]string{
+		"/v1/search": `{"results": [
+			{"name": "Bom Jesus", "latitude": -28.67, "longitude": -50.43, "country_code": "BR", "admin1": "Rio Grande do Sul"},
+			{"name": "Bom Jesus", "latitude": -9.07, "longitude": -44.36, "country_code": "BR", "admin1": "Piauí"}
+		]}`,
+		// 2024-01-01 e 2024-01-02 à meia-noite em UTC-3, com duas horas no primeiro dia
+		"/v1/forecast": `{"utc_offset_seconds": -10800,
+			"daily": {"time": [1704078000, 1704164400], "weather_code": [61, 0], "temperature_2m_max": [33, 35], "temperature_2m_min": [22, 23], "precipitation_sum": [4.2, 0], "precipitation_probability_max": [90, 5]},
+			"hourly": {"time": [1704078000, 1704081600], "temperature_2m": [23, 24], "precipitation_probability": [50, 60], "weather_code": [3, 61]}}`,
+	}, requests)
+	provider := services.NewOpenMeteoProvider(services.NewAPIClient(server.Client()), server.URL+"/v1", server.URL+"/v1", server.URL+"/v1")
+
+	city, uf := "Bom Jesus", "PI"
+	forecast, err := provider.Forecast(context.Background(), models.Location{City: &city, Uf: &uf}, 2)
+
+	assert.NoError(t, err)
+	<-requests // Geocodificação
+	request := <-requests
+	assert.Equal(t, "-9.0700", request.URL.Query().Get("latitude"))
+	assert.Equal(t, "2", request.URL.Query().Get("forecast_days"))
+
+	if assert.Len(t, forecast.Days, 2) {
+		first := forecast.Days[0]
+		assert.Equal(t, "2024-01-01", first.Date)
+		assert.Equal(t, 23.5, first.AvgTempC) // Média das horas
+		assert.Equal(t, models.WeatherCondition{Text: "Slight rain", Code: 61}, first.Condition)
+		assert.Equal(t, 90, first.ChanceOfRain)
+		assert.Equal(t, []models.ForecastHour{
+			{Time: "2024-01-01 00:00", TempC: 23, Condition: models.WeatherCondition{Text: "Overcast", Code: 3}, ChanceOfRain: 50},
+			{Time: "2024-01-01 01:00", TempC: 24, Condition: models.WeatherCondition{Text: "Slight rain", Code: 61}, ChanceOfRain: 60},
+		}, first.Hours)
+
+		second := forecast.Days[1]
+		assert.Equal(t, "2024-01-02", second.Date)
+		assert.Equal(t, 29.0, second.AvgTempC) // Sem horas, usa o ponto médio dos extremos
+		assert.Empty(t, second.Hours)
+	}
+
+	// A cidade geocodificada fica em cache
+	_, err = provider.Forecast(context.Background(), models.Location{City: &city, Uf: &uf}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/forecast", (<-requests).URL.Path)
+}
+
+func TestOpenWeatherMapProviderCurrentWeather(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	server := newFakeUpstream(t, map[string]string{
+		"/data/2.5/weather": `{"weather": [{"id": 803, "main": "Clouds", "description": "broken clouds", "icon": "04d"}], "main": {"temp": 21.5, "feels_like": 21.9, "pressure": 1017, "humidity": 83}, "visibility": 10000, "wind": {"speed": 5, "deg": 350, "gust": 7.5}, "clouds": {"all": 75}, "dt": 1700000000, "sys": {"sunrise": 1699980000, "sunset": 1700030000}, "timezone": -10800}`,
+	}, requests)
+	provider := services.NewOpenWeatherMapProvider(services.NewAPIClient(server.Client()), server.URL, "test-key")
+
+	city, uf := "Curitiba", "PR"
+	weather, err := provider.CurrentWeather(context.Background(), models.Location{City: &city, Uf: &uf})
+
+	assert.NoError(t, err)
+	request := <-requests
+	assert.Equal(t, "Curitiba,BR", request.URL.Query().Get("q"))
+	assert.Equal(t, "metric", request.URL.Query().Get("units"))
+	assert.Equal(t, "test-key", request.URL.Query().Get("appid"))
+
+	assert.Equal(t, []string{"openweathermap"}, weather.Providers)
+	assert.Equal(t, 21.5, weather.TempC)
+	assert.Equal(t, models.WeatherCondition{Text: "broken clouds", Icon: "https://openweathermap.org/img/wn/04d@2x.png", Code: 803}, weather.Condition)
+	assert.Equal(t, 18.0, weather.WindKph) // 5 m/s
+	assert.Equal(t, 27.0, weather.GustKph) // 7,5 m/s
+	assert.Equal(t, "N", weather.WindDir)
+	assert.Equal(t, 10.0, weather.VisKm)
+	assert.True(t, weather.IsDay)
+}
+
+func TestOpenWeatherMapProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"cod": 401, "message": "Invalid API key."}`)
+	}))
+	defer server.Close()
+	provider := services.NewOpenWeatherMapProvider(services.NewAPIClient(server.Client()), server.URL, "wrong-key")
+
+	city := "Curitiba"
+	_, err := provider.CurrentWeather(context.Background(), models.Location{City: &city})
+	assert.ErrorIs(t, err, services.ErrUpstreamAuth)
+	assert.ErrorContains(t, err, "Invalid API key.")
+
+	// Histórico e previsões além de 5 dias não são suportados
+	_, err = provider.History(context.Background(), models.Location{City: &city}, time.Now(), time.Now())
+	assert.ErrorIs(t, err, services.ErrUnsupported)
+	_, err = provider.Forecast(context.Background(), models.Location{City: &city}, 7)
+	assert.ErrorIs(t, err, services.ErrUnsupported)
+}
+
+func TestNewWeatherProvider(t *testing.T) {
+	for _, name := range services.WeatherProviderNames {
+		provider, err := services.NewWeatherProvider(name, new(MockApiClient), services.WeatherProviderConfig{})
+		assert.NoError(t, err)
+		assert.Equal(t, name, provider.Name())
+	}
+
+	_, err := services.NewWeatherProvider("accuweather", new(MockApiClient), services.WeatherProviderConfig{})
+	assert.ErrorContains(t, err, `unknown weather provider "accuweather"`)
+}
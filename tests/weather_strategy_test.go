@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"post-graduation-exercise-cloud-run-weather-api/models"
+	"post-graduation-exercise-cloud-run-weather-api/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newMockWeatherProvider cria um provedor que responde a temperatura informada
+func newMockWeatherProvider(name string, delay time.Duration, tempC float64, err error) *MockWeatherProvider {
+	provider := &MockWeatherProvider{ProviderName: name, Delay: delay}
+	provider.On("CurrentWeather", mock.Anything).
+		Return(models.CurrentWeather{Providers: []string{name}, TempC: tempC, FeelsLikeC: tempC + 1}, err)
+	return provider
+}
+
+func TestFailoverWeatherProvider(t *testing.T) {
+	city := "São Paulo"
+	quota := &services.UpstreamError{Provider: "weatherapi", Kind: services.ErrUpstreamQuota}
+
+	// O primeiro falha, o segundo estoura o tempo por tentativa e o terceiro responde
+	failing := newMockWeatherProvider("weatherapi", 0, 0, quota)
+	slow := newMockWeatherProvider("openweathermap", time.Second, 20, nil)
+	healthy := newMockWeatherProvider("openmeteo", 0, 22, nil)
+	provider, err := services.NewWeatherStrategy(services.WeatherStrategyFailover,
+		[]services.WeatherProvider{failing, slow, healthy},
+		services.WeatherStrategyConfig{AttemptTimeout: 50 * time.Millisecond})
+	assert.NoError(t, err)
+
+	weather, err := provider.CurrentWeather(context.Background(), models.Location{City: &city})
+	assert.NoError(t, err)
+	assert.Equal(t, 22.0, weather.TempC)
+	assert.Equal(t, []string{"openmeteo"}, weather.Providers)
+	slow.AssertNotCalled(t, "CurrentWeather", mock.Anything)
+
+	// Quando todos falham, os motivos continuam classificáveis
+	provider, _ = services.NewWeatherStrategy(services.WeatherStrategyFailover,
+		[]services.WeatherProvider{failing, slow},
+		services.WeatherStrategyConfig{AttemptTimeout: 50 * time.Millisecond})
+	_, err = provider.CurrentWeather(context.Background(), models.Location{City: &city})
+
+	var lookupErr *services.WeatherLookupError
+	assert.True(t, errors.As(err, &lookupErr))
+	assert.Len(t, lookupErr.Errors, 2)
+	assert.ErrorIs(t, err, services.ErrUpstreamQuota)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRaceWeatherProvider(t *testing.T) {
+	city := "São Paulo"
+	slow := newMockWeatherProvider("weatherapi", 5*time.Second, 20, nil)
+	fast := newMockWeatherProvider("openmeteo", 10*time.Millisecond, 22, nil)
+	provider, err := services.NewWeatherStrategy(services.WeatherStrategyRace,
+		[]services.WeatherProvider{slow, fast}, services.DefaultWeatherStrategyConfig)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	weather, err := provider.CurrentWeather(context.Background(), models.Location{City: &city})
+
+	// O mais rápido vence sem esperar o mais lento, que é cancelado
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []string{"openmeteo"}, weather.Providers)
+	slow.AssertNotCalled(t, "CurrentWeather", mock.Anything)
+}
+
+func TestConsensusWeatherProviderCurrentWeather(t *testing.T) {
+	city := "São Paulo"
+	first := newMockWeatherProvider("weatherapi", 0, 20, nil)
+	second := newMockWeatherProvider("openmeteo", 0, 21, nil)
+	outlier := newMockWeatherProvider("openweathermap", 0, 30, nil)
+	failing := newMockWeatherProvider("broken", 0, 0, errors.New("boom"))
+	provider, err := services.NewWeatherStrategy(services.WeatherStrategyConsensus,
+		[]services.WeatherProvider{first, second, outlier, failing}, services.DefaultWeatherStrategyConfig)
+	assert.NoError(t, err)
+
+	weather, err := provider.CurrentWeather(context.Background(), models.Location{City: &city})
+
+	// Mediana dos que concordam; o discrepante e o que falhou ficam de fora
+	assert.NoError(t, err)
+	assert.Equal(t, 20.5, weather.TempC)
+	assert.Equal(t, 21.5, weather.FeelsLikeC)
+	assert.Equal(t, []string{"weatherapi", "openmeteo"}, weather.Providers)
+	assert.Equal(t, []string{"openweathermap"}, weather.Outliers)
+}
+
+func TestConsensusWeatherProviderForecast(t *testing.T) {
+	city := "São Paulo"
+	forecast := func(name string, avgTempC float64) *MockWeatherProvider {
+		provider := &MockWeatherProvider{ProviderName: name}
+		provider.On("Forecast", mock.Anything, 1).Return(models.Forecast{
+			Providers: []string{name},
+			Days:      []models.ForecastDay{{Date: "2024-01-01", MinTempC: avgTempC - 5, MaxTempC: avgTempC + 5, AvgTempC: avgTempC}},
+		}, nil)
+		return provider
+	}
+	provider, err := services.NewWeatherStrategy(services.WeatherStrategyConsensus,
+		[]services.WeatherProvider{forecast("weatherapi", 24), forecast("openmeteo", 25), forecast("openweathermap", 26), forecast("broken", 40)},
+		services.DefaultWeatherStrategyConfig)
+	assert.NoError(t, err)
+
+	result, err := provider.Forecast(context.Background(), models.Location{City: &city}, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ForecastDay{{Date: "2024-01-01", MinTempC: 20, MaxTempC: 30, AvgTempC: 25}}, result.Days)
+	assert.Equal(t, []string{"weatherapi", "openmeteo", "openweathermap"}, result.Providers)
+	assert.Equal(t, []string{"broken"}, result.Outliers)
+}
+
+func TestNewWeatherStrategy(t *testing.T) {
+	single := newMockWeatherProvider("openmeteo", 0, 22, nil)
+
+	// Um único provedor dispensa estratégia
+	provider, err := services.NewWeatherStrategy(services.WeatherStrategyConsensus, []services.WeatherProvider{single}, services.DefaultWeatherStrategyConfig)
+	assert.NoError(t, err)
+	assert.Same(t, single, provider)
+
+	_, err = services.NewWeatherStrategy("fastest", []services.WeatherProvider{single}, services.DefaultWeatherStrategyConfig)
+	assert.ErrorContains(t, err, `unknown weather strategy "fastest"`)
+}